@@ -0,0 +1,164 @@
+package uniswapV2
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrorInvalidAddress = errors.New("INVALID_ADDRESS")
+)
+
+// AddressFormat validates an address and returns its canonical form, the
+// same for every spelling of the address.
+type AddressFormat func(text string) (Address, error)
+
+// NormalizeAddress is the format of the addresses passed to pairs: Mint,
+// Burn, allowances, permits and balance lookups fail with its error or use
+// the canonical form, which balances, positions and events then hold.
+// OpaqueAddress by default. Like AddressLess it is set once before any
+// service is created.
+var NormalizeAddress AddressFormat = OpaqueAddress
+
+// ParseAddress returns the canonical form of an address by
+// NormalizeAddress.
+func ParseAddress(text string) (Address, error) {
+	return NormalizeAddress(text)
+}
+
+// OpaqueAddress takes any address as it is.
+func OpaqueAddress(text string) (Address, error) {
+	return Address(text), nil
+}
+
+// HexAddress takes 20-byte hex addresses with a 0x prefix in any case and
+// returns them in lower case.
+func HexAddress(text string) (Address, error) {
+	if len(text) != 42 || text[0] != '0' || text[1] != 'x' && text[1] != 'X' {
+		return "", ErrorInvalidAddress
+	}
+	if _, err := hex.DecodeString(text[2:]); err != nil {
+		return "", ErrorInvalidAddress
+	}
+	return Address("0x" + strings.ToLower(text[2:])), nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Bech32Address takes bech32 addresses of the human-readable part hrp, in
+// upper or lower case, and returns them in lower case.
+func Bech32Address(hrp string) AddressFormat {
+	hrp = strings.ToLower(hrp)
+	return func(text string) (Address, error) {
+		lower := strings.ToLower(text)
+		if len(text) > 90 || text != lower && text != strings.ToUpper(text) {
+			return "", ErrorInvalidAddress
+		}
+		separator := strings.LastIndexByte(lower, '1')
+		if separator < 1 || lower[:separator] != hrp || len(lower)-separator-1 < 6 {
+			return "", ErrorInvalidAddress
+		}
+		values := make([]int, 0, 2*len(hrp)+1+len(lower)-separator-1)
+		for _, c := range []byte(hrp) {
+			values = append(values, int(c>>5))
+		}
+		values = append(values, 0)
+		for _, c := range []byte(hrp) {
+			values = append(values, int(c&31))
+		}
+		for _, c := range []byte(lower[separator+1:]) {
+			value := strings.IndexByte(bech32Charset, c)
+			if value < 0 {
+				return "", ErrorInvalidAddress
+			}
+			values = append(values, value)
+		}
+		if bech32Polymod(values) != 1 {
+			return "", ErrorInvalidAddress
+		}
+		return Address(lower), nil
+	}
+}
+
+func bech32Polymod(values []int) int {
+	generator := [5]int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := 1
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ value
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+// normalizeAddresses replaces addresses with their canonical forms.
+func normalizeAddresses(addresses ...*Address) error {
+	for _, address := range addresses {
+		normalized, err := NormalizeAddress(string(*address))
+		if err != nil {
+			return err
+		}
+		*address = normalized
+	}
+	return nil
+}
+
+// normalizeHolders replaces the addresses holding balances, allowances and
+// nonces of a decoded pair with their canonical forms, leaving addressZero,
+// the holder of the minimumLiquidity. Balances of addresses spelled alike
+// are summed and their nonces merged to the highest; an owner with two
+// allowances of the same spender fails with ErrorInvalidAddress, as neither
+// can be chosen.
+func normalizeHolders(pair *Pair) error {
+	normalize := func(address *Address) error {
+		if *address == addressZero {
+			return nil
+		}
+		return normalizeAddresses(address)
+	}
+	balances := make(map[Address]*big.Int, len(pair.balances))
+	for address, balance := range pair.balances {
+		if err := normalize(&address); err != nil {
+			return err
+		}
+		if held, ok := balances[address]; ok {
+			balance = new(big.Int).Add(held, balance)
+		}
+		balances[address] = balance
+	}
+	allowances := make(map[Address]map[Address]*big.Int, len(pair.allowances))
+	for owner, spenders := range pair.allowances {
+		if err := normalize(&owner); err != nil {
+			return err
+		}
+		if allowances[owner] == nil {
+			allowances[owner] = make(map[Address]*big.Int, len(spenders))
+		}
+		for spender, amount := range spenders {
+			if err := normalize(&spender); err != nil {
+				return err
+			}
+			if _, ok := allowances[owner][spender]; ok {
+				return ErrorInvalidAddress
+			}
+			allowances[owner][spender] = amount
+		}
+	}
+	nonces := make(map[Address]uint64, len(pair.nonces))
+	for address, nonce := range pair.nonces {
+		if err := normalize(&address); err != nil {
+			return err
+		}
+		if held, ok := nonces[address]; !ok || nonce > held {
+			nonces[address] = nonce
+		}
+	}
+	pair.balances, pair.allowances, pair.nonces = balances, allowances, nonces
+	return nil
+}
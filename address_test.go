@@ -0,0 +1,73 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestHexAddress(t *testing.T) {
+	address, err := HexAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != "0x6b175474e89094c44da98b954eedeac495271d0f" {
+		t.Errorf("address want lower case, got %s", address)
+	}
+	for _, text := range []string{"", "6b175474e89094c44da98b954eedeac495271d0f", "0x6b175474e89094c44da98b954eedeac495271d0", "0x6b175474e89094c44da98b954eedeac495271d0g"} {
+		if _, err := HexAddress(text); err != ErrorInvalidAddress {
+			t.Errorf("%q failed with %v; want error %v", text, err, ErrorInvalidAddress)
+		}
+	}
+}
+
+func TestBech32Address(t *testing.T) {
+	for hrp, text := range map[string]string{"a": "A12UEL5L", "abcdef": "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw"} {
+		if _, err := Bech32Address(hrp)(text); err != nil {
+			t.Errorf("%q failed with %v", text, err)
+		}
+	}
+	if address, _ := Bech32Address("a")("A12UEL5L"); address != "a12uel5l" {
+		t.Errorf("address want lower case, got %s", address)
+	}
+	for _, text := range []string{"A12uEL5L", "a12uel5m", "b12uel5l", "a1", "a12uel5b"} {
+		if _, err := Bech32Address("a")(text); err != ErrorInvalidAddress {
+			t.Errorf("%q failed with %v; want error %v", text, err, ErrorInvalidAddress)
+		}
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	defer func(format AddressFormat) { NormalizeAddress = format }(NormalizeAddress)
+	NormalizeAddress = HexAddress
+
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixed, lower := Address("0x6B175474E89094C44Da98b954EedeAC495271d0F"), Address("0x6b175474e89094c44da98b954eedeac495271d0f")
+	liquidity, err := pair.Mint(mixed, big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	more, err := pair.Mint(lower, big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, balance := new(big.Int).Add(liquidity, more), pair.Balance(mixed); balance.Cmp(want) != 0 {
+		t.Errorf("balance want %s, got %s", want, balance)
+	}
+	if holders := pair.HoldersCount(); holders != 2 {
+		t.Errorf("holders want 2 with the zero address, got %d", holders)
+	}
+	if keys := service.PositionsOf(mixed); len(keys) != 1 {
+		t.Errorf("positions want 1, got %v", keys)
+	}
+	if _, _, err := pair.Burn(lower, liquidity); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18)); !errors.Is(err, ErrorInvalidAddress) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidAddress)
+	}
+}
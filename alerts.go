@@ -0,0 +1,120 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sync"
+	"time"
+)
+
+type Alert struct {
+	Rule         string
+	CoinA, CoinB Token
+	At           time.Time
+	Value        *big.Rat
+}
+
+type alertObservation struct {
+	at                 time.Time
+	reserveA, reserveB *big.Int
+	totalSupply        *big.Int
+}
+
+type alertRule struct {
+	name         string
+	coinA, coinB Token
+	window       time.Duration
+	check        func(first, last alertObservation) (value *big.Rat, tripped bool)
+	history      []alertObservation
+	tripped      bool
+}
+
+// Alerts evaluates threshold rules against the pairs of a service every time
+// Check is called. notify is called once when a rule trips and again only
+// after it has recovered and tripped anew.
+type Alerts struct {
+	service *UniswapV2
+	notify  func(Alert)
+
+	mu    sync.Mutex
+	rules []*alertRule
+}
+
+func NewAlerts(service *UniswapV2, notify func(Alert)) *Alerts {
+	return &Alerts{service: service, notify: notify}
+}
+
+// ReserveBelow trips when the reserve of coinA in the pair drops below threshold.
+func (a *Alerts) ReserveBelow(coinA, coinB Token, threshold *big.Int) {
+	a.add(&alertRule{name: "reserve_below", coinA: coinA, coinB: coinB, check: func(_, last alertObservation) (*big.Rat, bool) {
+		return new(big.Rat).SetInt(last.reserveA), last.reserveA.Cmp(threshold) == -1
+	}})
+}
+
+// PriceMove trips when the price of coinA in coinB changes by more than
+// threshold (e.g. 1/10 for 10%) within window.
+func (a *Alerts) PriceMove(coinA, coinB Token, threshold *big.Rat, window time.Duration) {
+	a.add(&alertRule{name: "price_move", coinA: coinA, coinB: coinB, window: window, check: func(first, last alertObservation) (*big.Rat, bool) {
+		if first.reserveA.Sign() != 1 || last.reserveA.Sign() != 1 || first.reserveB.Sign() != 1 {
+			return nil, false
+		}
+		change := relativeChange(new(big.Rat).SetFrac(first.reserveB, first.reserveA), new(big.Rat).SetFrac(last.reserveB, last.reserveA))
+		return change, change.Cmp(threshold) == 1
+	}})
+}
+
+// SupplyChange trips when the LP total supply changes by more than threshold within window.
+func (a *Alerts) SupplyChange(coinA, coinB Token, threshold *big.Rat, window time.Duration) {
+	a.add(&alertRule{name: "supply_change", coinA: coinA, coinB: coinB, window: window, check: func(first, last alertObservation) (*big.Rat, bool) {
+		if first.totalSupply.Sign() != 1 {
+			return nil, false
+		}
+		change := relativeChange(new(big.Rat).SetInt(first.totalSupply), new(big.Rat).SetInt(last.totalSupply))
+		return change, change.Cmp(threshold) == 1
+	}})
+}
+
+func (a *Alerts) add(rule *alertRule) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.rules = append(a.rules, rule)
+}
+
+// Check observes the current state at the given time and returns the alerts
+// that tripped.
+func (a *Alerts) Check(at time.Time) []Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var alerts []Alert
+	for _, rule := range a.rules {
+		pair := a.service.Pair(rule.coinA, rule.coinB)
+		if pair == nil {
+			continue
+		}
+		reserveA, reserveB := pair.Reserves()
+		last := alertObservation{at: at, reserveA: reserveA, reserveB: reserveB, totalSupply: pair.TotalSupply()}
+
+		rule.history = append(rule.history, last)
+		for len(rule.history) > 1 && at.Sub(rule.history[0].at) > rule.window {
+			rule.history = rule.history[1:]
+		}
+
+		value, tripped := rule.check(rule.history[0], last)
+		if tripped && !rule.tripped {
+			alert := Alert{Rule: rule.name, CoinA: rule.coinA, CoinB: rule.coinB, At: at, Value: value}
+			alerts = append(alerts, alert)
+			if a.notify != nil {
+				a.notify(alert)
+			}
+		}
+		rule.tripped = tripped
+	}
+	return alerts
+}
+
+func relativeChange(from, to *big.Rat) *big.Rat {
+	change := new(big.Rat).Sub(to, from)
+	change.Abs(change)
+	return change.Quo(change, from)
+}
@@ -0,0 +1,57 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestAlerts_Check(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var notified []Alert
+	alerts := NewAlerts(service, func(alert Alert) {
+		notified = append(notified, alert)
+	})
+	alerts.ReserveBelow(1, 0, big.NewInt(9e17))
+	alerts.PriceMove(0, 1, big.NewRat(1, 10), time.Hour)
+	alerts.SupplyChange(0, 1, big.NewRat(1, 2), time.Hour)
+
+	start := time.Unix(1600000000, 0)
+	if tripped := alerts.Check(start); len(tripped) != 0 {
+		t.Fatalf("alerts want none, got %v", tripped)
+	}
+
+	_, _, err = pair.Swap(big.NewInt(2e17), big.NewInt(0), big.NewInt(0), big.NewInt(15e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tripped := alerts.Check(start.Add(time.Minute))
+	if len(tripped) != 2 || tripped[0].Rule != "reserve_below" || tripped[1].Rule != "price_move" {
+		t.Fatalf("alerts want reserve_below and price_move, got %v", tripped)
+	}
+	if len(notified) != 2 {
+		t.Errorf("notified want 2, got %d", len(notified))
+	}
+
+	if tripped := alerts.Check(start.Add(2 * time.Minute)); len(tripped) != 0 {
+		t.Errorf("alerts want none while still tripped, got %v", tripped)
+	}
+
+	_, err = pair.Mint("address", big.NewInt(2e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tripped = alerts.Check(start.Add(3 * time.Minute))
+	if len(tripped) != 1 || tripped[0].Rule != "supply_change" {
+		t.Fatalf("alerts want supply_change, got %v", tripped)
+	}
+}
@@ -0,0 +1,143 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrorInsufficientAllowance = errors.New("INSUFFICIENT_ALLOWANCE")
+	ErrorInvalidAmount         = errors.New("INVALID_AMOUNT")
+)
+
+// MaxAllowance is never decreased by TransferFrom, as uint(-1) in the contract.
+var MaxAllowance = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// Approve sets the amount of LP tokens of owner that spender may transfer.
+func (p *Pair) Approve(owner, spender Address, amount *big.Int) error {
+	if amount == nil {
+		return ErrorNilAmount
+	}
+	if err := normalizeAddresses(&owner, &spender); err != nil {
+		return err
+	}
+	if amount.Sign() == -1 || amount.Cmp(MaxAllowance) == 1 {
+		return ErrorInvalidAmount
+	}
+	p.saveForOp()
+	record := p.walRecord("approve", owner, amount)
+	record.To = spender
+	if err := p.service.writeWAL(record); err != nil {
+		return err
+	}
+
+	p.muBalance.Lock()
+	p.approve(owner, spender, amount)
+	p.muBalance.Unlock()
+
+	p.audit("approve", owner, auditAmounts{"value": amount.String(), "spender": string(spender)})
+	return nil
+}
+
+func (p *Pair) approve(owner, spender Address, amount *big.Int) {
+	spenders, ok := p.allowances[owner]
+	if !ok {
+		spenders = map[Address]*big.Int{}
+		p.allowances[owner] = spenders
+	}
+	if amount.Sign() == 0 {
+		delete(spenders, spender)
+		if len(spenders) == 0 {
+			delete(p.allowances, owner)
+		}
+	} else {
+		spenders[spender] = new(big.Int).Set(amount)
+	}
+	p.isDirtyAllowances = true
+}
+
+func (p *Pair) Allowance(owner, spender Address) *big.Int {
+	if err := normalizeAddresses(&owner, &spender); err != nil {
+		return big.NewInt(0)
+	}
+
+	p.muBalance.RLock()
+	defer p.muBalance.RUnlock()
+
+	allowance := p.allowances[owner][spender]
+	if allowance == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(allowance)
+}
+
+// TransferFrom moves amount of LP tokens of from to to on behalf of spender,
+// spending its allowance. A zero amount succeeds without an allowance or a
+// balance and changes nothing, as in ERC-20.
+func (p *Pair) TransferFrom(spender, from, to Address, amount *big.Int) error {
+	if amount == nil {
+		return ErrorNilAmount
+	}
+	if amount.Sign() == -1 {
+		return ErrorInvalidAmount
+	}
+	if err := normalizeAddresses(&spender, &from, &to); err != nil {
+		return err
+	}
+	p.saveForOp()
+	if err := p.transferFrom(spender, from, to, amount); err != nil {
+		return err
+	}
+
+	p.audit("transfer_from", spender, auditAmounts{"value": amount.String(), "from": string(from), "to": string(to)})
+	p.emit(Event{Kind: EventTransfer, Address: from, To: to, Liquidity: amount})
+	return nil
+}
+
+func (p *Pair) transferFrom(spender, from, to Address, amount *big.Int) error {
+	if amount.Sign() == 0 {
+		return nil
+	}
+
+	p.muBalance.Lock()
+	defer p.muBalance.Unlock()
+
+	allowance := p.allowances[from][spender]
+	if allowance == nil || allowance.Cmp(amount) == -1 {
+		return ErrorInsufficientAllowance
+	}
+	if balance := p.balances[from]; balance == nil || balance.Cmp(amount) == -1 {
+		return ErrorInsufficientBalance
+	}
+	record := p.walRecord("transfer_from", spender, amount)
+	record.From, record.To = from, to
+	if err := p.service.writeWAL(record); err != nil {
+		return err
+	}
+	if err := p.transfer(from, to, amount); err != nil {
+		return err
+	}
+	if allowance.Cmp(MaxAllowance) != 0 {
+		p.approve(from, spender, new(big.Int).Sub(allowance, amount))
+	}
+	return nil
+}
+
+// transfer moves LP tokens between addresses. The caller holds muBalance.
+func (p *Pair) transfer(from, to Address, amount *big.Int) error {
+	balance := p.balances[from]
+	if balance == nil || balance.Cmp(amount) == -1 {
+		return ErrorInsufficientBalance
+	}
+	if p.balances[to] == nil {
+		p.balances[to] = big.NewInt(0)
+	}
+	balance.Sub(balance, amount)
+	p.balances[to].Add(p.balances[to], amount)
+	p.touchBalance(from)
+	p.touchBalance(to)
+
+	p.service.updatePosition(from, p.key.sort(), balance)
+	p.service.updatePosition(to, p.key.sort(), p.balances[to])
+	return nil
+}
@@ -0,0 +1,71 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPair_TransferFrom(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("owner", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pair.Approve("owner", "spender", big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	if allowance := service.Pair(1, 0).Allowance("owner", "spender"); allowance.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("allowance want %d, got %s", 100, allowance)
+	}
+
+	for _, tt := range []struct {
+		amount int64
+		err    error
+	}{
+		{101, ErrorInsufficientAllowance},
+		{-1, ErrorInvalidAmount},
+		{0, nil},
+		{60, nil},
+		{60, ErrorInsufficientAllowance},
+		{40, nil},
+	} {
+		err := pair.TransferFrom("spender", "owner", "receiver", big.NewInt(tt.amount))
+		if err != tt.err {
+			t.Fatalf("failed with %v; want error %v", err, tt.err)
+		}
+	}
+	if balance := pair.Balance("receiver"); balance.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("receiver balance want %d, got %s", 100, balance)
+	}
+	if allowance := pair.Allowance("owner", "spender"); allowance.Sign() != 0 {
+		t.Errorf("allowance want 0, got %s", allowance)
+	}
+	if len(service.PositionsOf("receiver")) != 1 {
+		t.Errorf("receiver positions want 1, got %v", service.PositionsOf("receiver"))
+	}
+
+	if err := pair.Approve("receiver", "spender", MaxAllowance); err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.TransferFrom("spender", "receiver", "owner", big.NewInt(50)); err != nil {
+		t.Fatal(err)
+	}
+	if allowance := pair.Allowance("receiver", "spender"); allowance.Cmp(MaxAllowance) != 0 {
+		t.Errorf("allowance want %s, got %s", MaxAllowance, allowance)
+	}
+	if err := pair.TransferFrom("spender", "receiver", "owner", big.NewInt(51)); err != ErrorInsufficientBalance {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientBalance)
+	}
+
+	if err := pair.TransferFrom("stranger", "nobody", "receiver", big.NewInt(0)); err != nil {
+		t.Fatal(err)
+	}
+	if pair.Balance("nobody") != nil || len(service.PositionsOf("nobody")) != 0 {
+		t.Error("zero transfer from an unknown address changed its balance")
+	}
+}
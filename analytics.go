@@ -0,0 +1,292 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+var (
+	ErrorInsufficientObservations = errors.New("INSUFFICIENT_OBSERVATIONS")
+	ErrorInvalidTimestamp         = errors.New("INVALID_TIMESTAMP")
+)
+
+// LiquidityTokenValue returns the underlying amounts redeemable for one LP token.
+func (p *Pair) LiquidityTokenValue() (amount0, amount1 *big.Rat, err error) {
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+
+	if p.totalSupply.Sign() != 1 {
+		return nil, nil, ErrorInsufficientLiquidity
+	}
+
+	return new(big.Rat).SetFrac(p.reserve0, p.totalSupply), new(big.Rat).SetFrac(p.reserve1, p.totalSupply), nil
+}
+
+type LPValueObservation struct {
+	Timestamp time.Time
+	// Amount0 and Amount1 are the underlying amounts of one LP token.
+	Amount0, Amount1 *big.Rat
+}
+
+// Value0 is the value of one LP token in token0 at the spot price of the observation.
+func (o LPValueObservation) Value0() *big.Rat {
+	return new(big.Rat).Add(o.Amount0, o.Amount0)
+}
+
+// Value1 is the value of one LP token in token1 at the spot price of the observation.
+func (o LPValueObservation) Value1() *big.Rat {
+	return new(big.Rat).Add(o.Amount1, o.Amount1)
+}
+
+// Quote is the value of one LP token in a quote token given the prices of token0 and token1.
+func (o LPValueObservation) Quote(price0, price1 *big.Rat) *big.Rat {
+	value := new(big.Rat).Mul(o.Amount0, price0)
+	return value.Add(value, new(big.Rat).Mul(o.Amount1, price1))
+}
+
+// LPValueTWAP records the value of a pair LP token over time. Every
+// observation is assumed to hold until the next one.
+type LPValueTWAP struct {
+	mu           sync.RWMutex
+	observations []LPValueObservation
+}
+
+func (t *LPValueTWAP) Observe(pair *Pair, at time.Time) error {
+	amount0, amount1, err := pair.LiquidityTokenValue()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if n := len(t.observations); n != 0 && !at.After(t.observations[n-1].Timestamp) {
+		return ErrorInvalidTimestamp
+	}
+	t.observations = append(t.observations, LPValueObservation{Timestamp: at, Amount0: amount0, Amount1: amount1})
+	return nil
+}
+
+func (t *LPValueTWAP) Average0(from, to time.Time) (*big.Rat, error) {
+	return t.average(from, to, LPValueObservation.Value0)
+}
+
+func (t *LPValueTWAP) Average1(from, to time.Time) (*big.Rat, error) {
+	return t.average(from, to, LPValueObservation.Value1)
+}
+
+func (t *LPValueTWAP) AverageQuote(from, to time.Time, price0, price1 *big.Rat) (*big.Rat, error) {
+	return t.average(from, to, func(o LPValueObservation) *big.Rat {
+		return o.Quote(price0, price1)
+	})
+}
+
+func (t *LPValueTWAP) average(from, to time.Time, value func(LPValueObservation) *big.Rat) (*big.Rat, error) {
+	if !to.After(from) {
+		return nil, ErrorInvalidTimestamp
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if len(t.observations) == 0 || t.observations[0].Timestamp.After(from) {
+		return nil, ErrorInsufficientObservations
+	}
+
+	sum := new(big.Rat)
+	for i, observation := range t.observations {
+		start := observation.Timestamp
+		if start.Before(from) {
+			start = from
+		}
+		end := to
+		if i+1 < len(t.observations) && t.observations[i+1].Timestamp.Before(to) {
+			end = t.observations[i+1].Timestamp
+		}
+		if !end.After(start) {
+			continue
+		}
+		weight := new(big.Rat).SetInt64(int64(end.Sub(start)))
+		sum.Add(sum, weight.Mul(weight, value(observation)))
+	}
+
+	return sum.Quo(sum, new(big.Rat).SetInt64(int64(to.Sub(from)))), nil
+}
+
+// SpotValue prices liquidity at the current reserves. It can be moved by
+// anyone able to trade against the pair within the same block.
+func (p *Pair) SpotValue(liquidity *big.Int, price0, price1 *big.Rat) (*big.Rat, error) {
+	if err := checkAmounts(liquidity); err != nil {
+		return nil, err
+	}
+	if err := checkPrices(price0, price1); err != nil {
+		return nil, err
+	}
+	amount0, amount1, err := p.LiquidityTokenValue()
+	if err != nil {
+		return nil, err
+	}
+
+	value := LPValueObservation{Amount0: amount0, Amount1: amount1}.Quote(price0, price1)
+	return value.Mul(value, new(big.Rat).SetInt(liquidity)), nil
+}
+
+// FairValue prices liquidity as 2*sqrt(k*price0*price1)/totalSupply, which
+// depends only on the invariant and the external prices and therefore
+// cannot be manipulated by trading against the pair.
+func (p *Pair) FairValue(liquidity *big.Int, price0, price1 *big.Rat) (*big.Rat, error) {
+	if err := checkAmounts(liquidity); err != nil {
+		return nil, err
+	}
+	if err := checkPrices(price0, price1); err != nil {
+		return nil, err
+	}
+	reserve0, reserve1 := p.Reserves()
+	totalSupply := p.TotalSupply()
+	if totalSupply.Sign() != 1 {
+		return nil, ErrorInsufficientLiquidity
+	}
+
+	product := new(big.Rat).SetInt(new(big.Int).Mul(reserve0, reserve1))
+	product.Mul(product, price0)
+	product.Mul(product, price1)
+
+	sqrt := new(big.Float).SetPrec(512).SetRat(product)
+	sqrt.Sqrt(sqrt)
+	value, _ := sqrt.Rat(nil)
+
+	value.Mul(value, big.NewRat(2, 1))
+	value.Mul(value, new(big.Rat).SetFrac(liquidity, totalSupply))
+	return value, nil
+}
+
+// MaxSafeBorrow is the lower of the spot and fair values of liquidity scaled
+// by collateralFactor.
+func (p *Pair) MaxSafeBorrow(liquidity *big.Int, price0, price1, collateralFactor *big.Rat) (*big.Rat, error) {
+	if err := checkPrices(collateralFactor); err != nil {
+		return nil, err
+	}
+	spot, err := p.SpotValue(liquidity, price0, price1)
+	if err != nil {
+		return nil, err
+	}
+	fair, err := p.FairValue(liquidity, price0, price1)
+	if err != nil {
+		return nil, err
+	}
+
+	value := spot
+	if fair.Cmp(spot) == -1 {
+		value = fair
+	}
+	return new(big.Rat).Mul(value, collateralFactor), nil
+}
+
+type DepthSample struct {
+	// ZeroForOne is true for trades of token0 into token1.
+	ZeroForOne bool
+	AmountIn   *big.Int
+	AmountOut  *big.Int
+	// Price is the effective price AmountOut/AmountIn of the trade.
+	Price *big.Rat
+}
+
+// SampleDepth samples trades of points evenly spaced sizes, from
+// reserveIn/points up to the whole input reserve, in both directions:
+// first token0 into token1, then token1 into token0.
+func (p *Pair) SampleDepth(points int) ([]DepthSample, error) {
+	if points < 1 {
+		return nil, ErrorInsufficientAmount
+	}
+	if p.drained() {
+		return nil, ErrorInactivePair
+	}
+	reserve0, reserve1 := p.Reserves()
+	if reserve0.Sign() != 1 || reserve1.Sign() != 1 {
+		return nil, ErrorInsufficientLiquidity
+	}
+
+	samples := make([]DepthSample, 0, 2*points)
+	for _, zeroForOne := range []bool{true, false} {
+		reserveIn, reserveOut := reserve0, reserve1
+		if !zeroForOne {
+			reserveIn, reserveOut = reserve1, reserve0
+		}
+		for i := 1; i <= points; i++ {
+			amountIn := new(big.Int).Mul(reserveIn, big.NewInt(int64(i)))
+			amountIn.Quo(amountIn, big.NewInt(int64(points)))
+			amountOut, err := getAmountOut(amountIn, reserveIn, reserveOut, p.fee)
+			if err != nil {
+				continue
+			}
+			samples = append(samples, DepthSample{
+				ZeroForOne: zeroForOne,
+				AmountIn:   amountIn,
+				AmountOut:  amountOut,
+				Price:      new(big.Rat).SetFrac(amountOut, amountIn),
+			})
+		}
+	}
+	return samples, nil
+}
+
+// DepthCurve quotes trades of the given sizes of token0 into token1, or of
+// token1 into token0 unless zeroForOne, returning one sample per size in
+// order. The pair is read under a single read lock for all of them, so a
+// market-depth chart costs one lock instead of one per quote.
+func (p *Pair) DepthCurve(sizes []*big.Int, zeroForOne bool) ([]DepthSample, error) {
+	if err := checkAmounts(sizes...); err != nil {
+		return nil, err
+	}
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+
+	if p.totalSupply.Sign() == 1 && !p.isActive() {
+		return nil, ErrorInactivePair
+	}
+	reserveIn, reserveOut := p.reserve0, p.reserve1
+	if !zeroForOne {
+		reserveIn, reserveOut = reserveOut, reserveIn
+	}
+
+	samples := make([]DepthSample, 0, len(sizes))
+	for _, amountIn := range sizes {
+		amountOut, err := getAmountOut(amountIn, reserveIn, reserveOut, p.fee)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, DepthSample{
+			ZeroForOne: zeroForOne,
+			AmountIn:   new(big.Int).Set(amountIn),
+			AmountOut:  amountOut,
+			Price:      new(big.Rat).SetFrac(amountOut, amountIn),
+		})
+	}
+	return samples, nil
+}
+
+// PriceImpact returns the relative difference between the spot price and
+// the execution price of selling amountIn of token0 of the pair, or of
+// token1 unless zeroForOne, fee included as in the Uniswap interfaces:
+// 1/100 is 1%. It fails as GetAmountOut does if the trade cannot be made.
+func (p *Pair) PriceImpact(amountIn *big.Int, zeroForOne bool) (*big.Rat, error) {
+	if err := checkAmounts(amountIn); err != nil {
+		return nil, err
+	}
+	if p.drained() {
+		return nil, ErrorInactivePair
+	}
+	reserveIn, reserveOut := p.Reserves()
+	if !zeroForOne {
+		reserveIn, reserveOut = reserveOut, reserveIn
+	}
+	amountOut, err := getAmountOut(amountIn, reserveIn, reserveOut, p.fee)
+	if err != nil {
+		return nil, err
+	}
+	// 1 - (amountOut / amountIn) / (reserveOut / reserveIn)
+	execution := new(big.Rat).SetFrac(new(big.Int).Mul(amountOut, reserveIn), new(big.Int).Mul(amountIn, reserveOut))
+	return execution.Sub(big.NewRat(1, 1), execution), nil
+}
@@ -0,0 +1,254 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestLPValueTWAP_Average(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	twap := &LPValueTWAP{}
+	start := time.Unix(1600000000, 0)
+	if err := twap.Observe(pair, start); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pair.Mint("address", big.NewInt(3e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := twap.Observe(pair, start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := twap.Observe(pair, start.Add(time.Hour)); err != ErrorInvalidTimestamp {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidTimestamp)
+	}
+
+	// 1 LP is worth 2 token0 during the first hour and 4 token0 during the second.
+	average, err := twap.Average0(start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if average.Cmp(big.NewRat(3, 1)) != 0 {
+		t.Errorf("average want %s, got %s", big.NewRat(3, 1), average)
+	}
+
+	average, err = twap.AverageQuote(start, start.Add(2*time.Hour), big.NewRat(1, 1), big.NewRat(2, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if average.Cmp(big.NewRat(7, 2)) != 0 {
+		t.Errorf("quote average want %s, got %s", big.NewRat(7, 2), average)
+	}
+
+	_, err = twap.Average1(start.Add(-time.Hour), start)
+	if err != ErrorInsufficientObservations {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientObservations)
+	}
+}
+
+func TestPair_MaxSafeBorrow(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	liquidity := pair.TotalSupply()
+	price0, price1 := big.NewRat(2, 1), big.NewRat(1, 2)
+
+	spot, err := pair.SpotValue(liquidity, price0, price1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spot.Cmp(big.NewRat(4e18, 1)) != 0 {
+		t.Errorf("spot value want %s, got %s", big.NewRat(4e18, 1), spot)
+	}
+
+	fair, err := pair.FairValue(liquidity, price0, price1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fair.Cmp(big.NewRat(4e18, 1)) != 0 {
+		t.Errorf("fair value want %s, got %s", big.NewRat(4e18, 1), fair)
+	}
+
+	// Skew the pool: the spot value grows, the fair value does not.
+	_, _, err = pair.Swap(big.NewInt(1e18), big.NewInt(0), big.NewInt(0), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spot, err = pair.SpotValue(liquidity, price0, price1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	borrow, err := pair.MaxSafeBorrow(liquidity, price0, price1, big.NewRat(1, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fair, err = pair.FairValue(liquidity, price0, price1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fair.Cmp(spot) != -1 {
+		t.Errorf("fair value %s must be below spot value %s", fair, spot)
+	}
+	if want := new(big.Rat).Mul(fair, big.NewRat(1, 2)); borrow.Cmp(want) != 0 {
+		t.Errorf("borrow want %s, got %s", want, borrow)
+	}
+}
+
+func TestPair_SampleDepth(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.SampleDepth(4); err != ErrorInsufficientLiquidity {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := pair.SampleDepth(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 8 {
+		t.Fatalf("samples want 8, got %d", len(samples))
+	}
+	for i, sample := range samples {
+		if sample.ZeroForOne != (i < 4) {
+			t.Errorf("sample %d direction want zeroForOne %v", i, i < 4)
+		}
+		var want *big.Int
+		if sample.ZeroForOne {
+			want, err = pair.GetAmountOut(sample.AmountIn)
+		} else {
+			want, err = service.Pair(1, 0).GetAmountOut(sample.AmountIn)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sample.AmountOut.Cmp(want) != 0 {
+			t.Errorf("sample %d output want %s, got %s", i, want, sample.AmountOut)
+		}
+		if i%4 != 0 && sample.Price.Cmp(samples[i-1].Price) != -1 {
+			t.Errorf("sample %d price %s want below %s", i, sample.Price.FloatString(6), samples[i-1].Price.FloatString(6))
+		}
+	}
+	if samples[3].AmountIn.Cmp(big.NewInt(1e18)) != 0 || samples[7].AmountIn.Cmp(big.NewInt(2e18)) != 0 {
+		t.Errorf("largest inputs want the reserves, got %s and %s", samples[3].AmountIn, samples[7].AmountIn)
+	}
+
+	_, _, err = pair.Burn("address", pair.Balance("address"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.SampleDepth(4); err != ErrorInactivePair {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInactivePair)
+	}
+}
+
+func TestPair_DepthCurve(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes := []*big.Int{big.NewInt(1e15), big.NewInt(1e17), big.NewInt(5e17)}
+	samples, err := service.Pair(1, 0).DepthCurve(sizes, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != len(sizes) {
+		t.Fatalf("samples want %d, got %d", len(sizes), len(samples))
+	}
+	for i, sample := range samples {
+		want, err := pair.GetAmountOut(sizes[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sample.AmountIn.Cmp(sizes[i]) != 0 || sample.AmountOut.Cmp(want) != 0 || sample.ZeroForOne {
+			t.Errorf("sample %d want %s for %s, got %+v", i, want, sizes[i], sample)
+		}
+		if i > 0 && sample.Price.Cmp(samples[i-1].Price) != -1 {
+			t.Errorf("sample %d price %s want below %s", i, sample.Price.FloatString(6), samples[i-1].Price.FloatString(6))
+		}
+	}
+
+	if _, err := pair.DepthCurve([]*big.Int{big.NewInt(1), big.NewInt(0)}, true); err != ErrorInsufficientInputAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientInputAmount)
+	}
+
+	_, _, err = pair.Burn("address", pair.Balance("address"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.DepthCurve(sizes, true); err != ErrorInactivePair {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInactivePair)
+	}
+}
+
+func TestPair_PriceImpact(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.PriceImpact(big.NewInt(1e16), true); err != ErrorInsufficientLiquidity {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 0.3% fee and 1.0% of the reserve moved: 1 - 0.997 / 1.00997
+	impact, err := pair.PriceImpact(big.NewInt(1e16), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := impact.FloatString(6); got != "0.012842" {
+		t.Errorf("impact want 0.012842, got %s", got)
+	}
+	other, err := service.Pair(1, 0).PriceImpact(big.NewInt(1e16), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Cmp(impact) != 0 {
+		t.Errorf("impact of the reverse view want %s, got %s", impact.FloatString(6), other.FloatString(6))
+	}
+	small, err := pair.PriceImpact(big.NewInt(1e10), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small.Cmp(big.NewRat(3, 1000)) == -1 || small.Cmp(big.NewRat(31, 10000)) == 1 {
+		t.Errorf("impact of a small trade want the fee, got %s", small.FloatString(6))
+	}
+	if _, err := pair.PriceImpact(big.NewInt(0), false); err != ErrorInsufficientInputAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientInputAmount)
+	}
+}
@@ -0,0 +1,219 @@
+package uniswapV2
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrorApprovalRequired   = errors.New("APPROVAL_REQUIRED")
+	ErrorNotApprover        = errors.New("NOT_APPROVER")
+	ErrorAlreadyApproved    = errors.New("ALREADY_APPROVED")
+	ErrorActionNotExists    = errors.New("ACTION_NOT_EXISTS")
+	ErrorInvalidThreshold   = errors.New("INVALID_THRESHOLD")
+	ErrorInvalidAdminAction = errors.New("INVALID_ADMIN_ACTION")
+)
+
+type AdminActionKind int
+
+const (
+	// AdminRemovePair is RemovePairWithFee, or RemovePair for FeeTier zero.
+	AdminRemovePair AdminActionKind = iota
+	// AdminMigratePair is MigratePair from FeeTier to ToTier.
+	AdminMigratePair
+)
+
+func (k AdminActionKind) String() string {
+	switch k {
+	case AdminRemovePair:
+		return "remove_pair"
+	case AdminMigratePair:
+		return "migrate_pair"
+	}
+	return "unknown"
+}
+
+// AdminAction is an administrative operation on the TokenA/TokenB pair of
+// FeeTier, Signer signing the report of a migration as in MigratePair.
+type AdminAction struct {
+	Kind           AdminActionKind
+	TokenA, TokenB Token
+	FeeTier        uint32
+	ToTier         uint32
+	Signer         MigrationSigner
+}
+
+// PendingAction is a proposed AdminAction with the approvers who approved it
+// so far, in AddressLess order.
+type PendingAction struct {
+	ID        uint64
+	Action    AdminAction
+	Approvals []Address
+}
+
+type adminApprovals struct {
+	threshold int
+	approvers []Address
+
+	mu      sync.Mutex
+	next    uint64
+	pending map[uint64]*PendingAction
+}
+
+// WithAdminApprovals makes RemovePair, RemovePairWithFee and MigratePair
+// fail with ErrorApprovalRequired: they run as AdminActions instead, once
+// threshold of approvers approved them through ProposeAdminAction and
+// ApproveAdminAction. A threshold outside 1 to the number of approvers makes
+// every proposal fail with ErrorInvalidThreshold. Pending actions live in
+// memory only; they are not part of the state that Commit persists.
+func WithAdminApprovals(threshold int, approvers ...Address) Option {
+	approvers = append([]Address(nil), approvers...)
+	return func(o *options) {
+		o.approvals = &adminApprovals{threshold: threshold, approvers: approvers, pending: map[uint64]*PendingAction{}}
+	}
+}
+
+// ProposeAdminAction queues action for approval and approves it for
+// proposer, who must be an approver, running it at once if that is enough.
+// It returns the ID of the action, which is not pending anymore if executed.
+func (s *UniswapV2) ProposeAdminAction(proposer Address, action AdminAction) (id uint64, executed bool, report *MigrationReport, err error) {
+	a := s.approvals
+	if a == nil {
+		return 0, false, nil, ErrorNotApprover
+	}
+	if a.threshold < 1 || a.threshold > len(a.approvers) {
+		return 0, false, nil, ErrorInvalidThreshold
+	}
+	if action.Kind != AdminRemovePair && action.Kind != AdminMigratePair {
+		return 0, false, nil, ErrorInvalidAdminAction
+	}
+	if _, err := a.approver(proposer); err != nil {
+		return 0, false, nil, err
+	}
+	a.mu.Lock()
+	a.next++
+	id = a.next
+	a.pending[id] = &PendingAction{ID: id, Action: action}
+	a.mu.Unlock()
+
+	executed, report, err = s.ApproveAdminAction(proposer, id)
+	return id, executed, report, err
+}
+
+// ApproveAdminAction approves the pending action id for approver and runs
+// it once threshold approvers approved it, returning the report of a
+// migration. An action that ran is not pending anymore, even if it failed.
+func (s *UniswapV2) ApproveAdminAction(approver Address, id uint64) (executed bool, report *MigrationReport, err error) {
+	a := s.approvals
+	if a == nil {
+		return false, nil, ErrorNotApprover
+	}
+	if approver, err = a.approver(approver); err != nil {
+		return false, nil, err
+	}
+
+	a.mu.Lock()
+	pending, ok := a.pending[id]
+	if !ok {
+		a.mu.Unlock()
+		return false, nil, ErrorActionNotExists
+	}
+	for _, approved := range pending.Approvals {
+		if approved == approver {
+			a.mu.Unlock()
+			return false, nil, ErrorAlreadyApproved
+		}
+	}
+	pending.Approvals = append(pending.Approvals, approver)
+	sort.Slice(pending.Approvals, func(i, j int) bool { return AddressLess(pending.Approvals[i], pending.Approvals[j]) })
+	if len(pending.Approvals) < a.threshold {
+		a.mu.Unlock()
+		return false, nil, nil
+	}
+	delete(a.pending, id)
+	a.mu.Unlock()
+
+	action := pending.Action
+	switch action.Kind {
+	case AdminRemovePair:
+		err = s.removePairKey(nil, pairKey{TokenA: action.TokenA, TokenB: action.TokenB, Fee: action.FeeTier})
+	case AdminMigratePair:
+		report, err = s.migratePair(action.TokenA, action.TokenB, action.FeeTier, action.ToTier, action.Signer)
+	}
+	return true, report, err
+}
+
+// RevokeAdminApproval withdraws the approval of approver, if any, from the
+// pending action id. An action left without approvals is dropped.
+func (s *UniswapV2) RevokeAdminApproval(approver Address, id uint64) error {
+	a := s.approvals
+	if a == nil {
+		return ErrorNotApprover
+	}
+	approver, err := a.approver(approver)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pending, ok := a.pending[id]
+	if !ok {
+		return ErrorActionNotExists
+	}
+	for i, approved := range pending.Approvals {
+		if approved == approver {
+			pending.Approvals = append(pending.Approvals[:i], pending.Approvals[i+1:]...)
+			break
+		}
+	}
+	if len(pending.Approvals) == 0 {
+		delete(a.pending, id)
+	}
+	return nil
+}
+
+// PendingAdminActions returns the actions waiting for approvals by ID.
+func (s *UniswapV2) PendingAdminActions() []PendingAction {
+	a := s.approvals
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	actions := make([]PendingAction, 0, len(a.pending))
+	for _, pending := range a.pending {
+		action := *pending
+		action.Approvals = append([]Address(nil), pending.Approvals...)
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].ID < actions[j].ID })
+	return actions
+}
+
+// PendingAdminAction returns the pending action id.
+func (s *UniswapV2) PendingAdminAction(id uint64) (PendingAction, bool) {
+	for _, action := range s.PendingAdminActions() {
+		if action.ID == id {
+			return action, true
+		}
+	}
+	return PendingAction{}, false
+}
+
+// approver returns the canonical form of address if it is one of the
+// approvers, comparing the canonical forms of both.
+func (a *adminApprovals) approver(address Address) (Address, error) {
+	if err := normalizeAddresses(&address); err != nil {
+		return "", err
+	}
+	for _, approver := range a.approvers {
+		if normalizeAddresses(&approver) == nil && approver == address {
+			return address, nil
+		}
+	}
+	return "", ErrorNotApprover
+}
@@ -0,0 +1,79 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_WithAdminApprovals(t *testing.T) {
+	service := New(WithAdminApprovals(2, "alice", "bob", "carol"))
+	if _, err := service.CreatePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	pair, err := service.CreatePairWithFee(0, 2, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("dave", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.RemovePair(0, 1); err != ErrorApprovalRequired {
+		t.Fatalf("failed with %v; want error %v", err, ErrorApprovalRequired)
+	}
+	if _, err := service.MigratePair(0, 2, 30, 5, nil); err != ErrorApprovalRequired {
+		t.Fatalf("failed with %v; want error %v", err, ErrorApprovalRequired)
+	}
+	if _, _, _, err := service.ProposeAdminAction("dave", AdminAction{Kind: AdminRemovePair, TokenA: 0, TokenB: 1}); err != ErrorNotApprover {
+		t.Fatalf("failed with %v; want error %v", err, ErrorNotApprover)
+	}
+
+	remove, executed, _, err := service.ProposeAdminAction("alice", AdminAction{Kind: AdminRemovePair, TokenA: 1, TokenB: 0})
+	if err != nil || executed {
+		t.Fatalf("proposal failed with %v or executed", err)
+	}
+	migrate, _, _, err := service.ProposeAdminAction("bob", AdminAction{Kind: AdminMigratePair, TokenA: 0, TokenB: 2, FeeTier: 30, ToTier: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := service.PendingAdminActions()
+	if len(pending) != 2 || pending[0].ID != remove || pending[1].ID != migrate || len(pending[0].Approvals) != 1 || pending[0].Approvals[0] != "alice" {
+		t.Fatalf("pending actions want %d approved by alice and %d, got %+v", remove, migrate, pending)
+	}
+	if _, _, err := service.ApproveAdminAction("alice", remove); err != ErrorAlreadyApproved {
+		t.Fatalf("failed with %v; want error %v", err, ErrorAlreadyApproved)
+	}
+
+	executed, _, err = service.ApproveAdminAction("carol", remove)
+	if err != nil || !executed {
+		t.Fatalf("approval failed with %v or did not execute", err)
+	}
+	if service.Pair(0, 1) != nil {
+		t.Error("pair want removed")
+	}
+	if _, ok := service.PendingAdminAction(remove); ok {
+		t.Error("executed action still pending")
+	}
+
+	if err := service.RevokeAdminApproval("bob", migrate); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := service.ApproveAdminAction("carol", migrate); err != ErrorActionNotExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorActionNotExists)
+	}
+	migrate, _, _, err = service.ProposeAdminAction("bob", AdminAction{Kind: AdminMigratePair, TokenA: 2, TokenB: 0, FeeTier: 30, ToTier: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	executed, report, err := service.ApproveAdminAction("carol", migrate)
+	if err != nil || !executed || report == nil || report.ToTier != 5 {
+		t.Fatalf("migration failed with %v: %+v", err, report)
+	}
+	if service.PairWithFee(0, 2, 5).Balance("dave").Sign() != 1 {
+		t.Error("migrated balance want positive")
+	}
+
+	if _, _, _, err := New(WithAdminApprovals(3, "alice")).ProposeAdminAction("alice", AdminAction{}); err != ErrorInvalidThreshold {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidThreshold)
+	}
+}
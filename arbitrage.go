@@ -0,0 +1,110 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrorNoArbitrage = errors.New("NO_ARBITRAGE")
+)
+
+// Arbitrage is a cycle of trades from a token back to itself paying more
+// than it takes: selling AmountIn along Cycle returns AmountOut.
+type Arbitrage struct {
+	Cycle     []Token
+	AmountIn  *big.Int
+	AmountOut *big.Int
+}
+
+func (a *Arbitrage) Profit() *big.Int {
+	return new(big.Int).Sub(a.AmountOut, a.AmountIn)
+}
+
+// FindArbitrage searches the cycles of two up to maxHops hops from start
+// back to it whose product of marginal prices after fees exceeds 1, and
+// returns the most profitable one with its optimal input. Trading along a
+// cycle composes the outputs of its hops into x -> A*x / (B + C*x), which
+// is profitable if A > B and pays the most for x = (sqrt(A*B) - B) / C.
+// The cycle is traded as Router.Swap would, every hop on its best tier.
+func (s *UniswapV2) FindArbitrage(start Token, maxHops int) (*Arbitrage, error) {
+	if maxHops < 2 {
+		return nil, ErrorInvalidPath
+	}
+
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	var best *Arbitrage
+	var walk func(path []Token, visited map[Token]bool)
+	walk = func(path []Token, visited map[Token]bool) {
+		if len(path) > maxHops {
+			return
+		}
+		last := path[len(path)-1]
+		for _, next := range s.neighbours(last) {
+			if next == start && len(path) > 1 {
+				cycle := append(append([]Token(nil), path...), start)
+				if arbitrage := s.arbitrage(cycle); arbitrage != nil && (best == nil || arbitrage.Profit().Cmp(best.Profit()) == 1) {
+					best = arbitrage
+				}
+				continue
+			}
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			walk(append(path, next), visited)
+			visited[next] = false
+		}
+	}
+	walk([]Token{start}, map[Token]bool{start: true})
+
+	if best == nil {
+		return nil, ErrorNoArbitrage
+	}
+	return best, nil
+}
+
+// arbitrage returns the optimal trade along cycle, or nil if it does not
+// pay. The caller holds the muPairs lock.
+func (s *UniswapV2) arbitrage(cycle []Token) *Arbitrage {
+	a, b, c := big.NewInt(1), big.NewInt(1), big.NewInt(0)
+	for i := 0; i < len(cycle)-1; i++ {
+		// hop x -> rn*reserveOut*x / (rd*reserveIn + rn*x) on the tier with
+		// the best marginal price
+		var hopA, hopB, hopC *big.Int
+		for _, pair := range s.tierPairs(cycle[i], cycle[i+1]) {
+			if !pair.IsActive() {
+				continue
+			}
+			reserveIn, reserveOut := pair.Reserves()
+			pairA := new(big.Int).Mul(pair.fee.remainder(), reserveOut)
+			pairB := new(big.Int).Mul(pair.fee.denominator(), reserveIn)
+			if hopA == nil || new(big.Int).Mul(pairA, hopB).Cmp(new(big.Int).Mul(hopA, pairB)) == 1 {
+				hopA, hopB, hopC = pairA, pairB, pair.fee.remainder()
+			}
+		}
+		if hopA == nil {
+			return nil
+		}
+		c = new(big.Int).Add(new(big.Int).Mul(hopB, c), new(big.Int).Mul(hopC, a))
+		a = new(big.Int).Mul(hopA, a)
+		b = new(big.Int).Mul(hopB, b)
+	}
+	if a.Cmp(b) != 1 {
+		return nil
+	}
+
+	amountIn := new(big.Int).Sqrt(new(big.Int).Mul(a, b))
+	amountIn.Sub(amountIn, b)
+	amountIn.Quo(amountIn, c)
+	if amountIn.Sign() != 1 {
+		return nil
+	}
+	amountOut, err := s.pathOut(cycle, amountIn)
+	if err != nil || amountOut.Cmp(amountIn) != 1 {
+		return nil
+	}
+	return &Arbitrage{Cycle: cycle, AmountIn: amountIn, AmountOut: amountOut}
+}
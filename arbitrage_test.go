@@ -0,0 +1,59 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestUniswapV2_FindArbitrage(t *testing.T) {
+	service := New()
+	for _, reserves := range []struct {
+		tokenA, tokenB     Token
+		reserveA, reserveB int64
+	}{{0, 1, 1e18, 1e18}, {1, 2, 1e18, 1e18}, {0, 2, 1e18, 2e18}} {
+		pair, err := service.CreatePair(reserves.tokenA, reserves.tokenB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("alice", big.NewInt(reserves.reserveA), big.NewInt(reserves.reserveB))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := service.FindArbitrage(0, 1); err != ErrorInvalidPath {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidPath)
+	}
+
+	arbitrage, err := service.FindArbitrage(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(arbitrage.Cycle, []Token{0, 2, 1, 0}) {
+		t.Fatalf("cycle want [0 2 1 0], got %v", arbitrage.Cycle)
+	}
+	if arbitrage.Profit().Sign() != 1 {
+		t.Fatalf("profit want positive, got %s", arbitrage.Profit())
+	}
+	router := NewRouter(service)
+	for _, delta := range []int64{-1e15, 1e15} {
+		amounts, err := router.GetAmountsOut(new(big.Int).Add(arbitrage.AmountIn, big.NewInt(delta)), arbitrage.Cycle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if profit := new(big.Int).Sub(amounts[3], amounts[0]); profit.Cmp(arbitrage.Profit()) == 1 {
+			t.Errorf("profit of input %s want at most %s, got %s", amounts[0], arbitrage.Profit(), profit)
+		}
+	}
+
+	amounts, err := router.Swap(arbitrage.AmountIn, arbitrage.Cycle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amounts[3].Cmp(arbitrage.AmountOut) != 0 {
+		t.Errorf("amount out want %s, got %s", arbitrage.AmountOut, amounts[3])
+	}
+	if arbitrage, err := service.FindArbitrage(0, 3); err != ErrorNoArbitrage {
+		t.Fatalf("failed with %v, %+v; want error %v", err, arbitrage, ErrorNoArbitrage)
+	}
+}
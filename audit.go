@@ -0,0 +1,133 @@
+package uniswapV2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"sync"
+)
+
+// AuditRecord is one line of the audit log. Amounts are in canonical token
+// order, Hash is the state of the pair after the operation.
+type AuditRecord struct {
+	Time      int64             `json:"time"`
+	Operation string            `json:"operation"`
+	Address   Address           `json:"address,omitempty"`
+	Token0    Token             `json:"token0"`
+	Token1    Token             `json:"token1"`
+	FeeTier   uint32            `json:"fee_tier"`
+	Amounts   map[string]string `json:"amounts,omitempty"`
+	Hash      string            `json:"hash"`
+}
+
+// AuditRotate is called before every record with the current writer and the
+// number of bytes written to it, and returns the writer to use. Returning a
+// different writer starts a new file, e.g. on size or date.
+type AuditRotate func(w io.Writer, written int64) (io.Writer, error)
+
+// AuditLog writes a JSON line for every state-changing operation of the
+// service it is passed to with WithAuditLog. Operations never fail because
+// of the log; the first write error is kept and reported by Err, and no
+// further records are written.
+type AuditLog struct {
+	mu      sync.Mutex
+	w       io.Writer
+	written int64
+	rotate  AuditRotate
+	err     error
+}
+
+func NewAuditLog(w io.Writer, rotate AuditRotate) *AuditLog {
+	return &AuditLog{w: w, rotate: rotate}
+}
+
+func (l *AuditLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+func (l *AuditLog) write(record AuditRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return
+	}
+	if l.rotate != nil {
+		w, err := l.rotate(l.w, l.written)
+		if err != nil {
+			l.err = err
+			return
+		}
+		if w != l.w {
+			l.w, l.written = w, 0
+		}
+	}
+	n, err := l.w.Write(data)
+	l.written += int64(n)
+	l.err = err
+}
+
+// WithAuditLog writes every state-changing operation of the service to log.
+func WithAuditLog(log *AuditLog) Option {
+	return func(o *options) {
+		o.auditLog = log
+	}
+}
+
+type auditAmounts map[string]string
+
+// set records a pair of view amounts under amount0<suffix> and
+// amount1<suffix> in canonical order.
+func (a auditAmounts) set(p *Pair, suffix string, amount0, amount1 *big.Int) auditAmounts {
+	if !p.key.isSorted() {
+		amount0, amount1 = amount1, amount0
+	}
+	a["amount0"+suffix], a["amount1"+suffix] = amount0.String(), amount1.String()
+	return a
+}
+
+func (p *Pair) audit(operation string, address Address, amounts auditAmounts) {
+	log := p.service.auditLog
+	if log == nil {
+		return
+	}
+	key := p.key.sort()
+	record := AuditRecord{
+		Time:      p.service.now().Unix(),
+		Operation: operation,
+		Address:   address,
+		Token0:    key.TokenA,
+		Token1:    key.TokenB,
+		FeeTier:   key.Fee,
+		Amounts:   amounts,
+		Hash:      hex.EncodeToString(p.stateHash()),
+	}
+	p.deliver(func() { log.write(record) })
+}
+
+// stateHash is the SHA-256 of the canonical reserves and total supply of the pair.
+func (p *Pair) stateHash() []byte {
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+
+	reserve0, reserve1 := p.reserve0, p.reserve1
+	if !p.key.isSorted() {
+		reserve0, reserve1 = reserve1, reserve0
+	}
+	hash := sha256.New()
+	for _, value := range []*big.Int{reserve0, reserve1, p.totalSupply} {
+		hash.Write([]byte(value.String()))
+		hash.Write([]byte{0})
+	}
+	return hash.Sum(nil)
+}
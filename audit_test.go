@@ -0,0 +1,69 @@
+package uniswapV2
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	var first, second bytes.Buffer
+	log := NewAuditLog(&first, func(w io.Writer, written int64) (io.Writer, error) {
+		if written > 0 && w == &first {
+			return &second, nil
+		}
+		return w, nil
+	})
+	service := New(WithAuditLog(log), WithClock(func() time.Time { return time.Unix(1000, 0) }))
+
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if !errors.Is(err, ErrorInsufficientInputAmount) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientInputAmount)
+	}
+	if log.Err() != nil {
+		t.Fatal(log.Err())
+	}
+
+	if first.String() == "" || bytes.Count(first.Bytes(), []byte("\n")) != 1 {
+		t.Fatalf("first writer want 1 record, got %q", first.String())
+	}
+	var records []AuditRecord
+	scanner := bufio.NewScanner(&second)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 || records[0].Operation != "mint" || records[1].Operation != "swap" {
+		t.Fatalf("records want mint and swap, got %v", records)
+	}
+	mint := records[0]
+	if mint.Address != "address" || mint.Token0 != 0 || mint.Amounts["amount0"] != "2000000000000000000" || mint.Time != 1000 {
+		t.Errorf("mint record want canonical amounts of address, got %+v", mint)
+	}
+	if records[1].Amounts["amount1_in"] != "100000000000000000" || records[1].Amounts["amount0_out"] != "100000000000000000" {
+		t.Errorf("swap record want canonical amounts, got %v", records[1].Amounts)
+	}
+	if records[0].Hash == records[1].Hash {
+		t.Errorf("hashes want different, got %s", records[0].Hash)
+	}
+}
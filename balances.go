@@ -0,0 +1,67 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sort"
+)
+
+type Holder struct {
+	Address   Address
+	Liquidity *big.Int
+}
+
+// ForEachBalance calls fn with every positive LP balance of the pair in
+// AddressLess order, the minimumLiquidity locked under the empty address
+// included, until fn returns false. The balances are copied at once under
+// the balance lock, so fn sees a consistent state and may use the pair.
+func (p *Pair) ForEachBalance(fn func(address Address, liquidity *big.Int) bool) {
+	p.muBalance.RLock()
+	addresses := p.addresses()
+	balances := make([]*big.Int, 0, len(addresses))
+	for _, address := range addresses {
+		balances = append(balances, new(big.Int).Set(p.balances[address]))
+	}
+	p.muBalance.RUnlock()
+
+	for i, address := range addresses {
+		if balances[i].Sign() != 1 {
+			continue
+		}
+		if !fn(address, balances[i]) {
+			return
+		}
+	}
+}
+
+// HoldersCount returns the number of addresses with a positive LP balance,
+// the empty address holding the locked minimumLiquidity included.
+func (p *Pair) HoldersCount() int {
+	p.muBalance.RLock()
+	defer p.muBalance.RUnlock()
+
+	count := 0
+	for _, liquidity := range p.balances {
+		if liquidity.Sign() == 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// TopHolders returns the n largest LP positions of the pair, largest first
+// and in AddressLess order on ties. A negative n returns all of them.
+func (p *Pair) TopHolders(n int) []Holder {
+	var holders []Holder
+	p.ForEachBalance(func(address Address, liquidity *big.Int) bool {
+		holders = append(holders, Holder{Address: address, Liquidity: liquidity})
+		return true
+	})
+
+	sort.SliceStable(holders, func(i, j int) bool {
+		return holders[i].Liquidity.Cmp(holders[j].Liquidity) == 1
+	})
+	if n >= 0 && n < len(holders) {
+		holders = holders[:n]
+	}
+	return holders
+}
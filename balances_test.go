@@ -0,0 +1,87 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPair_ForEachBalance(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, address := range []Address{"carol", "alice", "bob"} {
+		_, err = pair.Mint(address, big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _, err = pair.Burn("bob", pair.Balance("bob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var addresses []Address
+	sum := big.NewInt(0)
+	pair.ForEachBalance(func(address Address, liquidity *big.Int) bool {
+		addresses = append(addresses, address)
+		sum.Add(sum, liquidity)
+		// the pair may be used while iterating
+		_, err := pair.Mint("dave", big.NewInt(1e18), big.NewInt(1e18))
+		return err == nil
+	})
+	if len(addresses) != 3 || addresses[0] != addressZero || addresses[1] != "alice" || addresses[2] != "carol" {
+		t.Errorf("addresses want \"\", alice, carol, got %v", addresses)
+	}
+	if want := new(big.Int).Sub(pair.TotalSupply(), pair.Balance("dave")); sum.Cmp(want) != 0 {
+		t.Errorf("sum want %s, got %s", want, sum)
+	}
+
+	count := 0
+	service.Pair(1, 0).ForEachBalance(func(Address, *big.Int) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Errorf("calls want 2, got %d", count)
+	}
+}
+
+func TestPair_TopHolders(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, holder := range []struct {
+		address Address
+		amount  int64
+	}{{"dave", 2e18}, {"alice", 1e18}, {"bob", 3e18}, {"carol", 1e18}} {
+		_, err = pair.Mint(holder.address, big.NewInt(holder.amount), big.NewInt(holder.amount))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _, err = pair.Burn("dave", pair.Balance("dave"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := pair.HoldersCount(); count != 4 {
+		t.Errorf("holders want 4, got %d", count)
+	}
+	top := pair.TopHolders(2)
+	if len(top) != 2 || top[0].Address != "bob" || top[1].Address != "alice" {
+		t.Fatalf("top holders want bob, alice, got %v", top)
+	}
+	if top[0].Liquidity.Cmp(pair.Balance("bob")) != 0 {
+		t.Errorf("liquidity want %s, got %s", pair.Balance("bob"), top[0].Liquidity)
+	}
+	if all := pair.TopHolders(-1); len(all) != 4 || all[2].Address != "carol" || all[3].Address != addressZero {
+		t.Errorf("holders want bob, alice, carol, \"\", got %v", all)
+	}
+	if none := pair.TopHolders(0); len(none) != 0 {
+		t.Errorf("holders want none, got %v", none)
+	}
+}
@@ -0,0 +1,335 @@
+package uniswapV2
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// binaryVersion is the first byte of every encoding made by Marshal. Decoders
+// keep accepting older versions when the layout changes.
+const binaryVersion byte = 1
+
+var (
+	ErrorInvalidEncoding = errors.New("INVALID_ENCODING")
+)
+
+// Marshal encodes the state of all pairs in a compact binary form: a version
+// byte, the number of pairs and every pair as encoded by Pair.Marshal with
+// its length, in canonical key order. With WithLazyStorage the pairs not in
+// memory are read from storage, failing with its error.
+func (s *UniswapV2) Marshal() ([]byte, error) {
+	s.muPairs.RLock()
+	pairs, err := s.statePairs()
+	s.muPairs.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &encoder{}
+	e.byte(binaryVersion)
+	e.uvarint(uint64(len(pairs)))
+	for _, pair := range pairs {
+		e.bytes(pair.Marshal())
+	}
+	return e.buf, nil
+}
+
+// Unmarshal loads an encoding made by Marshal into a service without pairs.
+func (s *UniswapV2) Unmarshal(data []byte) error {
+	d := &decoder{buf: data}
+	if d.byte() != binaryVersion {
+		return ErrorInvalidEncoding
+	}
+	n := d.uvarint()
+	if d.err != nil || n > uint64(len(data)) {
+		return ErrorInvalidEncoding
+	}
+	pairs := make([]*Pair, 0, n)
+	metadata := make([]map[string]string, 0, n)
+	for i := uint64(0); i < n; i++ {
+		pair, pairMetadata, err := unmarshalPair(d.bytes())
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, pair)
+		metadata = append(metadata, pairMetadata)
+	}
+	if d.err != nil || len(d.buf) != 0 {
+		return ErrorInvalidEncoding
+	}
+	return s.load(pairs, metadata)
+}
+
+// Marshal encodes the state of the pair in canonical token order: key, fee,
+// reserves, total supply, accumulators, balances, allowances, nonces and
+// metadata, after a version byte.
+func (p *Pair) Marshal() []byte {
+	if !p.key.isSorted() {
+		p = p.revert()
+	}
+	state := p.state()
+
+	e := &encoder{}
+	e.byte(binaryVersion)
+	e.varint(int64(state.Token0))
+	e.varint(int64(state.Token1))
+	e.uvarint(uint64(state.FeeTier))
+	e.varint(state.Fee.Numerator)
+	e.varint(state.Fee.Denominator)
+	for _, amount := range []*big.Int{state.Reserve0, state.Reserve1, state.TotalSupply, state.Price0CumulativeLast, state.Price1CumulativeLast} {
+		e.bigInt(amount)
+	}
+	e.uvarint(uint64(state.BlockTimestampLast))
+
+	p.muBalance.RLock()
+	addresses := p.addresses()
+	e.uvarint(uint64(len(addresses)))
+	for _, address := range addresses {
+		e.string(string(address))
+		e.bigInt(p.balances[address])
+	}
+	owners := make([]Address, 0, len(p.allowances))
+	for owner := range p.allowances {
+		owners = append(owners, owner)
+	}
+	sortAddresses(owners)
+	e.uvarint(uint64(len(owners)))
+	for _, owner := range owners {
+		spenders := make([]Address, 0, len(p.allowances[owner]))
+		for spender := range p.allowances[owner] {
+			spenders = append(spenders, spender)
+		}
+		sortAddresses(spenders)
+		e.string(string(owner))
+		e.uvarint(uint64(len(spenders)))
+		for _, spender := range spenders {
+			e.string(string(spender))
+			e.bigInt(p.allowances[owner][spender])
+		}
+	}
+	addresses = addresses[:0]
+	for address := range p.nonces {
+		addresses = append(addresses, address)
+	}
+	sortAddresses(addresses)
+	e.uvarint(uint64(len(addresses)))
+	for _, address := range addresses {
+		e.string(string(address))
+		e.uvarint(p.nonces[address])
+	}
+	p.muBalance.RUnlock()
+
+	keys := make([]string, 0, len(state.Metadata))
+	for key := range state.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	e.uvarint(uint64(len(keys)))
+	for _, key := range keys {
+		e.string(key)
+		e.string(state.Metadata[key])
+	}
+	return e.buf
+}
+
+// Unmarshal restores the pair in place from an encoding of the same pair
+// made by Marshal.
+func (p *Pair) Unmarshal(data []byte) error {
+	decoded, metadata, err := unmarshalPair(data)
+	if err != nil {
+		return err
+	}
+	if decoded.key != p.key.sort() {
+		return ErrorInvalidEncoding
+	}
+	if decoded.fee != p.fee {
+		return ErrorInvalidFee
+	}
+	if err := normalizeHolders(decoded); err != nil {
+		return err
+	}
+
+	p.saveForOp()
+	p.rollback(decoded)
+	for _, key := range p.MetadataKeys() {
+		p.SetMetadata(key, "")
+	}
+	for key, value := range metadata {
+		p.SetMetadata(key, value)
+	}
+	return nil
+}
+
+func unmarshalPair(data []byte) (*Pair, map[string]string, error) {
+	d := &decoder{buf: data}
+	if d.byte() != binaryVersion {
+		return nil, nil, ErrorInvalidEncoding
+	}
+	key := pairKey{TokenA: Token(d.varint()), TokenB: Token(d.varint()), Fee: uint32(d.uvarint())}
+	fee := Fee{Numerator: d.varint(), Denominator: d.varint()}
+	var amounts [5]*big.Int
+	for i := range amounts {
+		amounts[i] = d.bigInt()
+	}
+	blockTimestampLast := uint32(d.uvarint())
+	pair := &Pair{
+		key: key,
+		fee: fee,
+		pairData: pairData{
+			pairMutex:            &pairMutex{},
+			reserve0:             amounts[0],
+			reserve1:             amounts[1],
+			totalSupply:          amounts[2],
+			price0CumulativeLast: amounts[3],
+			price1CumulativeLast: amounts[4],
+			blockTimestampLast:   &blockTimestampLast,
+		},
+		balances:   map[Address]*big.Int{},
+		allowances: map[Address]map[Address]*big.Int{},
+		nonces:     map[Address]uint64{},
+		dirty:      &dirty{isDirty: true, isDirtyBalances: true, isDirtyAllowances: true},
+	}
+
+	sum := big.NewInt(0)
+	for n := d.count(); n > 0; n-- {
+		address, balance := Address(d.string()), d.bigInt()
+		pair.balances[address] = balance
+		sum.Add(sum, balance)
+	}
+	for n := d.count(); n > 0; n-- {
+		owner := Address(d.string())
+		pair.allowances[owner] = map[Address]*big.Int{}
+		for m := d.count(); m > 0; m-- {
+			spender := Address(d.string())
+			pair.allowances[owner][spender] = d.bigInt()
+		}
+	}
+	for n := d.count(); n > 0; n-- {
+		address := Address(d.string())
+		pair.nonces[address] = d.uvarint()
+	}
+	metadata := map[string]string{}
+	for n := d.count(); n > 0; n-- {
+		key := d.string()
+		metadata[key] = d.string()
+	}
+
+	if d.err != nil || len(d.buf) != 0 || !key.isSorted() || !fee.valid() {
+		return nil, nil, ErrorInvalidEncoding
+	}
+	if sum.Cmp(pair.totalSupply) != 0 {
+		return nil, nil, ErrorInvalidTotalSupply
+	}
+	return pair, metadata, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *encoder) uvarint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func (e *encoder) varint(v int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	e.buf = append(e.buf, tmp[:binary.PutVarint(tmp[:], v)]...)
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.uvarint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// bigInt encodes a non-negative integer as its big-endian bytes.
+func (e *encoder) bigInt(v *big.Int) {
+	e.bytes(v.Bytes())
+}
+
+// decoder reads what encoder wrote. The first error sticks and makes every
+// later read return zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) fail() {
+	d.err = ErrorInvalidEncoding
+	d.buf = nil
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil || len(d.buf) == 0 {
+		d.fail()
+		return 0
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// count reads a number of items, each taking at least one byte.
+func (d *decoder) count() uint64 {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)) {
+		d.fail()
+		return 0
+	}
+	return n
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.count()
+	if d.err != nil {
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+func (d *decoder) bigInt() *big.Int {
+	return new(big.Int).SetBytes(d.bytes())
+}
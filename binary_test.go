@@ -0,0 +1,112 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_Unmarshal(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Approve("alice", "bob", big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	pair.SetMetadata("listed", "true")
+	tier, err := service.CreatePairWithFee(0, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tier.Mint("carol", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := marshal(t, service)
+	if data[0] != binaryVersion {
+		t.Errorf("version want %d, got %d", binaryVersion, data[0])
+	}
+	loaded := New()
+	if err := loaded.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(marshal(t, loaded), data) {
+		t.Error("encoding of the loaded service differs")
+	}
+	var want, got bytes.Buffer
+	if err := service.ExportJSON(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.ExportJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Errorf("state want\n%s\ngot\n%s", want.String(), got.String())
+	}
+
+	if err := loaded.Unmarshal(data); err != ErrorPairExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairExists)
+	}
+	for _, corrupted := range [][]byte{append([]byte{2}, data[1:]...), data[:len(data)-1], append(data, 0)} {
+		if err := New().Unmarshal(corrupted); err != ErrorInvalidEncoding {
+			t.Fatalf("failed with %v; want error %v", err, ErrorInvalidEncoding)
+		}
+	}
+}
+
+func TestPair_Unmarshal(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair.SetMetadata("listed", "true")
+	data := service.Pair(1, 0).Marshal()
+
+	_, err = pair.Mint("bob", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair.SetMetadata("listed", "false")
+	pair.SetMetadata("risk", "high")
+
+	if err := pair.Unmarshal(data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pair.Marshal(), data) {
+		t.Error("encoding of the restored pair differs")
+	}
+	if len(service.PositionsOf("bob")) != 0 {
+		t.Errorf("positions want none, got %v", service.PositionsOf("bob"))
+	}
+	if keys := pair.MetadataKeys(); len(keys) != 1 || keys[0] != "listed" {
+		t.Errorf("metadata keys want [listed], got %v", keys)
+	}
+
+	other, err := service.CreatePair(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Unmarshal(data); err != ErrorInvalidEncoding {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidEncoding)
+	}
+}
+
+func marshal(t *testing.T, s *UniswapV2) []byte {
+	data, err := s.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
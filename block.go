@@ -0,0 +1,186 @@
+package uniswapV2
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrorBlockInProgress = errors.New("BLOCK_IN_PROGRESS")
+	ErrorNoBlock         = errors.New("NO_BLOCK")
+	ErrorInvalidHeight   = errors.New("INVALID_HEIGHT")
+)
+
+type block struct {
+	height uint64
+	// clock is the clock of the service outside blocks
+	clock func() time.Time
+}
+
+// BlockOp is an operation delivered within a block, e.g. a Mint or a Swap of
+// a transaction.
+type BlockOp func(s *UniswapV2) error
+
+// BeginBlock starts a block at height, above the height of the last commit,
+// for ABCI-style hosts. Until EndBlock every operation, delivered with
+// DeliverOp, sees blockTime as the current time, so that price accumulators
+// advance by block timestamps. BeginBlock and EndBlock must not run
+// concurrently with other operations.
+func (s *UniswapV2) BeginBlock(height uint64, blockTime time.Time) error {
+	s.muBlock.Lock()
+	defer s.muBlock.Unlock()
+
+	if s.block != nil {
+		return ErrorBlockInProgress
+	}
+	if height <= s.Height() {
+		return ErrorInvalidHeight
+	}
+	s.block = &block{height: height, clock: s.clock}
+	s.clock = func() time.Time { return blockTime }
+	return nil
+}
+
+// DeliverOp runs op within the current block. Operations run one at a time
+// and either apply whole or not at all: if op fails, every change it made,
+// e.g. a pair created before a failing Mint, is undone and the block goes
+// on with the next operation. The events, audit records, metrics and logs
+// of op are delivered once it succeeds and dropped if it fails. Each pair
+// is saved when op first changes it, so no other operation may change the
+// pairs while op runs.
+func (s *UniswapV2) DeliverOp(op BlockOp) error {
+	s.muBlock.Lock()
+	defer s.muBlock.Unlock()
+
+	if s.block == nil {
+		return ErrorNoBlock
+	}
+	s.writeWALBestEffort(walRecord{Operation: "begin_op"})
+	s.beginOp()
+	if err := op(s); err != nil {
+		s.writeWALBestEffort(walRecord{Operation: "revert_op"})
+		s.endOp(false)
+		return err
+	}
+	s.writeWALBestEffort(walRecord{Operation: "end_op"})
+	s.endOp(true)
+	return nil
+}
+
+// beginOp opens the journal of an operation delivered by DeliverOp.
+func (s *UniswapV2) beginOp() {
+	s.muOp.Lock()
+	defer s.muOp.Unlock()
+
+	s.op = &journal{saved: map[*dirty]struct{}{}}
+}
+
+// endOp closes the journal of the operation delivered by DeliverOp,
+// delivering its effects if ok and undoing its changes otherwise.
+func (s *UniswapV2) endOp(ok bool) {
+	s.muOp.Lock()
+	j := s.op
+	s.op = nil
+	s.muOp.Unlock()
+
+	if j == nil {
+		return
+	}
+	if ok {
+		j.commit()
+	} else {
+		j.revert()
+	}
+}
+
+// opOpen reports whether DeliverOp is running an operation.
+func (s *UniswapV2) opOpen() bool {
+	s.muOp.Lock()
+	defer s.muOp.Unlock()
+	return s.op != nil
+}
+
+// undoOp adds undo to the journal of the operation run by DeliverOp, if any.
+func (s *UniswapV2) undoOp(undo func()) {
+	s.muOp.Lock()
+	defer s.muOp.Unlock()
+
+	if s.op != nil {
+		s.op.add(undo)
+	}
+}
+
+// saveForOp saves the pair, its balances, allowances and metadata the first
+// time the operation run by DeliverOp changes it, to be restored if the
+// operation fails. The caller holds no lock of the pair.
+func (p *Pair) saveForOp() {
+	s := p.service
+	s.muOp.Lock()
+	save := s.op != nil
+	if save {
+		_, saved := s.op.saved[p.dirty]
+		save = !saved
+		s.op.saved[p.dirty] = struct{}{}
+	}
+	s.muOp.Unlock()
+	if !save {
+		return
+	}
+
+	checkpoint, metadata := p.Checkpoint(), p.metadata()
+	s.undoOp(func() {
+		p.rollback(checkpoint.state)
+		p.setMetadata(metadata)
+	})
+}
+
+// deliver runs fn, which delivers an event, audit record, metric or log of
+// a change, at once or, within DeliverOp, once the operation succeeds.
+func (s *UniswapV2) deliver(fn func()) {
+	s.muOp.Lock()
+	j := s.op
+	if j != nil {
+		j.deliver(fn)
+	}
+	s.muOp.Unlock()
+	if j == nil {
+		fn()
+	}
+}
+
+// EndBlock commits the changes of the block to writer at the height of the
+// block and returns the StateRoot after it. Like Commit it writes either
+// everything or, failing, leaves the changes uncommitted, in which case the
+// block stays open and EndBlock may be retried, or the changes dropped with
+// Discard, which closes the block. If the StateRoot fails after the commit,
+// the block stays open as well and EndBlock may be retried.
+func (s *UniswapV2) EndBlock(writer StateWriter) (root [32]byte, err error) {
+	s.muBlock.Lock()
+	defer s.muBlock.Unlock()
+
+	if s.block == nil {
+		return root, ErrorNoBlock
+	}
+	s.muPairs.Lock()
+	err = s.commit(writer, s.block.height)
+	s.muPairs.Unlock()
+	if err != nil {
+		return root, err
+	}
+
+	if root, err = s.StateRoot(); err != nil {
+		return root, err
+	}
+	s.closeBlock()
+	return root, nil
+}
+
+// closeBlock ends the current block, if any, restoring the clock of the
+// service. The caller holds muBlock.
+func (s *UniswapV2) closeBlock() {
+	if s.block == nil {
+		return
+	}
+	s.clock = s.block.clock
+	s.block = nil
+}
@@ -0,0 +1,252 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestUniswapV2_EndBlock(t *testing.T) {
+	service := New(WithHistory())
+	writer := &recordingWriter{}
+
+	if err := service.DeliverOp(func(s *UniswapV2) error { return nil }); err != ErrorNoBlock {
+		t.Fatalf("failed with %v; want error %v", err, ErrorNoBlock)
+	}
+	if err := service.BeginBlock(10, time.Unix(1600000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.BeginBlock(11, time.Unix(1600000005, 0)); err != ErrorBlockInProgress {
+		t.Fatalf("failed with %v; want error %v", err, ErrorBlockInProgress)
+	}
+	err := service.DeliverOp(func(s *UniswapV2) error {
+		pair, err := s.CreatePair(0, 1)
+		if err != nil {
+			return err
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := service.EndBlock(writer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != stateRoot(t, service) || service.Height() != 10 || len(writer.pairs) != 1 {
+		t.Errorf("block is not committed at 10: height %d, %d pairs written", service.Height(), len(writer.pairs))
+	}
+	if _, _, timestamp := service.Pair(0, 1).CumulativePrices(); timestamp != 1600000000 {
+		t.Errorf("timestamp want 1600000000, got %d", timestamp)
+	}
+
+	if err := service.BeginBlock(10, time.Unix(1600000005, 0)); err != ErrorInvalidHeight {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidHeight)
+	}
+	if err := service.BeginBlock(11, time.Unix(1600000005, 0)); err != nil {
+		t.Fatal(err)
+	}
+	err = service.DeliverOp(func(s *UniswapV2) error {
+		_, _, err := s.Pair(0, 1).Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(3e16))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	price0, _, timestamp := service.Pair(0, 1).CumulativePrices()
+	if timestamp != 1600000005 || price0.Cmp(new(big.Int).Lsh(big.NewInt(4*5), resolution)) != 0 {
+		t.Errorf("accumulator want 20<<112 at 1600000005, got %s at %d", price0, timestamp)
+	}
+
+	writer.err = ErrorInvalidFee
+	if _, err := service.EndBlock(writer); err != ErrorInvalidFee {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidFee)
+	}
+	writer.err = nil
+	if _, err := service.EndBlock(writer); err != nil {
+		t.Fatal(err)
+	}
+	if reserve0, _, _, err := service.PairAt(0, 1, 11); err != nil || reserve0.Cmp(big.NewInt(1e18+1e16)) != 0 {
+		t.Errorf("reserve0 at 11 want %d, got %s, %v", int64(1e18+1e16), reserve0, err)
+	}
+	if service.clock != nil {
+		t.Error("clock is not restored")
+	}
+}
+
+func TestUniswapV2_DeliverOp_failing(t *testing.T) {
+	service := New()
+	writer := &recordingWriter{}
+	if err := service.BeginBlock(1, time.Unix(1600000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	err := service.DeliverOp(func(s *UniswapV2) error {
+		pair, err := s.CreatePair(0, 1)
+		if err != nil {
+			return err
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errOp := errors.New("op")
+	err = service.DeliverOp(func(s *UniswapV2) error {
+		if _, err := s.CreatePair(1, 2); err != nil {
+			return err
+		}
+		_, _, err := s.Pair(0, 1).Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(3e16))
+		if err != nil {
+			return err
+		}
+		return errOp
+	})
+	if err != errOp {
+		t.Fatalf("failed with %v; want error %v", err, errOp)
+	}
+	if service.Pair(1, 2) != nil {
+		t.Error("pair created by the failed op is kept")
+	}
+	if reserve0, _ := service.Pair(0, 1).Reserves(); reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserve0 want %d, got %s", int64(1e18), reserve0)
+	}
+
+	if _, err := service.EndBlock(writer); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.pairs) != 1 {
+		t.Errorf("pairs written want 1, got %d", len(writer.pairs))
+	}
+}
+
+func TestUniswapV2_Discard_closesBlock(t *testing.T) {
+	service := New()
+	writer := &recordingWriter{err: ErrorInvalidFee}
+	if err := service.BeginBlock(1, time.Unix(1600000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	err := service.DeliverOp(func(s *UniswapV2) error {
+		_, err := s.CreatePair(0, 1)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.EndBlock(writer); err != ErrorInvalidFee {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidFee)
+	}
+
+	service.Discard()
+	if service.Pair(0, 1) != nil {
+		t.Error("pair of the discarded block is kept")
+	}
+	if service.clock != nil {
+		t.Error("clock is not restored")
+	}
+	if err := service.DeliverOp(func(s *UniswapV2) error { return nil }); err != ErrorNoBlock {
+		t.Fatalf("failed with %v; want error %v", err, ErrorNoBlock)
+	}
+	if err := service.BeginBlock(1, time.Unix(1600000005, 0)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUniswapV2_DeliverOp_journal(t *testing.T) {
+	var log bytes.Buffer
+	service := New(WithWAL(&log))
+	if err := service.BeginBlock(1, time.Unix(1600000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	err := service.DeliverOp(func(s *UniswapV2) error {
+		pair, err := s.CreatePair(0, 1)
+		if err != nil {
+			return err
+		}
+		if _, err := s.CreatePair(2, 3); err != nil {
+			return err
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := stateRoot(t, service)
+	pair := service.Pair(1, 0)
+
+	errOp := errors.New("op")
+	err = service.DeliverOp(func(s *UniswapV2) error {
+		if err := s.RemovePair(2, 3); err != nil {
+			return err
+		}
+		created, err := s.CreatePair(1, 2)
+		if err != nil {
+			return err
+		}
+		if _, err := s.CreatePairs([]PairSpec{{TokenA: 4, TokenB: 5}}); err != nil {
+			return err
+		}
+		if _, err := created.Mint("bob", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+			return err
+		}
+		if _, _, err := pair.Swap(big.NewInt(0), big.NewInt(1e16), big.NewInt(1e15), big.NewInt(0)); err != nil {
+			return err
+		}
+		if err := pair.Approve("alice", "bob", big.NewInt(5)); err != nil {
+			return err
+		}
+		if err := pair.TransferFrom("bob", "alice", "bob", big.NewInt(5)); err != nil {
+			return err
+		}
+		pair.SetMetadata("name", "0/1")
+		return errOp
+	})
+	if err != errOp {
+		t.Fatalf("failed with %v; want error %v", err, errOp)
+	}
+	if stateRoot(t, service) != root {
+		t.Error("state root changed by the failed op")
+	}
+	if service.Pair(2, 3) == nil || service.Pair(1, 2) != nil || service.Pair(4, 5) != nil {
+		t.Error("pairs removed or created by the failed op are not restored")
+	}
+	if value, ok := pair.Metadata("name"); ok || pair.Balance("bob") != nil || pair.Allowance("alice", "bob").Sign() != 0 {
+		t.Errorf("pair is not restored: metadata %q, bob %s", value, pair.Balance("bob"))
+	}
+	if positions := service.PositionsOf("bob"); len(positions) != 0 {
+		t.Errorf("positions of bob want none, got %v", positions)
+	}
+	if bytes.Contains(log.Bytes(), []byte(`"snapshot"`)) {
+		t.Error("DeliverOp takes a snapshot")
+	}
+
+	recovered := New()
+	if err := recovered.Recover(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if stateRoot(t, recovered) != root {
+		t.Error("recovered state root differs")
+	}
+
+	// a crash within an operation
+	err = service.DeliverOp(func(s *UniswapV2) error {
+		_, err := pair.Mint("carol", big.NewInt(1e17), big.NewInt(4e17))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cut := log.Bytes()[:bytes.LastIndex(log.Bytes(), []byte(`{"time"`))]
+	recovered = New()
+	if err := recovered.Recover(bytes.NewReader(cut)); err != nil {
+		t.Fatal(err)
+	}
+	if stateRoot(t, recovered) != root || recovered.opOpen() {
+		t.Error("operation cut short is not undone")
+	}
+}
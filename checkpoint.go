@@ -0,0 +1,65 @@
+package uniswapV2
+
+import "errors"
+
+var (
+	ErrorInvalidCheckpoint = errors.New("INVALID_CHECKPOINT")
+)
+
+// PairCheckpoint is an opaque copy of the state of a pair taken by Checkpoint.
+type PairCheckpoint struct {
+	service *UniswapV2
+	state   *Pair
+}
+
+// Checkpoint copies the reserves, total supply, balances and allowances of
+// the pair, to be restored with Rollback from either view of the pair.
+func (p *Pair) Checkpoint() PairCheckpoint {
+	state := p.clone()
+	if !p.key.isSorted() {
+		state.key = p.key.Revert()
+		state.pairData = state.pairData.Revert()
+	}
+	return PairCheckpoint{service: p.service, state: state}
+}
+
+// Rollback restores the pair to checkpoint in place and updates the
+// positions of every address whose balance it changes. A checkpoint may be
+// rolled back to more than once.
+func (p *Pair) Rollback(checkpoint PairCheckpoint) error {
+	if checkpoint.state == nil || checkpoint.service != p.service || checkpoint.state.key != p.key.sort() {
+		return ErrorInvalidCheckpoint
+	}
+
+	p.saveForOp()
+	if p.rollback(checkpoint.state.clone()) {
+		p.emit(Event{Kind: EventRevert})
+	}
+	return nil
+}
+
+// rollback restores state, a canonical copy not shared with anything else,
+// and updates the positions of the addresses holding liquidity before or
+// after. It reports whether the reserves, total supply or balances changed.
+func (p *Pair) rollback(state *Pair) bool {
+	p.muBalance.RLock()
+	addresses := make(map[Address]struct{}, len(p.balances))
+	for address := range p.balances {
+		addresses[address] = struct{}{}
+	}
+	p.muBalance.RUnlock()
+	for address := range state.balances {
+		addresses[address] = struct{}{}
+	}
+
+	canonical := p
+	if !p.key.isSorted() {
+		canonical = p.revert()
+	}
+	changed := canonical.restore(state)
+
+	for address := range addresses {
+		p.service.updatePosition(address, p.key.sort(), p.Balance(address))
+	}
+	return changed
+}
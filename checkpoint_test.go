@@ -0,0 +1,57 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestPair_Rollback(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reversed := service.Pair(1, 0)
+	checkpoint := reversed.Checkpoint()
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("other", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := pair.Rollback(checkpoint); err != nil {
+			t.Fatal(err)
+		}
+		reserve0, reserve1 := pair.Reserves()
+		if reserve0.Cmp(big.NewInt(1e18)) != 0 || reserve1.Cmp(big.NewInt(2e18)) != 0 {
+			t.Errorf("reserves want %s, %s, got %s, %s", big.NewInt(1e18), big.NewInt(2e18), reserve0, reserve1)
+		}
+		if pair.Balance("other") != nil || len(service.PositionsOf("other")) != 0 {
+			t.Errorf("other want no balance nor positions, got %s", pair.Balance("other"))
+		}
+		_, err = pair.Mint("other", big.NewInt(1e18), big.NewInt(2e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	other, err := service.CreatePair(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Rollback(checkpoint); err != ErrorInvalidCheckpoint {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidCheckpoint)
+	}
+	if err := pair.Rollback(PairCheckpoint{}); err != ErrorInvalidCheckpoint {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidCheckpoint)
+	}
+}
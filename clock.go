@@ -0,0 +1,59 @@
+package uniswapV2
+
+import (
+	"sync"
+	"time"
+)
+
+// SimulatedClock is a manually advanced time source for tests and
+// simulations, to be passed as WithClock(clock.Now). Accumulators only depend
+// on the elapsed time, so skipping any period in one Advance gives the same
+// state as living through it without trades.
+type SimulatedClock struct {
+	mu        sync.Mutex
+	now       time.Time
+	listeners []func(now time.Time)
+}
+
+func NewSimulatedClock(start time.Time) *SimulatedClock {
+	return &SimulatedClock{now: start}
+}
+
+func (c *SimulatedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// OnAdvance registers f to be called with the new time after every Advance,
+// e.g. to update an Oracle.
+func (c *SimulatedClock) OnAdvance(f func(now time.Time)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, f)
+}
+
+// Advance moves the clock forward by d in steps of at most step, calling the
+// listeners after each step in registration order. A non-positive step
+// advances in a single step.
+func (c *SimulatedClock) Advance(d, step time.Duration) {
+	if step <= 0 || step > d {
+		step = d
+	}
+	for d > 0 {
+		if step > d {
+			step = d
+		}
+		d -= step
+
+		c.mu.Lock()
+		c.now = c.now.Add(step)
+		now, listeners := c.now, make([]func(now time.Time), len(c.listeners))
+		copy(listeners, c.listeners)
+		c.mu.Unlock()
+
+		for _, listener := range listeners {
+			listener(now)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestSimulatedClock_Advance(t *testing.T) {
+	clock := NewSimulatedClock(time.Unix(1000, 0))
+	service := New(WithClock(clock.Now))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oracle := NewOracle(24 * time.Hour)
+	updates := 0
+	clock.OnAdvance(func(time.Time) {
+		if err := oracle.Update(pair); err != nil {
+			t.Fatal(err)
+		}
+		updates++
+	})
+
+	clock.Advance(30*24*time.Hour, time.Hour)
+	if updates != 30*24 {
+		t.Errorf("updates want %d, got %d", 30*24, updates)
+	}
+	if want := time.Unix(1000, 0).Add(30 * 24 * time.Hour); !clock.Now().Equal(want) {
+		t.Errorf("now want %s, got %s", want, clock.Now())
+	}
+
+	price, err := oracle.Consult(pair, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Cmp(big.NewRat(2, 1)) != 0 {
+		t.Errorf("price want %s, got %s", big.NewRat(2, 1), price)
+	}
+
+	clock.Advance(90*time.Minute, 0)
+	if updates != 30*24+1 {
+		t.Errorf("updates want %d, got %d", 30*24+1, updates)
+	}
+}
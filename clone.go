@@ -0,0 +1,171 @@
+package uniswapV2
+
+import (
+	"container/list"
+	"math/big"
+	"sync"
+)
+
+// Clone returns an independent copy of the service, with its pairs,
+// balances, allowances, metadata, tokens, hooks, quoters and commit
+// history, to be changed freely, e.g. for what-if analysis, while the
+// service keeps serving. The copy has no audit log, events, metrics,
+// logger, tracer or WAL. With WithLazyStorage, the pairs not loaded are
+// loaded by the copy from the same storage when asked for.
+func (s *UniswapV2) Clone() *UniswapV2 {
+	c := s.clone()
+	if s.lazy != nil {
+		c.lazy = &lazyPairs{storage: s.lazy.storage, maxLoaded: s.lazy.maxLoaded, recent: list.New(), elements: map[pairKey]*list.Element{}}
+		for _, key := range c.sortedKeys() {
+			c.lazy.use(key)
+		}
+	}
+	return c
+}
+
+func (s *UniswapV2) clone() *UniswapV2 {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	return s.cloneLocked()
+}
+
+// cloneLocked is clone for a caller holding the muPairs lock.
+func (s *UniswapV2) cloneLocked() *UniswapV2 {
+	c := &UniswapV2{
+		options:         s.options,
+		pairs:           make(map[pairKey]*Pair, len(s.pairs)),
+		keyPairs:        make([]pairKey, len(s.keyPairs)),
+		isDirtyKeyPairs: s.isDirtyKeyPairs,
+		tiers:           make(map[pairKey][]uint32, len(s.tiers)),
+		routes:          newRoutingIndex(),
+		positions:       map[Address]map[pairKey]struct{}{},
+		swapHooks:       map[pairKey][]SwapHook{},
+		metadata:        map[pairKey]map[string]string{},
+
+		committed:         make(map[pairKey]*Pair, len(s.committed)),
+		committedMetadata: make(map[pairKey]map[string]string, len(s.committedMetadata)),
+		height:            s.height,
+		prunedBelow:       s.prunedBelow,
+		versions:          make(map[pairKey][]pairVersion, len(s.versions)),
+	}
+	if s.routeCache != nil {
+		c.routeCache = newRouteCache(s.routeCache.size, s.routeCache.tolerance)
+	}
+	c.auditLog, c.events, c.metrics, c.logger, c.tracer, c.wal, c.lazy = nil, nil, nil, nil, nil, nil, nil
+	copy(c.keyPairs, s.keyPairs)
+	for key, fees := range s.tiers {
+		c.tiers[key] = append([]uint32(nil), fees...)
+	}
+	c.routes.rebuild(c.tokenPairs())
+	// committed states are never modified, only replaced
+	for key, pair := range s.committed {
+		c.committed[key] = pair
+	}
+	for key, metadata := range s.committedMetadata {
+		c.committedMetadata[key] = metadata
+	}
+	for key, versions := range s.versions {
+		c.versions[key] = versions[:len(versions):len(versions)]
+	}
+	for key, pair := range s.pairs {
+		pair := pair.clone()
+		pair.service = c
+		c.pairs[key] = pair
+	}
+
+	s.muPositions.RLock()
+	defer s.muPositions.RUnlock()
+	for address, keys := range s.positions {
+		c.positions[address] = make(map[pairKey]struct{}, len(keys))
+		for key := range keys {
+			c.positions[address][key] = struct{}{}
+		}
+	}
+
+	s.muHooks.RLock()
+	defer s.muHooks.RUnlock()
+	for key, hooks := range s.swapHooks {
+		c.swapHooks[key] = append([]SwapHook(nil), hooks...)
+	}
+	c.hooks = append([]Hooks(nil), s.hooks...)
+
+	s.muMetadata.RLock()
+	defer s.muMetadata.RUnlock()
+	for key, metadata := range s.metadata {
+		c.metadata[key] = make(map[string]string, len(metadata))
+		for k, v := range metadata {
+			c.metadata[key][k] = v
+		}
+	}
+
+	s.muTokens.RLock()
+	defer s.muTokens.RUnlock()
+	if s.tokens != nil {
+		c.tokens = make(map[Token]TokenInfo, len(s.tokens))
+		for token, info := range s.tokens {
+			c.tokens[token] = info
+		}
+	}
+
+	s.quoters.mu.RLock()
+	defer s.quoters.mu.RUnlock()
+	for _, name := range s.quoters.names {
+		c.AddQuoter(name, s.quoters.quoters[name])
+	}
+	return c
+}
+
+func (p *Pair) clone() *Pair {
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+	p.muBalance.RLock()
+	defer p.muBalance.RUnlock()
+
+	balances := make(map[Address]*big.Int, len(p.balances))
+	for address, balance := range p.balances {
+		balances[address] = new(big.Int).Set(balance)
+	}
+	allowances := make(map[Address]map[Address]*big.Int, len(p.allowances))
+	for owner, spenders := range p.allowances {
+		allowances[owner] = make(map[Address]*big.Int, len(spenders))
+		for spender, allowance := range spenders {
+			allowances[owner][spender] = new(big.Int).Set(allowance)
+		}
+	}
+	nonces := make(map[Address]uint64, len(p.nonces))
+	for owner, nonce := range p.nonces {
+		nonces[owner] = nonce
+	}
+
+	dirtyAddresses := make(map[Address]struct{}, len(p.dirtyAddresses))
+	for address := range p.dirtyAddresses {
+		dirtyAddresses[address] = struct{}{}
+	}
+
+	blockTimestampLast := *p.blockTimestampLast
+	return &Pair{
+		key: p.key,
+		fee: p.fee,
+		pairData: pairData{
+			pairMutex:            &pairMutex{},
+			reserve0:             new(big.Int).Set(p.reserve0),
+			reserve1:             new(big.Int).Set(p.reserve1),
+			totalSupply:          new(big.Int).Set(p.totalSupply),
+			price0CumulativeLast: new(big.Int).Set(p.price0CumulativeLast),
+			price1CumulativeLast: new(big.Int).Set(p.price1CumulativeLast),
+			blockTimestampLast:   &blockTimestampLast,
+		},
+		muBalance:  &sync.RWMutex{},
+		balances:   balances,
+		allowances: allowances,
+		nonces:     nonces,
+		dirty: &dirty{
+			isDirty:           p.isDirty,
+			isDirtyBalances:   p.isDirtyBalances,
+			isDirtyAllowances: p.isDirtyAllowances,
+			dirtyAddresses:    dirtyAddresses,
+			allBalances:       p.allBalances,
+		},
+	}
+}
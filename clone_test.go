@@ -0,0 +1,84 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_Clone(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Approve("alice", "bob", big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	state := marshal(t, service)
+
+	clone := service.Clone()
+	if !bytes.Equal(marshal(t, clone), state) {
+		t.Fatal("encoding of the clone differs")
+	}
+	clonePair := clone.Pair(1, 0)
+	_, _, err = clonePair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = clone.Pair(0, 1).Burn("alice", big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clone.CreatePair(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(marshal(t, service), state) {
+		t.Fatal("changes of the clone changed the service")
+	}
+	if service.Pair(1, 2) != nil {
+		t.Error("pair created in the clone exists in the service")
+	}
+
+	_, err = pair.Mint("carol", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := clone.Pair(0, 1).Balance("carol"); balance != nil {
+		t.Errorf("balance in the clone want none, got %s", balance)
+	}
+}
+
+func TestUniswapV2_CloneLazy(t *testing.T) {
+	storage := NewMemoryStorage()
+	service := New()
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	lazy := New(WithLazyStorage(storage, 1))
+	if lazy.Pair(0, 1) == nil {
+		t.Fatal("pair 0-1 not loaded")
+	}
+	clone := lazy.Clone()
+	if clone.Pair(1, 2) == nil || clone.Pair(0, 1) == nil {
+		t.Fatal("pairs not loaded by the clone")
+	}
+	if keys, _ := lazy.Pairs(); len(keys) != 1 {
+		t.Errorf("loaded pairs of the service want 1, got %v", keys)
+	}
+}
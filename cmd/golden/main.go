@@ -0,0 +1,52 @@
+// Command golden writes or verifies the golden file of an operation corpus,
+// see uniswapV2.WriteGolden.
+//
+//	golden -corpus corpus.jsonl -golden golden.jsonl [-update]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	uniswapV2 "github.com/klim0v/uniswapV2"
+)
+
+func main() {
+	corpusPath := flag.String("corpus", "corpus.jsonl", "operations in the WAL format")
+	goldenPath := flag.String("golden", "golden.jsonl", "outcomes of the operations")
+	update := flag.Bool("update", false, "write the golden file instead of verifying it")
+	flag.Parse()
+
+	if err := run(*corpusPath, *goldenPath, *update); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(corpusPath, goldenPath string, update bool) error {
+	corpus, err := os.Open(corpusPath)
+	if err != nil {
+		return err
+	}
+	defer corpus.Close()
+
+	if update {
+		golden, err := os.Create(goldenPath)
+		if err != nil {
+			return err
+		}
+		if err := uniswapV2.WriteGolden(corpus, golden); err != nil {
+			golden.Close()
+			return err
+		}
+		return golden.Close()
+	}
+
+	golden, err := os.Open(goldenPath)
+	if err != nil {
+		return err
+	}
+	defer golden.Close()
+	return uniswapV2.VerifyGolden(corpus, golden)
+}
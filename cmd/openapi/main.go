@@ -0,0 +1,38 @@
+// Command openapi writes the OpenAPI document of the HTTP handlers, see
+// uniswapV2.WriteOpenAPI.
+//
+//	openapi [-o openapi.json]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	uniswapV2 "github.com/klim0v/uniswapV2"
+)
+
+func main() {
+	outputPath := flag.String("o", "", "file to write instead of the standard output")
+	flag.Parse()
+
+	if err := run(*outputPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(outputPath string) error {
+	if outputPath == "" {
+		return uniswapV2.WriteOpenAPI(os.Stdout)
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	if err := uniswapV2.WriteOpenAPI(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,82 @@
+package uniswapV2
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// TokenCodec converts tokens to and from their text form in exports.
+type TokenCodec interface {
+	EncodeToken(token Token) (string, error)
+	DecodeToken(text string) (Token, error)
+}
+
+// AddressCodec converts addresses to and from their text form in exports.
+type AddressCodec interface {
+	EncodeAddress(address Address) (string, error)
+	DecodeAddress(text string) (Address, error)
+}
+
+// ExportTokenCodec and ExportAddressCodec let deployments serialize
+// identities in their native formats, e.g. hex or bech32, wherever tokens
+// and addresses are encoded as JSON. Like AddressLess they are set once
+// before any service is used. Without a codec tokens are JSON numbers and
+// addresses are written as they are.
+var (
+	ExportTokenCodec   TokenCodec
+	ExportAddressCodec AddressCodec
+)
+
+func (t Token) MarshalJSON() ([]byte, error) {
+	if ExportTokenCodec == nil {
+		return []byte(strconv.FormatInt(int64(t), 10)), nil
+	}
+	text, err := ExportTokenCodec.EncodeToken(t)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(text)
+}
+
+func (t *Token) UnmarshalJSON(data []byte) error {
+	if ExportTokenCodec == nil {
+		var token int32
+		if err := json.Unmarshal(data, &token); err != nil {
+			return err
+		}
+		*t = Token(token)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	token, err := ExportTokenCodec.DecodeToken(text)
+	if err != nil {
+		return err
+	}
+	*t = token
+	return nil
+}
+
+// MarshalText is used for addresses both as JSON values and as map keys.
+func (a Address) MarshalText() ([]byte, error) {
+	if ExportAddressCodec == nil {
+		return []byte(a), nil
+	}
+	text, err := ExportAddressCodec.EncodeAddress(a)
+	return []byte(text), err
+}
+
+func (a *Address) UnmarshalText(text []byte) error {
+	if ExportAddressCodec == nil {
+		*a = Address(text)
+		return nil
+	}
+	address, err := ExportAddressCodec.DecodeAddress(string(text))
+	if err != nil {
+		return err
+	}
+	*a = address
+	return nil
+}
@@ -0,0 +1,71 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+type hexCodec struct{}
+
+func (hexCodec) EncodeToken(token Token) (string, error) {
+	return fmt.Sprintf("0x%08x", uint32(token)), nil
+}
+
+func (hexCodec) DecodeToken(text string) (Token, error) {
+	var token uint32
+	_, err := fmt.Sscanf(text, "0x%08x", &token)
+	return Token(token), err
+}
+
+func (hexCodec) EncodeAddress(address Address) (string, error) {
+	return "0x" + hex.EncodeToString([]byte(address)), nil
+}
+
+func (hexCodec) DecodeAddress(text string) (Address, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(text, "0x"))
+	return Address(data), err
+}
+
+func TestExportCodecs(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var plain bytes.Buffer
+	if err := service.DumpPair(0, 1, &plain); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(plain.String(), `"token1": 1,`) || !strings.Contains(plain.String(), `"address": "address"`) {
+		t.Errorf("dump without codecs want native identities, got %s", plain.String())
+	}
+
+	defer func() { ExportTokenCodec, ExportAddressCodec = nil, nil }()
+	ExportTokenCodec, ExportAddressCodec = hexCodec{}, hexCodec{}
+
+	var buf bytes.Buffer
+	if err := service.DumpPair(0, 1, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"token1": "0x00000001"`) || !strings.Contains(buf.String(), `"address": "0x61646472657373"`) {
+		t.Errorf("dump want hex identities, got %s", buf.String())
+	}
+
+	var dump pairDump
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if dump.Token1 != 1 || dump.Balances[1].Address != "address" {
+		t.Errorf("decoded identities want 1 and %q, got %d and %q", "address", dump.Token1, dump.Balances[1].Address)
+	}
+}
@@ -0,0 +1,291 @@
+package uniswapV2
+
+import "math/big"
+
+// PairState is the persisted state of a pair, in canonical token order.
+type PairState struct {
+	Token0, Token1       Token
+	FeeTier              uint32
+	Fee                  Fee
+	Reserve0, Reserve1   *big.Int
+	TotalSupply          *big.Int
+	Price0CumulativeLast *big.Int
+	Price1CumulativeLast *big.Int
+	BlockTimestampLast   uint32
+	Metadata             map[string]string
+}
+
+// StateWriter persists the changes walked by Commit.
+type StateWriter interface {
+	// WritePair is called for every pair created or changed since the last commit.
+	WritePair(state PairState) error
+	// WriteBalances is called with all LP balances of a pair whose balances
+	// changed since the last commit, replacing the persisted ones.
+	WriteBalances(token0, token1 Token, feeTier uint32, balances map[Address]*big.Int) error
+}
+
+// BalanceUpdater is implemented by a StateWriter that can write single LP
+// balances. For a pair committed before, Commit calls UpdateBalances instead
+// of WriteBalances with only the balances changed since, nil for an address
+// holding none any more, so that the writes do not grow with the number of
+// holders.
+type BalanceUpdater interface {
+	UpdateBalances(token0, token1 Token, feeTier uint32, balances map[Address]*big.Int) error
+}
+
+// AllowanceWriter is implemented by a StateWriter that can persist
+// allowances and permit nonces. Commit calls it with all of them, replacing
+// the persisted ones, for every pair created or whose allowances or nonces
+// changed since the last commit; without it they are kept in memory only.
+type AllowanceWriter interface {
+	WriteAllowances(token0, token1 Token, feeTier uint32, allowances map[Address]map[Address]*big.Int, nonces map[Address]uint64) error
+}
+
+// BatchWriter is implemented by a StateWriter that can apply the writes of
+// a commit atomically. Commit calls BeginBatch before the first write and
+// EndBatch after the last with the error failing the commit, if any; the
+// commit succeeds only if EndBatch returns nil.
+type BatchWriter interface {
+	BeginBatch() error
+	EndBatch(err error) error
+}
+
+// Commit passes the pairs and balances changed since the last commit, in
+// canonical key order, to writer and clears their dirty flags. If writer
+// fails, the flags are left set so that the next Commit writes everything
+// again. Commit and Discard must not run concurrently with other operations.
+func (s *UniswapV2) Commit(writer StateWriter) error {
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+
+	return s.commit(writer, s.height+1)
+}
+
+// commit is Commit at height. The caller holds the muPairs write lock.
+func (s *UniswapV2) commit(writer StateWriter, height uint64) error {
+	batch, batched := writer.(BatchWriter)
+	if batched {
+		if err := batch.BeginBatch(); err != nil {
+			return err
+		}
+	}
+	removed, committed, err := s.write(writer)
+	if batched {
+		err = batch.EndBatch(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.forgetRemovedPairs(removed)
+	s.markCommitted(committed)
+	s.recordHistory(removed, committed, height)
+	s.isDirtyKeyPairs = false
+	s.writeWALBestEffort(walRecord{Operation: "commit", Height: height})
+	return nil
+}
+
+// write passes the changes since the last commit to writer and returns the
+// committed pairs removed and the pairs written. The caller holds the
+// muPairs write lock.
+func (s *UniswapV2) write(writer StateWriter) (removed []pairKey, committed []*Pair, err error) {
+	if removed, err = s.deleteRemovedPairs(writer); err != nil {
+		return nil, nil, err
+	}
+	for _, key := range s.sortedKeys() {
+		pair := s.pairs[key]
+		_, known := s.committed[key]
+		if known && !pair.changed() {
+			continue
+		}
+		if !known || pair.isDirty {
+			if err := writer.WritePair(pair.state()); err != nil {
+				return nil, nil, err
+			}
+		}
+		if pair.isDirtyBalances || !known {
+			if err := writeBalances(writer, pair, known); err != nil {
+				return nil, nil, err
+			}
+		}
+		if allowanceWriter, ok := writer.(AllowanceWriter); ok && (pair.isDirtyAllowances || !known) {
+			allowances, nonces := pair.allowancesCopy()
+			if err := allowanceWriter.WriteAllowances(key.TokenA, key.TokenB, key.Fee, allowances, nonces); err != nil {
+				return nil, nil, err
+			}
+		}
+		committed = append(committed, pair)
+	}
+	return removed, committed, nil
+}
+
+// writeBalances passes the LP balances of a canonical pair to writer, only
+// the changed ones if it is a BalanceUpdater and the pair was committed
+// before.
+func writeBalances(writer StateWriter, pair *Pair, known bool) error {
+	key := pair.key
+	if updater, ok := writer.(BalanceUpdater); ok && known && !pair.allBalances {
+		return updater.UpdateBalances(key.TokenA, key.TokenB, key.Fee, pair.dirtyBalances())
+	}
+	return writer.WriteBalances(key.TokenA, key.TokenB, key.Fee, pair.balancesCopy())
+}
+
+// markCommitted makes the current state of the canonical pairs their commit
+// baseline and clears their dirty flags. The caller holds the muPairs write
+// lock.
+func (s *UniswapV2) markCommitted(pairs []*Pair) {
+	for _, pair := range pairs {
+		saved := pair.clone()
+		saved.dirty = &dirty{}
+		s.committed[pair.key] = saved
+		s.committedMetadata[pair.key] = pair.metadata()
+		pair.clean()
+	}
+}
+
+// clean clears the dirty flags of the pair.
+func (p *Pair) clean() {
+	p.pairData.Lock()
+	defer p.pairData.Unlock()
+	p.muBalance.Lock()
+	defer p.muBalance.Unlock()
+
+	*p.dirty = dirty{}
+}
+
+// Discard drops all changes since the last commit: changed pairs are restored
+// in place, pairs created since are removed and pairs removed since are
+// added back. A block begun since is closed without committing; Discard
+// must not be called from a BlockOp.
+func (s *UniswapV2) Discard() {
+	s.muBlock.Lock()
+	s.closeBlock()
+	s.muBlock.Unlock()
+	s.writeWALBestEffort(walRecord{Operation: "discard"})
+
+	var restored, removed []*Pair
+	defer func() { emitReverts(restored, removed) }()
+
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+
+	removedAny := false
+	for _, key := range s.sortedKeys() {
+		pair := s.pairs[key]
+		saved, known := s.committed[key]
+		if !known {
+			s.removePair(pair)
+			removed = append(removed, pair)
+			removedAny = true
+			continue
+		}
+		if !pair.changed() {
+			continue
+		}
+		if pair.rollback(saved.clone()) {
+			restored = append(restored, pair)
+		}
+		pair.clean()
+
+		s.muMetadata.Lock()
+		delete(s.metadata, key)
+		if metadata := s.committedMetadata[key]; len(metadata) != 0 {
+			s.metadata[key] = make(map[string]string, len(metadata))
+			for k, v := range metadata {
+				s.metadata[key][k] = v
+			}
+		}
+		s.muMetadata.Unlock()
+	}
+	if added := s.restoreRemovedPairs(); len(added) != 0 {
+		restored = append(restored, added...)
+		removedAny = true
+	}
+	if removedAny {
+		s.routes.rebuild(s.tokenPairs())
+	}
+	s.isDirtyKeyPairs = false
+}
+
+// removePair deletes a canonical pair and the positions in it. The caller
+// holds the muPairs write lock.
+func (s *UniswapV2) removePair(pair *Pair) {
+	key := pair.key
+	delete(s.pairs, key)
+
+	tiers := s.tiers[key.tokens()][:0]
+	for _, fee := range s.tiers[key.tokens()] {
+		if fee != key.Fee {
+			tiers = append(tiers, fee)
+		}
+	}
+	if len(tiers) == 0 {
+		delete(s.tiers, key.tokens())
+	} else {
+		s.tiers[key.tokens()] = tiers
+	}
+
+	keyPairs := s.keyPairs[:0]
+	for _, keyPair := range s.keyPairs {
+		if keyPair != key {
+			keyPairs = append(keyPairs, keyPair)
+		}
+	}
+	s.keyPairs = keyPairs
+
+	for address := range pair.balancesCopy() {
+		s.updatePosition(address, key, nil)
+	}
+	s.muMetadata.Lock()
+	delete(s.metadata, key)
+	s.muMetadata.Unlock()
+}
+
+// state returns the persisted state of a canonical pair.
+func (p *Pair) state() PairState {
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+
+	return PairState{
+		Token0:               p.key.TokenA,
+		Token1:               p.key.TokenB,
+		FeeTier:              p.key.Fee,
+		Fee:                  p.fee,
+		Reserve0:             new(big.Int).Set(p.reserve0),
+		Reserve1:             new(big.Int).Set(p.reserve1),
+		TotalSupply:          new(big.Int).Set(p.totalSupply),
+		Price0CumulativeLast: new(big.Int).Set(p.price0CumulativeLast),
+		Price1CumulativeLast: new(big.Int).Set(p.price1CumulativeLast),
+		BlockTimestampLast:   *p.blockTimestampLast,
+		Metadata:             p.metadata(),
+	}
+}
+
+func (p *Pair) allowancesCopy() (map[Address]map[Address]*big.Int, map[Address]uint64) {
+	p.muBalance.RLock()
+	defer p.muBalance.RUnlock()
+
+	allowances := make(map[Address]map[Address]*big.Int, len(p.allowances))
+	for owner, spenders := range p.allowances {
+		allowances[owner] = make(map[Address]*big.Int, len(spenders))
+		for spender, allowance := range spenders {
+			allowances[owner][spender] = new(big.Int).Set(allowance)
+		}
+	}
+	nonces := make(map[Address]uint64, len(p.nonces))
+	for owner, nonce := range p.nonces {
+		nonces[owner] = nonce
+	}
+	return allowances, nonces
+}
+
+func (p *Pair) balancesCopy() map[Address]*big.Int {
+	p.muBalance.RLock()
+	defer p.muBalance.RUnlock()
+
+	balances := make(map[Address]*big.Int, len(p.balances))
+	for address, balance := range p.balances {
+		balances[address] = new(big.Int).Set(balance)
+	}
+	return balances
+}
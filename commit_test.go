@@ -0,0 +1,261 @@
+package uniswapV2
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+type recordingWriter struct {
+	pairs    []PairState
+	balances map[pairKey]map[Address]*big.Int
+	err      error
+}
+
+func (w *recordingWriter) WritePair(state PairState) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.pairs = append(w.pairs, state)
+	return nil
+}
+
+func (w *recordingWriter) WriteBalances(token0, token1 Token, feeTier uint32, balances map[Address]*big.Int) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.balances == nil {
+		w.balances = map[pairKey]map[Address]*big.Int{}
+	}
+	w.balances[pairKey{TokenA: token0, TokenB: token1, Fee: feeTier}] = balances
+	return nil
+}
+
+func TestUniswapV2_Commit(t *testing.T) {
+	service := New()
+	for _, key := range []pairKey{{TokenA: 0, TokenB: 1}, {TokenA: 1, TokenB: 2}} {
+		pair, err := service.CreatePair(key.TokenA, key.TokenB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writer := &recordingWriter{}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.pairs) != 2 || len(writer.balances) != 2 {
+		t.Fatalf("commit want 2 pairs with balances, got %d and %d", len(writer.pairs), len(writer.balances))
+	}
+
+	_, _, err := service.Pair(1, 2).Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := &recordingWriter{err: errors.New("disk full")}
+	if err := service.Commit(failing); err != failing.err {
+		t.Fatalf("failed with %v; want error %v", err, failing.err)
+	}
+
+	writer = &recordingWriter{}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.pairs) != 1 || writer.pairs[0].Token0 != 1 || len(writer.balances) != 0 {
+		t.Fatalf("commit want only pair 1/2 without balances, got %v and %v", writer.pairs, writer.balances)
+	}
+	if writer.pairs[0].Reserve0.Cmp(big.NewInt(11e17)) != 0 {
+		t.Errorf("reserve0 want %s, got %s", big.NewInt(11e17), writer.pairs[0].Reserve0)
+	}
+
+	writer = &recordingWriter{}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.pairs) != 0 || len(writer.balances) != 0 {
+		t.Errorf("commit want nothing, got %v and %v", writer.pairs, writer.balances)
+	}
+}
+
+func TestUniswapV2_Discard(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair.SetMetadata("listed", "true")
+	if err := service.Commit(&recordingWriter{}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pair.Mint("other", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair.SetMetadata("listed", "false")
+	created, err := service.CreatePair(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = created.Mint("other", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service.Discard()
+
+	if reserve0, _ := pair.Reserves(); reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserve0 want %s, got %s", big.NewInt(1e18), reserve0)
+	}
+	if value, _ := pair.Metadata("listed"); value != "true" {
+		t.Errorf("listed want %q, got %q", "true", value)
+	}
+	if service.Pair(1, 2) != nil {
+		t.Error("uncommitted pair want removed")
+	}
+	if keys, _ := service.Pairs(); !reflect.DeepEqual(keys, []pairKey{{TokenA: 0, TokenB: 1}}) {
+		t.Errorf("pairs want %v, got %v", []pairKey{{TokenA: 0, TokenB: 1}}, keys)
+	}
+	if positions := service.PositionsOf("other"); len(positions) != 0 {
+		t.Errorf("positions want none, got %v", positions)
+	}
+
+	writer := &recordingWriter{}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	if len(writer.pairs) != 0 || len(writer.balances) != 0 {
+		t.Errorf("commit after discard want nothing, got %v and %v", writer.pairs, writer.balances)
+	}
+}
+
+type updateRecorder struct {
+	StateWriter
+	updates map[pairKey]map[Address]*big.Int
+}
+
+func (w *updateRecorder) UpdateBalances(token0, token1 Token, feeTier uint32, balances map[Address]*big.Int) error {
+	w.updates[pairKey{TokenA: token0, TokenB: token1, Fee: feeTier}] = balances
+	return w.StateWriter.(BalanceUpdater).UpdateBalances(token0, token1, feeTier, balances)
+}
+
+func TestUniswapV2_Commit_updateBalances(t *testing.T) {
+	storage := NewMemoryStorage()
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("bob", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pair.Mint("carol", big.NewInt(1e17), big.NewInt(1e17)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Approve("alice", "dave", big.NewInt(1e17)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.TransferFrom("dave", "alice", "erin", big.NewInt(1e17)); err != nil {
+		t.Fatal(err)
+	}
+	writer := &updateRecorder{StateWriter: NewStorageWriter(storage), updates: map[pairKey]map[Address]*big.Int{}}
+	if err := service.CommitCtx(context.Background(), writer); err != nil {
+		t.Fatal(err)
+	}
+	updated := writer.updates[pairKey{TokenA: 0, TokenB: 1}]
+	if len(updated) != 3 || updated["bob"] != nil || updated["carol"] == nil || updated["erin"] == nil {
+		t.Errorf("updated balances want alice, carol and erin, got %v", updated)
+	}
+
+	if _, err := service.MigratePair(0, 1, 0, 30, nil); err != nil {
+		t.Fatal(err)
+	}
+	writer.updates = map[pairKey]map[Address]*big.Int{}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	if updated := writer.updates[pairKey{TokenA: 0, TokenB: 1}]; len(updated) != 5 || updated["alice"] != nil {
+		t.Errorf("balances of the migrated pair want deleted, got %v", updated)
+	}
+	if _, ok := writer.updates[pairKey{TokenA: 0, TokenB: 1, Fee: 30}]; ok {
+		t.Error("balances of the new pair are updated instead of written")
+	}
+
+	loaded := New()
+	if err := loaded.LoadStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	if stateRoot(t, loaded) != stateRoot(t, service) {
+		t.Error("stored state differs")
+	}
+}
+
+func TestUniswapV2_Commit_restored(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	storage := NewMemoryStorage()
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint := pair.Checkpoint()
+
+	for _, restore := range []struct {
+		name string
+		save func() func() error
+	}{
+		{"Revert", func() func() error {
+			id := service.Snapshot()
+			return func() error { return service.Revert(id) }
+		}},
+		{"Rollback", func() func() error {
+			return func() error { return pair.Rollback(checkpoint) }
+		}},
+	} {
+		undo := restore.save()
+		if _, err := pair.Mint("bob", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Commit(NewStorageWriter(storage)); err != nil {
+			t.Fatal(err)
+		}
+		if err := undo(); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Commit(NewStorageWriter(storage)); err != nil {
+			t.Fatal(err)
+		}
+		loaded := New()
+		if err := loaded.LoadStorage(storage); err != nil {
+			t.Fatal(err)
+		}
+		if balance := loaded.Pair(0, 1).Balance("bob"); balance != nil {
+			t.Errorf("after %s stored balance of bob want none, got %s", restore.name, balance)
+		}
+		if reserve0, _ := loaded.Pair(0, 1).Reserves(); reserve0.Cmp(big.NewInt(1e18)) != 0 {
+			t.Errorf("after %s stored reserve0 want 1e18, got %s", restore.name, reserve0)
+		}
+	}
+}
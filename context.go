@@ -0,0 +1,192 @@
+package uniswapV2
+
+import (
+	"context"
+	"math/big"
+)
+
+// Context returns the context the operation running on the pair was called
+// with by one of the Ctx variants, for hooks to honor, or a background
+// context.
+func (p *Pair) Context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// withContext returns a view of the pair carrying ctx.
+func (p *Pair) withContext(ctx context.Context) *Pair {
+	view := *p
+	view.ctx = ctx
+	return &view
+}
+
+// holdOpContext takes the operation lock of the pair unless ctx is done
+// first, and returns a view of the pair holding it with the function
+// releasing it. Mint, Burn, Swap and Sync hold the lock from their first
+// check to their last change, so that no other of them changes the pair
+// meanwhile; they run on a view holding it, which hooks get, without taking
+// it again. A view holding the lock already gets it at once.
+func (p *Pair) holdOpContext(ctx context.Context) (*Pair, func(), error) {
+	if p.opHeld {
+		return p, func() {}, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	m := p.pairData.pairMutex
+	m.opOnce.Do(func() { m.op = make(chan struct{}, 1) })
+	select {
+	case m.op <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	view := *p
+	view.opHeld = true
+	return &view, func() { <-m.op }, nil
+}
+
+// holdOp is holdOpContext without a context.
+func (p *Pair) holdOp() (*Pair, func()) {
+	held, release, _ := p.holdOpContext(context.Background())
+	return held, release
+}
+
+// MintCtx is Mint giving up with the error of ctx if it is done before the
+// pair can be changed. Once it gets the operation lock of the pair it holds
+// it until the end and runs to completion, so an operation is never applied
+// in part. Hooks get ctx from Context of their pair.
+func (p *Pair) MintCtx(ctx context.Context, address Address, amount0, amount1 *big.Int) (liquidity *big.Int, err error) {
+	held, release, err := p.holdOpContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return held.withContext(ctx).Mint(address, amount0, amount1)
+}
+
+// BurnCtx is Burn as MintCtx is Mint.
+func (p *Pair) BurnCtx(ctx context.Context, address Address, liquidity *big.Int) (amount0, amount1 *big.Int, err error) {
+	held, release, err := p.holdOpContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+	return held.withContext(ctx).Burn(address, liquidity)
+}
+
+// SwapCtx is SwapWithCallback as MintCtx is Mint.
+func (p *Pair) SwapCtx(ctx context.Context, amount0In, amount1In, amount0Out, amount1Out *big.Int, callee SwapCallee) (amount0, amount1 *big.Int, err error) {
+	held, release, err := p.holdOpContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+	return held.withContext(ctx).SwapWithCallback(amount0In, amount1In, amount0Out, amount1Out, callee)
+}
+
+// SyncCtx is Sync as MintCtx is Mint.
+func (p *Pair) SyncCtx(ctx context.Context, balance0, balance1 *big.Int) error {
+	held, release, err := p.holdOpContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return held.withContext(ctx).Sync(balance0, balance1)
+}
+
+// ContextStateWriter is a StateWriter that can be bound to the context of
+// CommitCtx, e.g. to pass its deadline on to a database.
+type ContextStateWriter interface {
+	StateWriter
+	WithContext(ctx context.Context) StateWriter
+}
+
+type contextStateWriter struct {
+	ctx context.Context
+	StateWriter
+}
+
+func (w contextStateWriter) WritePair(state PairState) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	return w.StateWriter.WritePair(state)
+}
+
+func (w contextStateWriter) WriteBalances(token0, token1 Token, feeTier uint32, balances map[Address]*big.Int) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	return w.StateWriter.WriteBalances(token0, token1, feeTier, balances)
+}
+
+// DeletePair passes the deletion on if the writer is a PairDeleter.
+func (w contextStateWriter) DeletePair(token0, token1 Token, feeTier uint32) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if deleter, ok := w.StateWriter.(PairDeleter); ok {
+		return deleter.DeletePair(token0, token1, feeTier)
+	}
+	return nil
+}
+
+// WriteAllowances passes the allowances on if the writer is an
+// AllowanceWriter.
+func (w contextStateWriter) WriteAllowances(token0, token1 Token, feeTier uint32, allowances map[Address]map[Address]*big.Int, nonces map[Address]uint64) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if allowanceWriter, ok := w.StateWriter.(AllowanceWriter); ok {
+		return allowanceWriter.WriteAllowances(token0, token1, feeTier, allowances, nonces)
+	}
+	return nil
+}
+
+// BeginBatch passes the batch on if the writer is a BatchWriter.
+func (w contextStateWriter) BeginBatch() error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	if batch, ok := w.StateWriter.(BatchWriter); ok {
+		return batch.BeginBatch()
+	}
+	return nil
+}
+
+// EndBatch ends the batch whether or not ctx is done, so that a batch
+// failed by ctx is dropped.
+func (w contextStateWriter) EndBatch(err error) error {
+	if batch, ok := w.StateWriter.(BatchWriter); ok {
+		return batch.EndBatch(err)
+	}
+	return err
+}
+
+// contextBalanceUpdater is contextStateWriter for a BalanceUpdater.
+type contextBalanceUpdater struct {
+	contextStateWriter
+}
+
+func (w contextBalanceUpdater) UpdateBalances(token0, token1 Token, feeTier uint32, balances map[Address]*big.Int) error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	return w.StateWriter.(BalanceUpdater).UpdateBalances(token0, token1, feeTier, balances)
+}
+
+// CommitCtx is Commit failing with the error of ctx once it is done, before
+// the next write. As with any failed Commit, the next one writes everything
+// again. A ContextStateWriter is bound to ctx.
+func (s *UniswapV2) CommitCtx(ctx context.Context, writer StateWriter) error {
+	if bound, ok := writer.(ContextStateWriter); ok {
+		writer = bound.WithContext(ctx)
+	}
+	wrapped := contextStateWriter{ctx: ctx, StateWriter: writer}
+	if _, ok := writer.(BalanceUpdater); ok {
+		return s.Commit(contextBalanceUpdater{wrapped})
+	}
+	return s.Commit(wrapped)
+}
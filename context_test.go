@@ -0,0 +1,100 @@
+package uniswapV2
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+)
+
+type contextKey struct{}
+
+func TestPair_MintCtx(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen interface{}
+	service.AddHooks(Hooks{AfterMint: func(pair *Pair, address Address, amount0, amount1, liquidity *big.Int) {
+		seen = pair.Context().Value(contextKey{})
+	}})
+	ctx := context.WithValue(context.Background(), contextKey{}, "value")
+	_, err = service.Pair(1, 0).MintCtx(ctx, "alice", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen != "value" {
+		t.Errorf("hook context value want value, got %v", seen)
+	}
+	if pair.Context() != context.Background() {
+		t.Error("pair context want background")
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := pair.BurnCtx(cancelled, "alice", big.NewInt(1e17)); err != context.Canceled {
+		t.Fatalf("failed with %v; want error %v", err, context.Canceled)
+	}
+
+	// another writer holds the pair for longer than the deadline
+	_, release := pair.holdOp()
+	timeout, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = pair.SwapCtx(timeout, big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(1e15), nil)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("failed with %v; want error %v", err, context.DeadlineExceeded)
+	}
+	release()
+
+	// a Mint holds the pair until its hooks ran
+	var during error
+	service.AddHooks(Hooks{AfterMint: func(*Pair, Address, *big.Int, *big.Int, *big.Int) {
+		timeout, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, _, during = service.Pair(0, 1).SwapCtx(timeout, big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(1e15), nil)
+	}})
+	if _, err := pair.Mint("bob", big.NewInt(1e17), big.NewInt(1e17)); err != nil {
+		t.Fatal(err)
+	}
+	if during != context.DeadlineExceeded {
+		t.Fatalf("swap during mint failed with %v; want error %v", during, context.DeadlineExceeded)
+	}
+
+	if err := pair.SyncCtx(context.Background(), big.NewInt(2e18), big.NewInt(2e18)); err != nil {
+		t.Fatal(err)
+	}
+	if reserve0, _ := pair.Reserves(); reserve0.Cmp(big.NewInt(2e18)) != 0 {
+		t.Errorf("reserve0 want %d, got %s", int64(2e18), reserve0)
+	}
+}
+
+func TestUniswapV2_CommitCtx(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewMemoryStorage()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := service.CommitCtx(ctx, NewStorageWriter(storage)); err != context.Canceled {
+		t.Fatalf("failed with %v; want error %v", err, context.Canceled)
+	}
+	if err := service.CommitCtx(context.Background(), NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New()
+	if err := loaded.LoadStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	if balance := loaded.Pair(0, 1).Balance("alice"); balance == nil || balance.Cmp(pair.Balance("alice")) != 0 {
+		t.Errorf("balance want %s, got %v", pair.Balance("alice"), balance)
+	}
+}
@@ -0,0 +1,120 @@
+package uniswapV2
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// resolution is the number of fractional bits of the UQ112x112 prices
+// accumulated by the pair, as in the contract.
+const resolution = 112
+
+var cumulativeModulus = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// CumulativePrices returns the price accumulators and the time of their last
+// update, mirroring price0CumulativeLast, price1CumulativeLast and
+// blockTimestampLast of the contract. Prices are UQ112x112 values summed per
+// second and wrap modulo 2^256, the timestamp wraps modulo 2^32.
+func (p *Pair) CumulativePrices() (price0Cumulative, price1Cumulative *big.Int, blockTimestamp uint32) {
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+	return new(big.Int).Set(p.price0CumulativeLast), new(big.Int).Set(p.price1CumulativeLast), *p.blockTimestampLast
+}
+
+// accumulate adds the prices of the current reserves for the time elapsed
+// since the last update. The caller must hold the pair lock.
+func (p *pairData) accumulate(now time.Time) {
+	blockTimestamp := uint32(now.Unix())
+	timeElapsed := blockTimestamp - *p.blockTimestampLast
+	if timeElapsed > 0 && p.reserve0.Sign() == 1 && p.reserve1.Sign() == 1 {
+		elapsed := big.NewInt(int64(timeElapsed))
+		p.price0CumulativeLast.Add(p.price0CumulativeLast, cumulativePrice(p.reserve1, p.reserve0, elapsed))
+		p.price0CumulativeLast.Mod(p.price0CumulativeLast, cumulativeModulus)
+		p.price1CumulativeLast.Add(p.price1CumulativeLast, cumulativePrice(p.reserve0, p.reserve1, elapsed))
+		p.price1CumulativeLast.Mod(p.price1CumulativeLast, cumulativeModulus)
+	}
+	*p.blockTimestampLast = blockTimestamp
+}
+
+// AccumulatorOverflowError is returned with WithCheckedAccumulators by
+// operations that would make an accumulator of the pair wrap.
+type AccumulatorOverflowError struct {
+	Token0, Token1 Token
+	FeeTier        uint32
+	// Accumulator is price0CumulativeLast, price1CumulativeLast or
+	// blockTimestampLast, in canonical token order.
+	Accumulator string
+	// Value is what the accumulator would have been without wrapping.
+	Value *big.Int
+}
+
+func (e *AccumulatorOverflowError) Error() string {
+	return fmt.Sprintf("%s: %s of pair %d/%d tier %d would be %s", ErrorOverflow, e.Accumulator, e.Token0, e.Token1, e.FeeTier, e.Value)
+}
+
+func (e *AccumulatorOverflowError) Unwrap() error {
+	return ErrorOverflow
+}
+
+// checkAccumulators fails if updating the pair now would wrap one of its
+// accumulators, when the service runs with checked accumulators.
+func (p *Pair) checkAccumulators() error {
+	if !p.service.checkedAccumulators {
+		return nil
+	}
+	key := p.key.sort()
+	overflow := func(accumulator string, value *big.Int) error {
+		return &AccumulatorOverflowError{Token0: key.TokenA, Token1: key.TokenB, FeeTier: key.Fee, Accumulator: accumulator, Value: value}
+	}
+
+	now := p.service.now().Unix()
+	if now < 0 || now > math.MaxUint32 {
+		return overflow("blockTimestampLast", big.NewInt(now))
+	}
+
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+
+	timeElapsed := uint32(now) - *p.blockTimestampLast
+	if timeElapsed == 0 || p.reserve0.Sign() != 1 || p.reserve1.Sign() != 1 {
+		return nil
+	}
+	elapsed := big.NewInt(int64(timeElapsed))
+	names := [2]string{"price0CumulativeLast", "price1CumulativeLast"}
+	if !p.key.isSorted() {
+		names[0], names[1] = names[1], names[0]
+	}
+	if price0 := new(big.Int).Add(p.price0CumulativeLast, cumulativePrice(p.reserve1, p.reserve0, elapsed)); price0.Cmp(cumulativeModulus) != -1 {
+		return overflow(names[0], price0)
+	}
+	if price1 := new(big.Int).Add(p.price1CumulativeLast, cumulativePrice(p.reserve0, p.reserve1, elapsed)); price1.Cmp(cumulativeModulus) != -1 {
+		return overflow(names[1], price1)
+	}
+	return nil
+}
+
+func cumulativePrice(numerator, denominator, elapsed *big.Int) *big.Int {
+	price := new(big.Int).Lsh(numerator, resolution)
+	price.Quo(price, denominator)
+	return price.Mul(price, elapsed)
+}
+
+// currentCumulativePrices returns the accumulators as if the pair were
+// updated at now, like UniswapV2OracleLibrary.currentCumulativePrices.
+func (p *Pair) currentCumulativePrices(now time.Time) (price0Cumulative, price1Cumulative *big.Int, blockTimestamp uint32) {
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+
+	current := pairData{
+		reserve0:             p.reserve0,
+		reserve1:             p.reserve1,
+		price0CumulativeLast: new(big.Int).Set(p.price0CumulativeLast),
+		price1CumulativeLast: new(big.Int).Set(p.price1CumulativeLast),
+		blockTimestampLast:   new(uint32),
+	}
+	*current.blockTimestampLast = *p.blockTimestampLast
+	current.accumulate(now)
+	return current.price0CumulativeLast, current.price1CumulativeLast, *current.blockTimestampLast
+}
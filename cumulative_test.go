@@ -0,0 +1,100 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestPair_CumulativePrices(t *testing.T) {
+	now := time.Unix(1000, 0)
+	service := New(WithClock(func() time.Time { return now }))
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	price0, price1, timestamp := pair.CumulativePrices()
+	if price0.Sign() != 0 || price1.Sign() != 0 || timestamp != 1000 {
+		t.Fatalf("cumulative prices want 0, 0, 1000, got %s, %s, %d", price0, price1, timestamp)
+	}
+
+	now = now.Add(10 * time.Second)
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	price0, price1, timestamp = pair.CumulativePrices()
+	want0 := new(big.Int).Mul(new(big.Int).Lsh(big.NewInt(2), resolution), big.NewInt(10))
+	want1 := new(big.Int).Mul(new(big.Int).Rsh(new(big.Int).Lsh(big.NewInt(1), resolution), 1), big.NewInt(10))
+	if price0.Cmp(want0) != 0 {
+		t.Errorf("price0Cumulative want %s, got %s", want0, price0)
+	}
+	if price1.Cmp(want1) != 0 {
+		t.Errorf("price1Cumulative want %s, got %s", want1, price1)
+	}
+	if timestamp != 1010 {
+		t.Errorf("blockTimestamp want %d, got %d", 1010, timestamp)
+	}
+
+	sorted := service.Pair(0, 1)
+	sorted0, sorted1, _ := sorted.CumulativePrices()
+	if sorted0.Cmp(price1) != 0 || sorted1.Cmp(price0) != 0 {
+		t.Errorf("reversed view want %s, %s, got %s, %s", price1, price0, sorted0, sorted1)
+	}
+}
+
+func TestPair_checkedAccumulators(t *testing.T) {
+	for _, checked := range []bool{false, true} {
+		now := time.Unix(1000, 0)
+		options := []Option{WithClock(func() time.Time { return now })}
+		if checked {
+			options = append(options, WithCheckedAccumulators())
+		}
+		service := New(options...)
+		pair, err := service.CreatePair(1, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pair.price1CumulativeLast.Sub(cumulativeModulus, big.NewInt(1))
+
+		now = now.Add(time.Second)
+		_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e16))
+		if !checked {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if price0, _, _ := service.Pair(0, 1).CumulativePrices(); price0.Cmp(new(big.Int).Lsh(big.NewInt(1), resolution)) != -1 {
+				t.Errorf("price0Cumulative want wrapped, got %s", price0)
+			}
+			continue
+		}
+
+		if !errors.Is(err, ErrorOverflow) {
+			t.Fatalf("failed with %v; want error %v", err, ErrorOverflow)
+		}
+		var overflow *AccumulatorOverflowError
+		if !errors.As(err, &overflow) || overflow.Accumulator != "price0CumulativeLast" || overflow.Token0 != 0 {
+			t.Errorf("overflow want price0CumulativeLast of pair 0/1, got %v", err)
+		}
+		if reserve0, _ := pair.Reserves(); reserve0.Cmp(big.NewInt(1e18)) != 0 {
+			t.Errorf("reserve0 want %s, got %s", big.NewInt(1e18), reserve0)
+		}
+
+		now = time.Unix(1<<32, 0)
+		_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+		if !errors.As(err, &overflow) || overflow.Accumulator != "blockTimestampLast" {
+			t.Errorf("overflow want blockTimestampLast, got %v", err)
+		}
+	}
+}
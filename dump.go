@@ -0,0 +1,95 @@
+package uniswapV2
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const dumpVersion = 1
+
+type pairDump struct {
+	Version       int               `json:"version"`
+	Token0        Token             `json:"token0"`
+	Token1        Token             `json:"token1"`
+	FeeTier       uint32            `json:"fee_tier"`
+	Reserve0      string            `json:"reserve0"`
+	Reserve1      string            `json:"reserve1"`
+	TotalSupply   string            `json:"total_supply"`
+	Fee           Fee               `json:"fee"`
+	Dirty         bool              `json:"dirty"`
+	DirtyBalances bool              `json:"dirty_balances"`
+	Balances      []balanceDump     `json:"balances"`
+	Metadata      map[string]string `json:"metadata"`
+	Stats         pairStatsDump     `json:"stats"`
+}
+
+type balanceDump struct {
+	Address   Address `json:"address"`
+	Liquidity string  `json:"liquidity"`
+}
+
+type pairStatsDump struct {
+	Holders   int `json:"holders"`
+	SwapHooks int `json:"swap_hooks"`
+}
+
+// DumpPair writes everything known about the pair, in canonical token
+// order, as a single JSON document for diagnostics.
+func (s *UniswapV2) DumpPair(tokenA, tokenB Token, w io.Writer) error {
+	return s.dumpPair(pairKey{TokenA: tokenA, TokenB: tokenB}, w)
+}
+
+// DumpPairWithFee is DumpPair for the pair of the feeBps tier.
+func (s *UniswapV2) DumpPairWithFee(tokenA, tokenB Token, feeBps uint32, w io.Writer) error {
+	if feeBps == 0 {
+		return ErrorInvalidFee
+	}
+	return s.dumpPair(pairKey{TokenA: tokenA, TokenB: tokenB, Fee: feeBps}, w)
+}
+
+func (s *UniswapV2) dumpPair(key pairKey, w io.Writer) error {
+	s.muPairs.Lock()
+	pair, ok, err := s.loadPair(key.sort())
+	s.muPairs.Unlock()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrorPairNotExists
+	}
+
+	pair.pairData.RLock()
+	pair.muBalance.RLock()
+	dump := pairDump{
+		Version:       dumpVersion,
+		Token0:        pair.key.TokenA,
+		Token1:        pair.key.TokenB,
+		FeeTier:       pair.key.Fee,
+		Reserve0:      pair.reserve0.String(),
+		Reserve1:      pair.reserve1.String(),
+		TotalSupply:   pair.totalSupply.String(),
+		Fee:           pair.fee,
+		Dirty:         pair.isDirty,
+		DirtyBalances: pair.isDirtyBalances,
+		Balances:      make([]balanceDump, 0, len(pair.balances)),
+	}
+	for _, address := range pair.addresses() {
+		liquidity := pair.balances[address]
+		dump.Balances = append(dump.Balances, balanceDump{Address: address, Liquidity: liquidity.String()})
+		if liquidity.Sign() == 1 {
+			dump.Stats.Holders++
+		}
+	}
+	pair.muBalance.RUnlock()
+	pair.pairData.RUnlock()
+
+	dump.Metadata = pair.metadata()
+
+	s.muHooks.RLock()
+	dump.Stats.SwapHooks = len(s.swapHooks[pair.key.tokens()])
+	s.muHooks.RUnlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dump)
+}
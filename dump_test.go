@@ -0,0 +1,139 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_DumpPair(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := service.DumpPair(1, 0, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var dump pairDump
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if dump.Token0 != 0 || dump.Token1 != 1 {
+		t.Errorf("tokens want 0/1, got %d/%d", dump.Token0, dump.Token1)
+	}
+	if dump.Reserve0 != "4000000000000000000" || dump.Reserve1 != "1000000000000000000" {
+		t.Errorf("reserves want %s/%s, got %s/%s", "4000000000000000000", "1000000000000000000", dump.Reserve0, dump.Reserve1)
+	}
+	if len(dump.Balances) != 2 || dump.Balances[0].Address != addressZero || dump.Balances[1].Address != "address" {
+		t.Errorf("unexpected balances %v", dump.Balances)
+	}
+	if dump.Stats.Holders != 2 {
+		t.Errorf("holders want 2, got %d", dump.Stats.Holders)
+	}
+
+	if err := service.DumpPair(0, 2, &buf); err != ErrorPairNotExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+}
+
+func TestUniswapV2_DumpPairWithFee(t *testing.T) {
+	service := New()
+	_, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := service.CreatePairWithFee(0, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := service.DumpPairWithFee(1, 0, 5, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var dump pairDump
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if dump.FeeTier != 5 || dump.Fee != (Fee{Numerator: 5, Denominator: 10000}) {
+		t.Errorf("fee want tier 5, got %d %v", dump.FeeTier, dump.Fee)
+	}
+	if dump.TotalSupply != "2000000000000000000" {
+		t.Errorf("total supply want %s, got %s", "2000000000000000000", dump.TotalSupply)
+	}
+
+	buf.Reset()
+	if err := service.DumpPair(0, 1, &buf); err != nil {
+		t.Fatal(err)
+	}
+	dump = pairDump{}
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if dump.FeeTier != 0 || dump.TotalSupply != "0" {
+		t.Errorf("default tier want empty, got tier %d supply %s", dump.FeeTier, dump.TotalSupply)
+	}
+
+	if err := service.DumpPairWithFee(0, 1, 30, &buf); err != ErrorPairNotExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+	if err := service.DumpPairWithFee(0, 1, 0, &buf); err != ErrorInvalidFee {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidFee)
+	}
+}
+
+func TestUniswapV2_DumpPair_addressOrder(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, address := range []Address{"d", "b", "e", "a", "c", "f", "h", "g"} {
+		_, err = pair.Mint(address, big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var first bytes.Buffer
+	if err := service.DumpPair(0, 1, &first); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		var buf bytes.Buffer
+		if err := service.DumpPair(0, 1, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first.Bytes(), buf.Bytes()) {
+			t.Fatal("dump is not stable across runs")
+		}
+	}
+
+	defer func(less func(a, b Address) bool) { AddressLess = less }(AddressLess)
+	AddressLess = func(a, b Address) bool { return a > b }
+
+	var buf bytes.Buffer
+	if err := service.DumpPair(0, 1, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var dump pairDump
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if dump.Balances[0].Address != "h" || dump.Balances[len(dump.Balances)-1].Address != addressZero {
+		t.Errorf("balances are not in custom order: %v", dump.Balances)
+	}
+}
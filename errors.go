@@ -0,0 +1,169 @@
+package uniswapV2
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// PairError is returned by Mint, Burn, Swap and Sync of a pair and the
+// operations built on them, e.g. of the Router, with the context of the
+// failure. The error it wraps is one of the Error sentinels, e.g. ErrorK,
+// or the error of a hook or a callee; match it with errors.Is.
+type PairError struct {
+	Operation      string
+	Token0, Token1 Token
+	FeeTier        uint32
+	// Amounts are the amounts the operation was called with by name, e.g.
+	// amount0_in or liquidity, in canonical token order.
+	Amounts map[string]*big.Int
+	// Reserve0 and Reserve1 are the reserves of the pair at the failure.
+	Reserve0, Reserve1 *big.Int
+	Err                error
+}
+
+func (e *PairError) Error() string {
+	names := make([]string, 0, len(e.Amounts))
+	for name := range e.Amounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	amounts := make([]string, len(names))
+	for i, name := range names {
+		amounts[i] = name + "=" + e.Amounts[name].String()
+	}
+	return fmt.Sprintf("%s: %s on pair %d/%d tier %d, reserves %s/%s [%s]", e.Err, e.Operation, e.Token0, e.Token1, e.FeeTier, e.Reserve0, e.Reserve1, strings.Join(amounts, " "))
+}
+
+func (e *PairError) Unwrap() error {
+	return e.Err
+}
+
+type errorAmounts map[string]*big.Int
+
+// set adds view amounts as <name>0 and <name>1 in canonical order, leaving
+// out nil ones.
+func (a errorAmounts) set(p *Pair, name string, amount0, amount1 *big.Int) errorAmounts {
+	if !p.key.isSorted() {
+		amount0, amount1 = amount1, amount0
+	}
+	if amount0 != nil {
+		a[name+"0"] = new(big.Int).Set(amount0)
+	}
+	if amount1 != nil {
+		a[name+"1"] = new(big.Int).Set(amount1)
+	}
+	return a
+}
+
+// wrapError replaces a non-nil *err of operation on the pair with a
+// *PairError.
+func (p *Pair) wrapError(err *error, operation string, amounts func() errorAmounts) {
+	if *err == nil {
+		return
+	}
+	if _, ok := (*err).(*PairError); ok {
+		return
+	}
+	key := p.key.sort()
+	reserve0, reserve1 := p.Reserves()
+	if !p.key.isSorted() {
+		reserve0, reserve1 = reserve1, reserve0
+	}
+	*err = &PairError{
+		Operation: operation,
+		Token0:    key.TokenA,
+		Token1:    key.TokenB,
+		FeeTier:   key.Fee,
+		Amounts:   amounts(),
+		Reserve0:  reserve0,
+		Reserve1:  reserve1,
+		Err:       *err,
+	}
+}
+
+// NoRouteError is returned by the operations of a Router that find no pair
+// for hop Hop of their path, trading TokenIn for TokenOut, or no path at
+// all, Hop being -1 then. It wraps ErrorPairNotExists or ErrorPathNotFound.
+type NoRouteError struct {
+	Hop               int
+	TokenIn, TokenOut Token
+	Err               error
+}
+
+func (e *NoRouteError) Error() string {
+	if e.Hop < 0 {
+		return fmt.Sprintf("%s: no route from %d to %d", e.Err, e.TokenIn, e.TokenOut)
+	}
+	return fmt.Sprintf("%s: hop %d from %d to %d", e.Err, e.Hop, e.TokenIn, e.TokenOut)
+}
+
+func (e *NoRouteError) Unwrap() error {
+	return e.Err
+}
+
+// LiquidityError is returned by the operations of a Router whose hop Hop,
+// trading TokenIn for TokenOut, has too little liquidity for the trade. It
+// wraps ErrorInsufficientLiquidity or ErrorInactivePair, through the
+// *PairError of the swap if the hop failed while trading.
+type LiquidityError struct {
+	Hop               int
+	TokenIn, TokenOut Token
+	Err               error
+}
+
+func (e *LiquidityError) Error() string {
+	return fmt.Sprintf("%s: hop %d from %d to %d", e.Err, e.Hop, e.TokenIn, e.TokenOut)
+}
+
+func (e *LiquidityError) Unwrap() error {
+	return e.Err
+}
+
+// SlippageError is returned by the operations of a Router whose Amount
+// breaks the Limit of the caller. Hop is the hop it is traded on: the last
+// one for an output below its minimum, the first one for an input above
+// its maximum, -1 for the total of several paths. It wraps
+// ErrorInsufficientOutputAmount or ErrorExcessiveInputAmount.
+type SlippageError struct {
+	Hop           int
+	Amount, Limit *big.Int
+	Err           error
+}
+
+func (e *SlippageError) Error() string {
+	return fmt.Sprintf("%s: %s against a limit of %s on hop %d", e.Err, e.Amount, e.Limit, e.Hop)
+}
+
+func (e *SlippageError) Unwrap() error {
+	return e.Err
+}
+
+// ExpiredError is returned by the operations of a Router bound to a
+// Deadline by WithDeadline once the clock of the service, at Now, is past
+// it. It wraps ErrorExpired.
+type ExpiredError struct {
+	Deadline, Now int64
+}
+
+func (e *ExpiredError) Error() string {
+	return fmt.Sprintf("%s: deadline %d passed at %d", ErrorExpired, e.Deadline, e.Now)
+}
+
+func (e *ExpiredError) Unwrap() error {
+	return ErrorExpired
+}
+
+// hopError classifies the failure of hop, trading tokenIn for tokenOut, of
+// a router path.
+func hopError(hop int, tokenIn, tokenOut Token, err error) error {
+	switch {
+	case errors.Is(err, ErrorPairNotExists), errors.Is(err, ErrorPathNotFound):
+		return &NoRouteError{Hop: hop, TokenIn: tokenIn, TokenOut: tokenOut, Err: err}
+	case errors.Is(err, ErrorInsufficientLiquidity), errors.Is(err, ErrorInactivePair):
+		return &LiquidityError{Hop: hop, TokenIn: tokenIn, TokenOut: tokenOut, Err: err}
+	}
+	return err
+}
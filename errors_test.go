@@ -0,0 +1,112 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestPairError(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = service.Pair(1, 0).Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if !errors.Is(err, ErrorK) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorK)
+	}
+	var pairErr *PairError
+	if !errors.As(err, &pairErr) {
+		t.Fatalf("error want *PairError, got %T", err)
+	}
+	if pairErr.Operation != "swap" || pairErr.Token0 != 0 || pairErr.Token1 != 1 || pairErr.FeeTier != 0 {
+		t.Errorf("context want swap on 0/1 tier 0, got %s on %d/%d tier %d", pairErr.Operation, pairErr.Token0, pairErr.Token1, pairErr.FeeTier)
+	}
+	for name, want := range map[string]int64{"amount_in0": 0, "amount_in1": 1e17, "amount_out0": 1e17, "amount_out1": 0} {
+		if got := pairErr.Amounts[name]; got == nil || got.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("%s want %d, got %v", name, want, got)
+		}
+	}
+	if pairErr.Reserve0.Cmp(big.NewInt(1e18)) != 0 || pairErr.Reserve1.Cmp(big.NewInt(2e18)) != 0 {
+		t.Errorf("reserves want %s, %s, got %s, %s", big.NewInt(1e18), big.NewInt(2e18), pairErr.Reserve0, pairErr.Reserve1)
+	}
+
+	_, _, err = NewRouter(service).RemoveLiquidity(0, 1, big.NewInt(1), big.NewInt(0), big.NewInt(0), "bob")
+	if !errors.As(err, &pairErr) || pairErr.Operation != "burn" || pairErr.Amounts["liquidity"].Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("error want burn *PairError, got %v", err)
+	}
+	if !errors.Is(err, ErrorInsufficientLiquidityBurned) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidityBurned)
+	}
+}
+
+func TestRouter_errors(t *testing.T) {
+	clock := NewSimulatedClock(time.Unix(1600000000, 0))
+	service := New(WithClock(clock.Now))
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := service.CreatePair(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(service)
+
+	_, err := router.Swap(big.NewInt(1e16), []Token{0, 1, 4})
+	var noRoute *NoRouteError
+	if !errors.As(err, &noRoute) || noRoute.Hop != 1 || noRoute.TokenIn != 1 || noRoute.TokenOut != 4 || !errors.Is(err, ErrorPairNotExists) {
+		t.Errorf("error want no route on hop 1 from 1 to 4, got %v", err)
+	}
+	_, err = router.RemoveLiquidityAndSwap("alice", 0, 1, big.NewInt(1e17), 4, big.NewInt(0))
+	if !errors.As(err, &noRoute) || noRoute.Hop != -1 || !errors.Is(err, ErrorPathNotFound) {
+		t.Errorf("error want no route, got %v", err)
+	}
+
+	_, err = router.Swap(big.NewInt(1e16), []Token{0, 1, 2, 3})
+	var liquidity *LiquidityError
+	if !errors.As(err, &liquidity) || liquidity.Hop != 2 || liquidity.TokenIn != 2 || liquidity.TokenOut != 3 || !errors.Is(err, ErrorInsufficientLiquidity) {
+		t.Errorf("error want insufficient liquidity on hop 2 from 2 to 3, got %v", err)
+	}
+
+	amounts, err := router.GetAmountsOut(big.NewInt(1e16), []Token{0, 1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limit := new(big.Int).Add(amounts[2], big.NewInt(1))
+	_, err = router.SwapExactTokensForTokens(big.NewInt(1e16), limit, []Token{0, 1, 2})
+	var slippage *SlippageError
+	if !errors.As(err, &slippage) || slippage.Hop != 1 || slippage.Amount.Cmp(amounts[2]) != 0 || slippage.Limit.Cmp(limit) != 0 || !errors.Is(err, ErrorInsufficientOutputAmount) {
+		t.Errorf("error want slippage on hop 1, got %v", err)
+	}
+	_, err = router.SwapTokensForExactTokens(amounts[2], big.NewInt(1), []Token{0, 1, 2})
+	if !errors.As(err, &slippage) || slippage.Hop != 0 || !errors.Is(err, ErrorExcessiveInputAmount) {
+		t.Errorf("error want slippage on hop 0, got %v", err)
+	}
+
+	expiring := router.WithDeadline(1600000060)
+	if _, err := expiring.Swap(big.NewInt(1e16), []Token{0, 1}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2*time.Minute, time.Minute)
+	_, err = expiring.Swap(big.NewInt(1e16), []Token{0, 1})
+	var expired *ExpiredError
+	if !errors.As(err, &expired) || expired.Deadline != 1600000060 || expired.Now != 1600000120 || !errors.Is(err, ErrorExpired) {
+		t.Errorf("error want expired, got %v", err)
+	}
+	if _, err := router.Swap(big.NewInt(1e16), []Token{0, 1}); err != nil {
+		t.Fatalf("router without deadline failed with %v", err)
+	}
+}
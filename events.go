@@ -0,0 +1,231 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+)
+
+type EventKind int
+
+const (
+	EventMint EventKind = iota + 1
+	EventBurn
+	EventSwap
+	EventSync
+	EventTransfer
+	EventRevert
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventMint:
+		return "mint"
+	case EventBurn:
+		return "burn"
+	case EventSwap:
+		return "swap"
+	case EventSync:
+		return "sync"
+	case EventTransfer:
+		return "transfer"
+	case EventRevert:
+		return "revert"
+	}
+	return "unknown"
+}
+
+// Event describes a state change of a pair, in canonical token order. Amounts
+// flow into and out of the pair: a Mint has the deposited inputs, a Burn the
+// withdrawn outputs and a Swap both, net of swap taxes. Reserves are those
+// after the change; for a Sync they are the synced balances. Unused amounts
+// are zero, never nil. To is the recipient of the outputs of a Burn, the
+// burning Address unless burned with BurnTo. A Transfer moves Liquidity from
+// Address to To. A Swap has the Address of its sender if it was made with
+// SwapFrom, a router WithSender or an Operation, none otherwise. A Revert
+// has no amounts: Revert, Rollback or Discard restored the pair to the
+// Reserves, and possibly balances, it had before events already delivered,
+// which it undoes; a pair created since is dropped with zero Reserves.
+type Event struct {
+	Kind                   EventKind
+	Token0, Token1         Token
+	FeeTier                uint32
+	Address                Address
+	To                     Address
+	Liquidity              *big.Int
+	Amount0In, Amount1In   *big.Int
+	Amount0Out, Amount1Out *big.Int
+	Reserve0, Reserve1     *big.Int
+}
+
+// SubscriptionFilter narrows the events of a subscription down. An event
+// passes if it is of one of Pairs, in either token order and on every fee
+// tier, and if one of Addresses is its Address or To, which Revert events
+// always pass. An empty list lets every event through.
+type SubscriptionFilter struct {
+	Pairs     [][2]Token
+	Addresses []Address
+}
+
+type subscription struct {
+	events chan Event
+	once   sync.Once
+	// pairs and addresses are the filter, nil to match every event
+	pairs     map[[2]Token]struct{}
+	addresses map[Address]struct{}
+}
+
+func (sub *subscription) match(event Event) bool {
+	if sub.pairs != nil {
+		if _, ok := sub.pairs[[2]Token{event.Token0, event.Token1}]; !ok {
+			return false
+		}
+	}
+	// a Revert may restore the balance of any address
+	if sub.addresses != nil && event.Kind != EventRevert {
+		_, address := sub.addresses[event.Address]
+		_, to := sub.addresses[event.To]
+		if !address && !to {
+			return false
+		}
+	}
+	return true
+}
+
+// Events delivers the events of the service it is passed to with WithEvents,
+// synchronously after every Mint, Burn, Swap, Sync and TransferFrom and
+// outside the locks of the pair, or, for an operation of Execute, a Router
+// or DeliverOp, once the whole operation succeeds; nothing is delivered for
+// a failed one. Revert, Rollback and Discard deliver Revert events for the
+// pairs they restore. Listeners are called first, in the order
+// they were added; then the event is queued on every subscription whose
+// filter it passes. A subscription whose buffer is full misses the event
+// instead of holding up the operation, and the miss is counted by Dropped.
+type Events struct {
+	// first to keep it 64-bit aligned for the atomic operations
+	dropped uint64
+
+	mu            sync.RWMutex
+	listeners     []func(Event)
+	subscriptions map[*subscription]struct{}
+}
+
+func NewEvents() *Events {
+	return &Events{subscriptions: map[*subscription]struct{}{}}
+}
+
+// WithEvents emits the events of every state change of the service to events.
+func WithEvents(events *Events) Option {
+	return func(o *options) {
+		o.events = events
+	}
+}
+
+// Listen registers fn to be called with every event.
+func (e *Events) Listen(fn func(Event)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.listeners = append(e.listeners, fn)
+}
+
+// Subscribe returns a channel receiving every event, buffered by buffer of
+// at least one, and a function cancelling the subscription and closing the
+// channel. Events arriving while the buffer is full are dropped.
+func (e *Events) Subscribe(buffer int) (<-chan Event, func()) {
+	return e.SubscribeFiltered(buffer, SubscriptionFilter{})
+}
+
+// SubscribeFiltered is Subscribe receiving only the events passing filter,
+// so that a wallet can follow its own mints, burns, swaps and transfers
+// without the events of everybody else. Only those count as dropped.
+func (e *Events) SubscribeFiltered(buffer int, filter SubscriptionFilter) (<-chan Event, func()) {
+	if buffer < 1 {
+		buffer = 1
+	}
+	sub := &subscription{events: make(chan Event, buffer)}
+	if len(filter.Pairs) != 0 {
+		sub.pairs = map[[2]Token]struct{}{}
+		for _, pair := range filter.Pairs {
+			key := pairKey{TokenA: pair[0], TokenB: pair[1]}.sort()
+			sub.pairs[[2]Token{key.TokenA, key.TokenB}] = struct{}{}
+		}
+	}
+	if len(filter.Addresses) != 0 {
+		sub.addresses = map[Address]struct{}{}
+		for _, address := range filter.Addresses {
+			// events carry canonical addresses; an invalid one matches none
+			if normalized, err := NormalizeAddress(string(address)); err == nil {
+				address = normalized
+			}
+			sub.addresses[address] = struct{}{}
+		}
+	}
+
+	e.mu.Lock()
+	e.subscriptions[sub] = struct{}{}
+	e.mu.Unlock()
+
+	return sub.events, func() {
+		sub.once.Do(func() {
+			e.mu.Lock()
+			delete(e.subscriptions, sub)
+			e.mu.Unlock()
+			close(sub.events)
+		})
+	}
+}
+
+// Dropped returns the number of events missed by subscriptions with a full
+// buffer, summed over all of them.
+func (e *Events) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+func (e *Events) emit(event Event) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	for _, fn := range e.listeners {
+		fn(event)
+	}
+	for sub := range e.subscriptions {
+		if !sub.match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			atomic.AddUint64(&e.dropped, 1)
+		}
+	}
+}
+
+// emit completes an event with view amounts with the key and, unless set,
+// the reserves of the pair and delivers it in canonical order once the
+// operation commits.
+func (p *Pair) emit(event Event) {
+	events := p.service.events
+	if events == nil {
+		return
+	}
+	amounts := []**big.Int{&event.Liquidity, &event.Amount0In, &event.Amount1In, &event.Amount0Out, &event.Amount1Out}
+	for _, amount := range amounts {
+		if *amount == nil {
+			*amount = big.NewInt(0)
+		} else {
+			*amount = new(big.Int).Set(*amount)
+		}
+	}
+	if event.Reserve0 == nil || event.Reserve1 == nil {
+		event.Reserve0, event.Reserve1 = p.Reserves()
+	}
+	if !p.key.isSorted() {
+		event.Amount0In, event.Amount1In = event.Amount1In, event.Amount0In
+		event.Amount0Out, event.Amount1Out = event.Amount1Out, event.Amount0Out
+		event.Reserve0, event.Reserve1 = event.Reserve1, event.Reserve0
+	}
+	key := p.key.sort()
+	event.Token0, event.Token1, event.FeeTier = key.TokenA, key.TokenB, key.Fee
+	p.deliver(func() { events.emit(event) })
+}
@@ -0,0 +1,265 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	events := NewEvents()
+	var listened []Event
+	events.Listen(func(event Event) {
+		listened = append(listened, event)
+	})
+	subscribed, cancel := events.Subscribe(10)
+	service := New(WithEvents(events))
+
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if !errors.Is(err, ErrorInsufficientInputAmount) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientInputAmount)
+	}
+	_, _, err = pair.Burn("alice", big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Sync(big.NewInt(2e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := []EventKind{EventMint, EventSwap, EventBurn, EventSync}
+	if len(listened) != len(kinds) {
+		t.Fatalf("events want %d, got %d", len(kinds), len(listened))
+	}
+	for i, kind := range kinds {
+		if listened[i].Kind != kind {
+			t.Errorf("event %d want %s, got %s", i, kind, listened[i].Kind)
+		}
+		if received := <-subscribed; received.Kind != kind {
+			t.Errorf("subscribed event %d want %s, got %s", i, kind, received.Kind)
+		}
+	}
+
+	mint := listened[0]
+	if mint.Token0 != 0 || mint.Token1 != 1 || mint.Address != "alice" {
+		t.Errorf("mint want canonical tokens of alice, got %+v", mint)
+	}
+	if mint.Amount0In.Cmp(big.NewInt(2e18)) != 0 || mint.Amount1In.Cmp(big.NewInt(1e18)) != 0 || mint.Amount0Out.Sign() != 0 {
+		t.Errorf("mint amounts want 2e18, 1e18 in, got %s, %s", mint.Amount0In, mint.Amount1In)
+	}
+	if mint.Reserve0.Cmp(big.NewInt(2e18)) != 0 || mint.Reserve1.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("mint reserves want 2e18, 1e18, got %s, %s", mint.Reserve0, mint.Reserve1)
+	}
+	swap := listened[1]
+	if swap.Amount1In.Cmp(big.NewInt(1e17)) != 0 || swap.Amount0Out.Cmp(big.NewInt(1e17)) != 0 || swap.Liquidity.Sign() != 0 {
+		t.Errorf("swap amounts want 1e17 in of token1 and out of token0, got %+v", swap)
+	}
+	reserve1, reserve0 := pair.Reserves()
+	if sync := listened[3]; sync.Reserve0.Cmp(reserve0) != 0 || sync.Reserve1.Cmp(reserve1) != 0 {
+		t.Errorf("sync reserves want %s, %s, got %s, %s", reserve0, reserve1, sync.Reserve0, sync.Reserve1)
+	}
+
+	cancel()
+	cancel()
+	if _, ok := <-subscribed; ok {
+		t.Error("channel want closed after cancel")
+	}
+	if err := pair.Sync(big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	if len(listened) != 5 {
+		t.Errorf("events want 5, got %d", len(listened))
+	}
+}
+
+func TestEvents_slowSubscriber(t *testing.T) {
+	events := NewEvents()
+	slow, cancel := events.Subscribe(0)
+	defer cancel()
+	fast, cancelFast := events.Subscribe(10)
+	defer cancelFast()
+	service := New(WithEvents(events))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nobody receives from slow, which must not hold up the operations
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := pair.Sync(big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if dropped := events.Dropped(); dropped != 3 {
+		t.Errorf("dropped want 3, got %d", dropped)
+	}
+	if received := <-slow; received.Kind != EventMint {
+		t.Errorf("buffered event want %s, got %s", EventMint, received.Kind)
+	}
+	if len(fast) != 4 {
+		t.Errorf("fast subscription events want 4, got %d", len(fast))
+	}
+}
+
+func TestEvents_SubscribeFiltered(t *testing.T) {
+	events := NewEvents()
+	aliceEvents, cancelAlice := events.SubscribeFiltered(10, SubscriptionFilter{Pairs: [][2]Token{{1, 0}}, Addresses: []Address{"alice"}})
+	defer cancelAlice()
+	bobEvents, cancelBob := events.SubscribeFiltered(10, SubscriptionFilter{Addresses: []Address{"bob"}})
+	defer cancelBob()
+	service := New(WithEvents(events))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := service.CreatePair(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("bob", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Mint("bob", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := pair.Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(9e15)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := pair.SwapFrom("alice", big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(9e15)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRouter(service).WithSender("bob").Swap(big.NewInt(1e16), []Token{2, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Approve("bob", "alice", big.NewInt(1e17)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.TransferFrom("alice", "bob", "alice", big.NewInt(1e17)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, subscribed := range []struct {
+		name   Address
+		events <-chan Event
+		kinds  []EventKind
+	}{
+		{"alice", aliceEvents, []EventKind{EventMint, EventSwap, EventTransfer}},
+		{"bob", bobEvents, []EventKind{EventMint, EventMint, EventSwap, EventTransfer}},
+	} {
+		if len(subscribed.events) != len(subscribed.kinds) {
+			t.Fatalf("%s events want %d, got %d", subscribed.name, len(subscribed.kinds), len(subscribed.events))
+		}
+		for i, kind := range subscribed.kinds {
+			if received := <-subscribed.events; received.Kind != kind {
+				t.Errorf("%s event %d want %s, got %s", subscribed.name, i, kind, received.Kind)
+			} else if received.Address != subscribed.name && received.To != subscribed.name {
+				t.Errorf("%s event %d want of %s, got %+v", subscribed.name, i, subscribed.name, received)
+			}
+		}
+	}
+}
+
+func TestEvents_revert(t *testing.T) {
+	events := NewEvents()
+	var listened []Event
+	events.Listen(func(event Event) {
+		listened = append(listened, event)
+	})
+	service := New(WithEvents(events))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.BeginBlock(1, time.Unix(1600000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	errOp := errors.New("op failed")
+	err = service.DeliverOp(func(s *UniswapV2) error {
+		if _, _, err := s.Pair(0, 1).Swap(big.NewInt(1e15), big.NewInt(0), big.NewInt(0), big.NewInt(1e14)); err != nil {
+			return err
+		}
+		return errOp
+	})
+	if err != errOp {
+		t.Fatalf("failed with %v; want error %v", err, errOp)
+	}
+	if len(listened) != 1 {
+		t.Fatalf("failed operation want no events, got %v", listened[1:])
+	}
+	if _, err := service.EndBlock(&recordingWriter{}); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint := pair.Checkpoint()
+	id := service.Snapshot()
+	if _, _, err := pair.Swap(big.NewInt(1e15), big.NewInt(0), big.NewInt(0), big.NewInt(1e14)); err != nil {
+		t.Fatal(err)
+	}
+	created, err := service.CreatePair(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := created.Mint("bob", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Revert(id); err != nil {
+		t.Fatal(err)
+	}
+	if len(listened) != 5 {
+		t.Fatalf("events want mint, swap, mint and 2 reverts, got %v", listened)
+	}
+	reverted, removed := listened[3], listened[4]
+	if reverted.Kind != EventRevert || reverted.Token0 != 0 || reverted.Reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("revert want reserves of 0/1 restored, got %v", reverted)
+	}
+	if removed.Kind != EventRevert || removed.Token0 != 1 || removed.Reserve0.Sign() != 0 || removed.Reserve1.Sign() != 0 {
+		t.Errorf("revert want 1/2 removed, got %v", removed)
+	}
+
+	if _, _, err := pair.Swap(big.NewInt(1e15), big.NewInt(0), big.NewInt(0), big.NewInt(1e14)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Rollback(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Rollback(checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	if len(listened) != 7 || listened[6].Kind != EventRevert {
+		t.Fatalf("events want a swap and one revert, got %v", listened[5:])
+	}
+
+	if _, _, err := pair.Swap(big.NewInt(1e15), big.NewInt(0), big.NewInt(0), big.NewInt(1e14)); err != nil {
+		t.Fatal(err)
+	}
+	service.Discard()
+	if len(listened) != 9 || listened[8].Kind != EventRevert || listened[8].Reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Fatalf("events want a swap and a revert to the committed reserves, got %v", listened[7:])
+	}
+}
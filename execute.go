@@ -0,0 +1,123 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrorInvalidOperation = errors.New("INVALID_OPERATION")
+)
+
+type OperationKind int
+
+const (
+	OperationCreatePair OperationKind = iota
+	OperationMint
+	OperationBurn
+	OperationSwap
+)
+
+func (k OperationKind) String() string {
+	switch k {
+	case OperationCreatePair:
+		return "create_pair"
+	case OperationMint:
+		return "mint"
+	case OperationBurn:
+		return "burn"
+	case OperationSwap:
+		return "swap"
+	}
+	return "unknown"
+}
+
+// Operation describes one step of Execute on the TokenA/TokenB pair of
+// FeeTier, zero for the CreatePair one. Amounts are in TokenA/TokenB order:
+// a Mint deposits AmountAIn and AmountBIn for Address, a Burn burns
+// Liquidity of Address and a Swap trades as Pair.SwapFrom does for Address,
+// if any, nil amounts being zero. A CreatePair of a non-zero tier creates it
+// as CreatePairWithFee does.
+type Operation struct {
+	Kind                   OperationKind
+	TokenA, TokenB         Token
+	FeeTier                uint32
+	Address                Address
+	Liquidity              *big.Int
+	AmountAIn, AmountBIn   *big.Int
+	AmountAOut, AmountBOut *big.Int
+}
+
+// Execute applies ops in order. Either all are applied or none: the first
+// failing operation reverts the ones before it, pairs they created
+// included, and its error is returned. Unknown kinds fail with
+// ErrorInvalidOperation. Events, audit records, metrics and logs of the
+// operations are delivered once all are applied.
+func (s *UniswapV2) Execute(ops []Operation) error {
+	var j journal
+	for _, op := range ops {
+		if err := s.execute(&j, op); err != nil {
+			j.revert()
+			return err
+		}
+	}
+	j.commit()
+	return nil
+}
+
+func (s *UniswapV2) execute(j *journal, op Operation) error {
+	if op.Kind == OperationCreatePair {
+		key := pairKey{TokenA: op.TokenA, TokenB: op.TokenB, Fee: op.FeeTier}
+		opts := pairOptions{fee: DefaultFee, journal: j}
+		if op.FeeTier != 0 {
+			opts.fee = Fee{Numerator: int64(op.FeeTier), Denominator: 10000}
+		}
+		if _, err := s.createPair(key, opts); err != nil {
+			return err
+		}
+		j.add(func() {
+			s.removePairKey(j, key)
+		})
+		return nil
+	}
+
+	pair := s.PairWithFee(op.TokenA, op.TokenB, op.FeeTier)
+	if pair == nil {
+		return ErrorPairNotExists
+	}
+	pair = pair.inJournal(j)
+	switch op.Kind {
+	case OperationMint:
+		_, err := mint(j, pair, op.Address, orZero(op.AmountAIn), orZero(op.AmountBIn))
+		return err
+	case OperationBurn:
+		if op.Liquidity == nil {
+			return ErrorInsufficientLiquidityBurned
+		}
+		_, _, err := removeLiquidity(j, pair, op.Liquidity, big.NewInt(0), big.NewInt(0), op.Address)
+		return err
+	case OperationSwap:
+		sender := op.Address
+		if sender != addressZero {
+			if err := normalizeAddresses(&sender); err != nil {
+				return err
+			}
+		}
+		amount0, amount1, err := pair.withSender(sender).Swap(orZero(op.AmountAIn), orZero(op.AmountBIn), orZero(op.AmountAOut), orZero(op.AmountBOut))
+		if err != nil {
+			return err
+		}
+		j.add(func() {
+			pair.rollbackUpdate(new(big.Int).Neg(amount0), new(big.Int).Neg(amount1))
+		})
+		return nil
+	}
+	return ErrorInvalidOperation
+}
+
+func orZero(amount *big.Int) *big.Int {
+	if amount == nil {
+		return big.NewInt(0)
+	}
+	return amount
+}
@@ -0,0 +1,130 @@
+package uniswapV2
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_Execute(t *testing.T) {
+	service := New()
+	err := service.Execute([]Operation{
+		{Kind: OperationCreatePair, TokenA: 0, TokenB: 1},
+		{Kind: OperationMint, TokenA: 0, TokenB: 1, Address: "alice", AmountAIn: big.NewInt(1e18), AmountBIn: big.NewInt(2e18)},
+		{Kind: OperationSwap, TokenA: 0, TokenB: 1, AmountAIn: big.NewInt(1e15), AmountBOut: big.NewInt(1e15)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair := service.Pair(0, 1)
+	if pair == nil {
+		t.Fatal("pair not created")
+	}
+	reserve0, reserve1 := pair.Reserves()
+	if reserve0.Cmp(big.NewInt(1001e15)) != 0 || reserve1.Cmp(big.NewInt(1999e15)) != 0 {
+		t.Fatalf("reserves want 1001e15 and 1999e15, got %s and %s", reserve0, reserve1)
+	}
+	liquidity := pair.Balance("alice")
+	totalSupply := pair.TotalSupply()
+
+	err = service.Execute([]Operation{
+		{Kind: OperationCreatePair, TokenA: 1, TokenB: 2},
+		{Kind: OperationMint, TokenA: 1, TokenB: 2, Address: "alice", AmountAIn: big.NewInt(1e18), AmountBIn: big.NewInt(1e18)},
+		{Kind: OperationBurn, TokenA: 1, TokenB: 0, Address: "alice", Liquidity: big.NewInt(1e17)},
+		{Kind: OperationSwap, TokenA: 1, TokenB: 0, AmountAIn: big.NewInt(1e15), AmountBOut: big.NewInt(4e14)},
+		{Kind: OperationBurn, TokenA: 0, TokenB: 1, Address: "bob", Liquidity: big.NewInt(1)},
+	})
+	if !errors.Is(err, ErrorInsufficientLiquidityBurned) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidityBurned)
+	}
+	if service.Pair(1, 2) != nil {
+		t.Error("created pair want removed")
+	}
+	reserve0After, reserve1After := pair.Reserves()
+	if reserve0After.Cmp(reserve0) != 0 || reserve1After.Cmp(reserve1) != 0 {
+		t.Errorf("reserves want %s and %s, got %s and %s", reserve0, reserve1, reserve0After, reserve1After)
+	}
+	if pair.Balance("alice").Cmp(liquidity) != 0 {
+		t.Errorf("balance want %s, got %s", liquidity, pair.Balance("alice"))
+	}
+	if pair.TotalSupply().Cmp(totalSupply) != 0 {
+		t.Errorf("total supply want %s, got %s", totalSupply, pair.TotalSupply())
+	}
+
+	if err := service.Execute([]Operation{{Kind: OperationMint, TokenA: 0, TokenB: 1, FeeTier: 5}}); err != ErrorPairNotExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+	if err := service.Execute([]Operation{{Kind: OperationKind(-1), TokenA: 0, TokenB: 1}}); err != ErrorInvalidOperation {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidOperation)
+	}
+
+	defer func(format AddressFormat) { NormalizeAddress = format }(NormalizeAddress)
+	NormalizeAddress = HexAddress
+	err = service.Execute([]Operation{
+		{Kind: OperationSwap, TokenA: 0, TokenB: 1, AmountAIn: big.NewInt(1e15), AmountBOut: big.NewInt(1e15)},
+		{Kind: OperationSwap, TokenA: 0, TokenB: 1, Address: "alice", AmountAIn: big.NewInt(1e15), AmountBOut: big.NewInt(1e15)},
+	})
+	if err != ErrorInvalidAddress {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidAddress)
+	}
+	if reserve0After, _ := pair.Reserves(); reserve0After.Cmp(reserve0) != 0 {
+		t.Errorf("reserve0 want %s, got %s", reserve0, reserve0After)
+	}
+}
+
+func TestUniswapV2_Execute_effects(t *testing.T) {
+	events := NewEvents()
+	var kinds []EventKind
+	events.Listen(func(event Event) {
+		kinds = append(kinds, event.Kind)
+	})
+	var audit bytes.Buffer
+	service := New(WithEvents(events), WithAuditLog(NewAuditLog(&audit, nil)))
+
+	err := service.Execute([]Operation{
+		{Kind: OperationCreatePair, TokenA: 0, TokenB: 1},
+		{Kind: OperationMint, TokenA: 0, TokenB: 1, Address: "alice", AmountAIn: big.NewInt(1e18), AmountBIn: big.NewInt(1e18)},
+		{Kind: OperationSwap, TokenA: 0, TokenB: 1, AmountAIn: big.NewInt(1e15), AmountBOut: big.NewInt(1e15)},
+	})
+	if !errors.Is(err, ErrorK) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorK)
+	}
+	if len(kinds) != 0 || audit.Len() != 0 {
+		t.Fatalf("reverted operations want no events and audit records, got %v and %q", kinds, audit.String())
+	}
+
+	err = service.Execute([]Operation{
+		{Kind: OperationCreatePair, TokenA: 0, TokenB: 1},
+		{Kind: OperationMint, TokenA: 0, TokenB: 1, Address: "alice", AmountAIn: big.NewInt(1e18), AmountBIn: big.NewInt(1e18)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewRouter(service).SwapExactTokensForTokens(big.NewInt(1e15), big.NewInt(1e18), []Token{0, 1})
+	if err == nil {
+		t.Fatal("swap below the minimum output want error")
+	}
+	_, err = NewRouter(service).SwapExactTokensForTokens(big.NewInt(1e15), big.NewInt(0), []Token{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(kinds) != 2 || kinds[0] != EventMint || kinds[1] != EventSwap {
+		t.Fatalf("events want mint and swap, got %v", kinds)
+	}
+	var operations []string
+	scanner := bufio.NewScanner(&audit)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		operations = append(operations, record.Operation)
+	}
+	if len(operations) != 3 || operations[0] != "create_pair" || operations[1] != "mint" || operations[2] != "swap" {
+		t.Fatalf("audit records want create_pair, mint and swap, got %v", operations)
+	}
+}
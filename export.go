@@ -0,0 +1,295 @@
+package uniswapV2
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+)
+
+const exportVersion = 1
+
+var (
+	ErrorInvalidExport = errors.New("INVALID_EXPORT")
+)
+
+// stateExport is the document written by ExportJSON:
+//
+//	{
+//	  "version": 1,
+//	  "pairs": [{
+//	    "token0": 0, "token1": 1,          canonical order, token0 < token1
+//	    "fee_tier": 0,                     0 for CreatePair, else bps
+//	    "fee": {"numerator": 3, "denominator": 1000},
+//	    "reserve0": "...", "reserve1": "...", "total_supply": "...",
+//	    "price0_cumulative_last": "...", "price1_cumulative_last": "...",
+//	    "block_timestamp_last": 0,
+//	    "balances": [{"address": "...", "liquidity": "..."}],
+//	    "allowances": [{"owner": "...", "spender": "...", "amount": "..."}],
+//	    "nonces": [{"address": "...", "nonce": 0}],
+//	    "metadata": {"key": "value"}
+//	  }],
+//	  "tokens": [{"token": 0, "symbol": "WETH", "decimals": 18}]
+//	}
+//
+// Amounts are decimal strings. Pairs are sorted by token0, token1 and fee
+// tier, balances and allowances by AddressLess. Tokens, registered with
+// RegisterToken, are left out if there are none.
+type stateExport struct {
+	Version int           `json:"version"`
+	Pairs   []pairExport  `json:"pairs"`
+	Tokens  []tokenExport `json:"tokens,omitempty"`
+}
+
+type tokenExport struct {
+	Token Token `json:"token"`
+	TokenInfo
+}
+
+type pairExport struct {
+	Token0               Token             `json:"token0"`
+	Token1               Token             `json:"token1"`
+	FeeTier              uint32            `json:"fee_tier"`
+	Fee                  Fee               `json:"fee"`
+	Reserve0             string            `json:"reserve0"`
+	Reserve1             string            `json:"reserve1"`
+	TotalSupply          string            `json:"total_supply"`
+	Price0CumulativeLast string            `json:"price0_cumulative_last"`
+	Price1CumulativeLast string            `json:"price1_cumulative_last"`
+	BlockTimestampLast   uint32            `json:"block_timestamp_last"`
+	Balances             []balanceDump     `json:"balances"`
+	Allowances           []allowanceExport `json:"allowances"`
+	Nonces               []nonceExport     `json:"nonces"`
+	Metadata             map[string]string `json:"metadata"`
+}
+
+type allowanceExport struct {
+	Owner   Address `json:"owner"`
+	Spender Address `json:"spender"`
+	Amount  string  `json:"amount"`
+}
+
+type nonceExport struct {
+	Address Address `json:"address"`
+	Nonce   uint64  `json:"nonce"`
+}
+
+// ExportJSON writes the whole state of the service as one JSON document,
+// to be loaded with ImportJSON. With WithLazyStorage the pairs not in
+// memory are read from storage.
+func (s *UniswapV2) ExportJSON(w io.Writer) error {
+	s.muPairs.RLock()
+	pairs, err := s.statePairs()
+	s.muPairs.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	export := stateExport{Version: exportVersion, Pairs: make([]pairExport, 0, len(pairs))}
+	for _, pair := range pairs {
+		export.Pairs = append(export.Pairs, pair.export())
+	}
+	for _, token := range s.registeredTokens() {
+		info, _ := s.TokenInfo(token)
+		export.Tokens = append(export.Tokens, tokenExport{Token: token, TokenInfo: info})
+	}
+	return json.NewEncoder(w).Encode(export)
+}
+
+func (p *Pair) export() pairExport {
+	state := p.state()
+	export := pairExport{
+		Token0:               state.Token0,
+		Token1:               state.Token1,
+		FeeTier:              state.FeeTier,
+		Fee:                  state.Fee,
+		Reserve0:             state.Reserve0.String(),
+		Reserve1:             state.Reserve1.String(),
+		TotalSupply:          state.TotalSupply.String(),
+		Price0CumulativeLast: state.Price0CumulativeLast.String(),
+		Price1CumulativeLast: state.Price1CumulativeLast.String(),
+		BlockTimestampLast:   state.BlockTimestampLast,
+		Balances:             []balanceDump{},
+		Allowances:           []allowanceExport{},
+		Nonces:               []nonceExport{},
+		Metadata:             state.Metadata,
+	}
+
+	p.muBalance.RLock()
+	defer p.muBalance.RUnlock()
+
+	for _, address := range p.addresses() {
+		export.Balances = append(export.Balances, balanceDump{Address: address, Liquidity: p.balances[address].String()})
+	}
+	owners := make([]Address, 0, len(p.allowances))
+	for owner := range p.allowances {
+		owners = append(owners, owner)
+	}
+	sortAddresses(owners)
+	for _, owner := range owners {
+		spenders := make([]Address, 0, len(p.allowances[owner]))
+		for spender := range p.allowances[owner] {
+			spenders = append(spenders, spender)
+		}
+		sortAddresses(spenders)
+		for _, spender := range spenders {
+			export.Allowances = append(export.Allowances, allowanceExport{Owner: owner, Spender: spender, Amount: p.allowances[owner][spender].String()})
+		}
+	}
+	addresses := make([]Address, 0, len(p.nonces))
+	for address := range p.nonces {
+		addresses = append(addresses, address)
+	}
+	sortAddresses(addresses)
+	for _, address := range addresses {
+		export.Nonces = append(export.Nonces, nonceExport{Address: address, Nonce: p.nonces[address]})
+	}
+	return export
+}
+
+// ImportJSON loads a document written by ExportJSON into a service without
+// pairs. The document is validated as a whole before anything is added:
+// amounts must be non-negative integers and balances must sum up to the
+// total supply of their pair.
+func (s *UniswapV2) ImportJSON(r io.Reader) error {
+	var export stateExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return err
+	}
+	if export.Version != exportVersion {
+		return ErrorInvalidExport
+	}
+
+	pairs := make([]*Pair, 0, len(export.Pairs))
+	seen := map[pairKey]bool{}
+	for _, pe := range export.Pairs {
+		key := pairKey{TokenA: pe.Token0, TokenB: pe.Token1, Fee: pe.FeeTier}
+		if !key.isSorted() || seen[key] || !pe.Fee.valid() {
+			return ErrorInvalidExport
+		}
+		seen[key] = true
+		pair, err := pe.pair(key)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, pair)
+	}
+
+	tokens := map[Token]bool{}
+	for _, te := range export.Tokens {
+		if tokens[te.Token] {
+			return ErrorInvalidExport
+		}
+		tokens[te.Token] = true
+	}
+
+	metadata := make([]map[string]string, 0, len(export.Pairs))
+	for _, pe := range export.Pairs {
+		metadata = append(metadata, pe.Metadata)
+	}
+	if err := s.load(pairs, metadata); err != nil {
+		return err
+	}
+	for _, te := range export.Tokens {
+		s.RegisterToken(te.Token, te.Symbol, te.Decimals)
+	}
+	return nil
+}
+
+// load adds detached canonical pairs with their metadata to a service
+// without pairs, holding their balances by the canonical addresses.
+func (s *UniswapV2) load(pairs []*Pair, metadata []map[string]string) error {
+	for _, loaded := range pairs {
+		if err := normalizeHolders(loaded); err != nil {
+			return err
+		}
+	}
+
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+
+	if len(s.pairs) != 0 {
+		return ErrorPairExists
+	}
+	for i, loaded := range pairs {
+		pair := s.addPair(loaded.key, loaded.pairData, loaded.balances, loaded.fee)
+		pair.allowances, pair.nonces = loaded.allowances, loaded.nonces
+		s.addKeyPair(loaded.key)
+		for address, balance := range pair.balances {
+			s.updatePosition(address, pair.key, balance)
+		}
+		if len(metadata[i]) != 0 {
+			s.muMetadata.Lock()
+			s.metadata[pair.key] = metadata[i]
+			s.muMetadata.Unlock()
+		}
+	}
+	return nil
+}
+
+// pair builds a detached pair from the export.
+func (pe pairExport) pair(key pairKey) (*Pair, error) {
+	var amounts [5]*big.Int
+	for i, text := range []string{pe.Reserve0, pe.Reserve1, pe.TotalSupply, pe.Price0CumulativeLast, pe.Price1CumulativeLast} {
+		amount, err := parseAmount(text)
+		if err != nil {
+			return nil, err
+		}
+		amounts[i] = amount
+	}
+	blockTimestampLast := pe.BlockTimestampLast
+	pair := &Pair{
+		key: key,
+		fee: pe.Fee,
+		pairData: pairData{
+			pairMutex:            &pairMutex{},
+			reserve0:             amounts[0],
+			reserve1:             amounts[1],
+			totalSupply:          amounts[2],
+			price0CumulativeLast: amounts[3],
+			price1CumulativeLast: amounts[4],
+			blockTimestampLast:   &blockTimestampLast,
+		},
+		balances:   make(map[Address]*big.Int, len(pe.Balances)),
+		allowances: map[Address]map[Address]*big.Int{},
+		nonces:     make(map[Address]uint64, len(pe.Nonces)),
+	}
+
+	sum := big.NewInt(0)
+	for _, balance := range pe.Balances {
+		liquidity, err := parseAmount(balance.Liquidity)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := pair.balances[balance.Address]; ok {
+			return nil, ErrorInvalidExport
+		}
+		pair.balances[balance.Address] = liquidity
+		sum.Add(sum, liquidity)
+	}
+	if sum.Cmp(pair.totalSupply) != 0 {
+		return nil, ErrorInvalidTotalSupply
+	}
+	for _, allowance := range pe.Allowances {
+		amount, err := parseAmount(allowance.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if pair.allowances[allowance.Owner] == nil {
+			pair.allowances[allowance.Owner] = map[Address]*big.Int{}
+		}
+		pair.allowances[allowance.Owner][allowance.Spender] = amount
+	}
+	for _, nonce := range pe.Nonces {
+		pair.nonces[nonce.Address] = nonce.Nonce
+	}
+	return pair, nil
+}
+
+func parseAmount(text string) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(text, 10)
+	if !ok || amount.Sign() == -1 {
+		return nil, ErrorInvalidExport
+	}
+	return amount, nil
+}
@@ -0,0 +1,129 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestUniswapV2_ImportJSON(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("bob", big.NewInt(1e17), big.NewInt(4e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Approve("alice", "bob", big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	pair.SetMetadata("listed", "true")
+	tier, err := service.CreatePairWithFee(0, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tier.Mint("carol", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exported bytes.Buffer
+	if err := service.ExportJSON(&exported); err != nil {
+		t.Fatal(err)
+	}
+
+	imported := New()
+	if err := imported.ImportJSON(bytes.NewReader(exported.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	var reexported bytes.Buffer
+	if err := imported.ExportJSON(&reexported); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(exported.Bytes(), reexported.Bytes()) {
+		t.Errorf("export want\n%s\ngot\n%s", exported.String(), reexported.String())
+	}
+
+	if balance := imported.Pair(1, 0).Balance("bob"); balance.Cmp(pair.Balance("bob")) != 0 {
+		t.Errorf("balance want %s, got %s", pair.Balance("bob"), balance)
+	}
+	if allowance := imported.Pair(0, 1).Allowance("alice", "bob"); allowance.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("allowance want %d, got %s", 100, allowance)
+	}
+	if len(imported.PositionsOf("alice")) != 1 || len(imported.PositionsOf("carol")) != 1 {
+		t.Errorf("positions want restored, got %v and %v", imported.PositionsOf("alice"), imported.PositionsOf("carol"))
+	}
+	if _, _, err := imported.FindBestPath(0, 1, big.NewInt(1e16), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := imported.ImportJSON(bytes.NewReader(exported.Bytes())); err != ErrorPairExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairExists)
+	}
+	corrupted := strings.Replace(exported.String(), `"liquidity":"1000"`, `"liquidity":"1001"`, 1)
+	if err := New().ImportJSON(strings.NewReader(corrupted)); err != ErrorInvalidTotalSupply {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidTotalSupply)
+	}
+	if err := New().ImportJSON(strings.NewReader(`{"version":2,"pairs":[]}`)); err != ErrorInvalidExport {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidExport)
+	}
+}
+
+func TestUniswapV2_ImportJSON_normalized(t *testing.T) {
+	defer func(format AddressFormat) { NormalizeAddress = format }(NormalizeAddress)
+
+	alice := "0x52908400098527886E0F7030069857D2E4169EE7"
+	bob := "0xde709f2102306220921060314715629080e2fb77"
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, address := range []Address{Address(alice), Address(strings.ToLower(alice))} {
+		if _, err := pair.Mint(address, big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pair.Approve(Address(alice), Address(strings.ToUpper(bob)), big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	var exported bytes.Buffer
+	if err := service.ExportJSON(&exported); err != nil {
+		t.Fatal(err)
+	}
+
+	NormalizeAddress = HexAddress
+	imported := New()
+	if err := imported.ImportJSON(bytes.NewReader(exported.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if balance := imported.Pair(0, 1).Balance(Address(alice)); balance.Cmp(big.NewInt(2e18-minimumLiquidity)) != 0 {
+		t.Errorf("balance want %d, got %s", int64(2e18-minimumLiquidity), balance)
+	}
+	if allowance := imported.Pair(0, 1).Allowance(Address(alice), Address(bob)); allowance.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("allowance want %d, got %s", 100, allowance)
+	}
+	if positions := imported.PositionsOf(Address(strings.ToLower(alice))); len(positions) != 1 {
+		t.Errorf("positions want 1, got %d", len(positions))
+	}
+
+	NormalizeAddress = OpaqueAddress
+	if _, err := pair.Mint("carol", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	exported.Reset()
+	if err := service.ExportJSON(&exported); err != nil {
+		t.Fatal(err)
+	}
+	NormalizeAddress = HexAddress
+	if err := New().ImportJSON(bytes.NewReader(exported.Bytes())); err != ErrorInvalidAddress {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidAddress)
+	}
+}
@@ -0,0 +1,91 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrorInsufficientBalance = errors.New("INSUFFICIENT_BALANCE")
+	ErrorOverflow            = errors.New("OVERFLOW")
+)
+
+// MaxUint112 is the largest reserve the contract can store, 2^112 - 1.
+var MaxUint112 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 112), big.NewInt(1))
+
+// maxReserve bounds the reserves set by Sync and loaded from genesis
+// whatever the limits of the service.
+var maxReserve = new(big.Int).Set(MaxUint112)
+
+// Skim returns the amounts by which the actual token balances of the pair,
+// as tracked by the embedding ledger, exceed the reserves. The pair holds no
+// token balances, so it is up to the ledger to transfer them to whoever
+// skims; the reserves are unchanged.
+func (p *Pair) Skim(actualBalance0, actualBalance1 *big.Int) (amount0, amount1 *big.Int, err error) {
+	if err := checkAmounts(actualBalance0, actualBalance1); err != nil {
+		return nil, nil, err
+	}
+	reserve0, reserve1 := p.Reserves()
+	if actualBalance0.Cmp(reserve0) == -1 || actualBalance1.Cmp(reserve1) == -1 {
+		return nil, nil, ErrorInsufficientBalance
+	}
+	return reserve0.Sub(actualBalance0, reserve0), reserve1.Sub(actualBalance1, reserve1), nil
+}
+
+// Sync sets the reserves to the actual token balances of the pair, e.g.
+// after a rebase or a transfer fee of one of the tokens. Once liquidity is
+// minted both balances must stay positive, or the next Mint could not price
+// the deposit; Sync fails with ErrorInsufficientLiquidity otherwise.
+func (p *Pair) Sync(balance0, balance1 *big.Int) (err error) {
+	p, release := p.holdOp()
+	defer release()
+	defer p.wrapError(&err, "sync", func() errorAmounts {
+		return errorAmounts{}.set(p, "balance", balance0, balance1)
+	})
+	if balance0 == nil || balance1 == nil {
+		return ErrorNilAmount
+	}
+	if balance0.Sign() == -1 || balance1.Sign() == -1 {
+		return ErrorInsufficientBalance
+	}
+	if balance0.Cmp(maxReserve) == 1 || balance1.Cmp(maxReserve) == 1 {
+		return ErrorOverflow
+	}
+	if limit := p.service.reserveLimit; limit != nil && (balance0.Cmp(limit) == 1 || balance1.Cmp(limit) == 1) {
+		return ErrorOverflow
+	}
+	if (balance0.Sign() == 0 || balance1.Sign() == 0) && p.TotalSupply().Sign() == 1 {
+		return ErrorInsufficientLiquidity
+	}
+	for _, hooks := range p.service.operationHooks() {
+		if hooks.BeforeSync != nil {
+			if err := hooks.BeforeSync(p, balance0, balance1); err != nil {
+				return err
+			}
+		}
+	}
+	if err := p.checkAccumulators(); err != nil {
+		return err
+	}
+	p.saveForOp()
+	if err := p.writeWAL("sync", addressZero, balance0, balance1); err != nil {
+		return err
+	}
+
+	p.pairData.Lock()
+	p.isDirty = true
+	p.accumulate(p.service.now())
+	p.reserve0.Set(balance0)
+	p.reserve1.Set(balance1)
+	p.service.routes.touch(p.key.TokenA, p.key.TokenB)
+	p.pairData.Unlock()
+
+	p.audit("sync", addressZero, auditAmounts{}.set(p, "", balance0, balance1))
+	p.emit(Event{Kind: EventSync})
+	for _, hooks := range p.service.operationHooks() {
+		if hooks.AfterSync != nil {
+			hooks.AfterSync(p, balance0, balance1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestPair_Skim(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amount0, amount1, err := pair.Skim(big.NewInt(1e18+5), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount0.Cmp(big.NewInt(5)) != 0 || amount1.Sign() != 0 {
+		t.Errorf("skimmed want 5, 0, got %s, %s", amount0, amount1)
+	}
+	reserve0, _ := pair.Reserves()
+	if reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserve0 want %s, got %s", big.NewInt(1e18), reserve0)
+	}
+
+	_, _, err = pair.Skim(big.NewInt(1e18), big.NewInt(2e18-1))
+	if err != ErrorInsufficientBalance {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientBalance)
+	}
+}
+
+func TestPair_Sync(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pair.Sync(big.NewInt(9e17), big.NewInt(2e18)); err != nil {
+		t.Fatal(err)
+	}
+	reserve0, reserve1 := service.Pair(0, 1).Reserves()
+	if reserve0.Cmp(big.NewInt(2e18)) != 0 || reserve1.Cmp(big.NewInt(9e17)) != 0 {
+		t.Errorf("reserves want %s, %s, got %s, %s", big.NewInt(2e18), big.NewInt(9e17), reserve0, reserve1)
+	}
+
+	if err := pair.Sync(new(big.Int).Add(maxReserve, big.NewInt(1)), big.NewInt(1)); !errors.Is(err, ErrorOverflow) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorOverflow)
+	}
+	if err := pair.Sync(big.NewInt(-1), big.NewInt(1)); !errors.Is(err, ErrorInsufficientBalance) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientBalance)
+	}
+}
+
+func TestPair_Sync_zeroBalances(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Sync(big.NewInt(0), big.NewInt(5)); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pair.Sync(big.NewInt(0), big.NewInt(0)); !errors.Is(err, ErrorInsufficientLiquidity) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+	if err := pair.Sync(big.NewInt(1e18), big.NewInt(0)); !errors.Is(err, ErrorInsufficientLiquidity) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPair_Mint_zeroReserve(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a reserve can only reach zero by an import or a genesis
+	pair.pairData.reserve1.SetInt64(0)
+
+	if _, err := pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18)); !errors.Is(err, ErrorInsufficientLiquidity) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+}
@@ -0,0 +1,48 @@
+package uniswapV2
+
+import "math/big"
+
+// ExecuteRoutes sells amountIn of the first token of routes for the last one
+// along the first route, tried in order, that pays at least amountOutMin, as
+// SwapExactTokensForTokens does, e.g. with the paths found by an aggregator
+// best first. A route that fails, for a missing pair, too little liquidity
+// or output, is undone before the next one is tried, so that either one
+// route is applied or none. route is the index of the route used; if none
+// is, it is -1 and the error of the last route is returned. Without routes
+// it fails with ErrorInvalidPath.
+func (r *Router) ExecuteRoutes(amountIn, amountOutMin *big.Int, routes [][]Token) (route int, amounts []*big.Int, err error) {
+	if err := r.checkDeadline(); err != nil {
+		return -1, nil, err
+	}
+	if err := checkAmounts(amountIn, amountOutMin); err != nil {
+		return -1, nil, err
+	}
+	if len(routes) == 0 {
+		return -1, nil, ErrorInvalidPath
+	}
+
+	for i, path := range routes {
+		if amounts, err = r.executeRoute(amountIn, amountOutMin, path); err == nil {
+			return i, amounts, nil
+		}
+	}
+	return -1, nil, err
+}
+
+func (r *Router) executeRoute(amountIn, amountOutMin *big.Int, path []Token) ([]*big.Int, error) {
+	amounts, pairs, err := r.service.amountsOut(amountIn, path)
+	if err != nil {
+		return nil, err
+	}
+	if amounts[len(amounts)-1].Cmp(amountOutMin) == -1 {
+		return nil, &SlippageError{Hop: len(pairs) - 1, Amount: amounts[len(amounts)-1], Limit: amountOutMin, Err: ErrorInsufficientOutputAmount}
+	}
+
+	var j journal
+	if err := swap(&j, r.sender, amounts, pairs); err != nil {
+		j.revert()
+		return nil, err
+	}
+	j.commit()
+	return amounts, nil
+}
@@ -0,0 +1,54 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestRouter_ExecuteRoutes(t *testing.T) {
+	service := New()
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}, {0, 2}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := NewRouter(service)
+	amountIn := big.NewInt(1e16)
+	direct, err := router.GetAmountsOut(amountIn, []Token{0, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the missing pair and the two-hop route paying less than the direct one
+	// are skipped and leave nothing behind
+	routes := [][]Token{{0, 3}, {0, 1, 2}, {0, 2}}
+	route, amounts, err := router.ExecuteRoutes(amountIn, direct[1], routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route != 2 || amounts[1].Cmp(direct[1]) != 0 {
+		t.Fatalf("route want 2 paying %s, got %d paying %v", direct[1], route, amounts)
+	}
+	if reserve0, reserve1 := service.Pair(0, 1).Reserves(); reserve0.Cmp(big.NewInt(1e18)) != 0 || reserve1.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserves of the skipped route want 1e18/1e18, got %s/%s", reserve0, reserve1)
+	}
+
+	state := marshal(t, service)
+	route, _, err = router.ExecuteRoutes(amountIn, big.NewInt(1e18), routes)
+	if !errors.Is(err, ErrorInsufficientOutputAmount) || route != -1 {
+		t.Fatalf("failed with %v on route %d; want error %v", err, route, ErrorInsufficientOutputAmount)
+	}
+	if !bytes.Equal(marshal(t, service), state) {
+		t.Error("failed routes changed the state")
+	}
+	if _, _, err := router.ExecuteRoutes(amountIn, big.NewInt(0), nil); err != ErrorInvalidPath {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidPath)
+	}
+}
@@ -0,0 +1,136 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+// Fee is the share of every swap input kept by liquidity providers,
+// Numerator/Denominator.
+type Fee struct {
+	Numerator   int64 `json:"numerator"`
+	Denominator int64 `json:"denominator"`
+}
+
+var DefaultFee = Fee{Numerator: 3, Denominator: 1000}
+
+var (
+	ErrorInvalidFee = errors.New("INVALID_FEE")
+)
+
+func (f Fee) valid() bool {
+	return f.Denominator > 0 && f.Numerator >= 0 && f.Numerator < f.Denominator
+}
+
+func (f Fee) numerator() *big.Int {
+	return big.NewInt(f.Numerator)
+}
+
+func (f Fee) denominator() *big.Int {
+	return big.NewInt(f.Denominator)
+}
+
+// remainder is the part of the input that is traded, Denominator-Numerator.
+func (f Fee) remainder() *big.Int {
+	return big.NewInt(f.Denominator - f.Numerator)
+}
+
+type pairOptions struct {
+	fee Fee
+	// journal holds back the audit record and log of the creation
+	journal *journal
+}
+
+type PairOption func(*pairOptions)
+
+func WithFee(numerator, denominator int64) PairOption {
+	return func(o *pairOptions) {
+		o.fee = Fee{Numerator: numerator, Denominator: denominator}
+	}
+}
+
+// CreatePairWithFee creates an additional pool for the token pair charging
+// feeBps basis points. Pools of different tiers are independent pairs.
+func (s *UniswapV2) CreatePairWithFee(coinA, coinB Token, feeBps uint32) (*Pair, error) {
+	if feeBps == 0 {
+		return nil, ErrorInvalidFee
+	}
+	opts := pairOptions{fee: Fee{Numerator: int64(feeBps), Denominator: 10000}}
+	return s.createPair(pairKey{TokenA: coinA, TokenB: coinB, Fee: feeBps}, opts)
+}
+
+func (s *UniswapV2) PairWithFee(coinA, coinB Token, feeBps uint32) *Pair {
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+
+	key := pairKey{TokenA: coinA, TokenB: coinB, Fee: feeBps}
+	pair, _, err := s.loadPair(key)
+	if err != nil {
+		s.logStorageError(key, err)
+	}
+	return pair
+}
+
+// Tiers returns every pair of the two tokens, the CreatePair one first.
+func (s *UniswapV2) Tiers(coinA, coinB Token) []*Pair {
+	s.muPairs.RLock()
+	pairs := s.tierPairs(coinA, coinB)
+	s.muPairs.RUnlock()
+
+	if err := s.attachPairs(pairs); err != nil {
+		s.logStorageError(pairKey{TokenA: coinA, TokenB: coinB}, err)
+		return nil
+	}
+	return pairs
+}
+
+func (s *UniswapV2) tierPairs(coinA, coinB Token) []*Pair {
+	key := pairKey{TokenA: coinA, TokenB: coinB}
+	fees := s.tiers[key.sort()]
+	pairs := make([]*Pair, 0, len(fees))
+	for _, fee := range fees {
+		key.Fee = fee
+		if pair, ok := s.viewPair(key); ok {
+			pairs = append(pairs, pair)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].key.Fee < pairs[j].key.Fee })
+	return pairs
+}
+
+// bestPairOut picks the tier paying the most tokenOut for amountIn of tokenIn.
+func (s *UniswapV2) bestPairOut(tokenIn, tokenOut Token, amountIn *big.Int) (best *Pair, amountOut *big.Int, err error) {
+	err = ErrorPairNotExists
+	for _, pair := range s.tierPairs(tokenIn, tokenOut) {
+		out, outErr := pair.GetAmountOut(amountIn)
+		if outErr != nil {
+			if best == nil {
+				err = outErr
+			}
+			continue
+		}
+		if amountOut == nil || out.Cmp(amountOut) == 1 {
+			best, amountOut, err = pair, out, nil
+		}
+	}
+	return best, amountOut, err
+}
+
+// bestPairIn picks the tier requiring the least tokenIn for amountOut of tokenOut.
+func (s *UniswapV2) bestPairIn(tokenIn, tokenOut Token, amountOut *big.Int) (best *Pair, amountIn *big.Int, err error) {
+	err = ErrorPairNotExists
+	for _, pair := range s.tierPairs(tokenIn, tokenOut) {
+		in, inErr := pair.GetAmountIn(amountOut)
+		if inErr != nil {
+			if best == nil {
+				err = inErr
+			}
+			continue
+		}
+		if amountIn == nil || in.Cmp(amountIn) == -1 {
+			best, amountIn, err = pair, in, nil
+		}
+	}
+	return best, amountIn, err
+}
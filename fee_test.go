@@ -0,0 +1,73 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_CreatePairWithFee(t *testing.T) {
+	service := New()
+	if _, err := service.CreatePairWithFee(0, 1, 0); err != ErrorInvalidFee {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidFee)
+	}
+	if _, err := service.CreatePairWithFee(0, 1, 10000); err != ErrorInvalidFee {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidFee)
+	}
+
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair5, err := service.CreatePairWithFee(1, 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CreatePairWithFee(0, 1, 5); err != ErrorPairExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairExists)
+	}
+	if service.PairWithFee(0, 1, 5) == nil || service.PairWithFee(0, 1, 30) != nil {
+		t.Error("unexpected tier lookup result")
+	}
+
+	for _, pair := range []*Pair{pair, pair5} {
+		_, err = pair.Mint("address", big.NewInt(5e18), big.NewInt(5e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tiers := service.Tiers(1, 0)
+	if len(tiers) != 2 || tiers[0].key.Fee != 0 || tiers[1].key.Fee != 5 {
+		t.Fatalf("unexpected tiers %v", tiers)
+	}
+
+	expected, err := service.PairWithFee(0, 1, 5).GetAmountOut(big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	amounts, err := NewRouter(service).Swap(big.NewInt(1e18), []Token{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amounts[1].Cmp(expected) != 0 {
+		t.Errorf("amountOut want %s, got %s", expected, amounts[1])
+	}
+	if reserve0, _ := pair.Reserves(); reserve0.Cmp(big.NewInt(5e18)) != 0 {
+		t.Errorf("default tier reserve0 want %s, got %s", big.NewInt(5e18), reserve0)
+	}
+	if reserve0, _ := service.PairWithFee(0, 1, 5).Reserves(); reserve0.Cmp(big.NewInt(6e18)) != 0 {
+		t.Errorf("5bps tier reserve0 want %s, got %s", big.NewInt(6e18), reserve0)
+	}
+
+	path, amountOut, err := service.FindBestPath(1, 0, big.NewInt(1e17), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, err := service.PairWithFee(1, 0, 5).GetAmountOut(big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 2 || amountOut.Cmp(best) != 0 {
+		t.Errorf("best path want %v with %s, got %v with %s", []Token{1, 0}, best, path, amountOut)
+	}
+}
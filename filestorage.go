@@ -0,0 +1,513 @@
+package uniswapV2
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrorCorruptStorage  = errors.New("CORRUPT_STORAGE")
+	ErrorBatchInProgress = errors.New("BATCH_IN_PROGRESS")
+)
+
+const (
+	fileRecordSet    byte = 1
+	fileRecordDelete byte = 2
+	// fileRecordBegin and fileRecordEnd enclose the records of a batch
+	fileRecordBegin byte = 3
+	fileRecordEnd   byte = 4
+
+	// fileCompactGarbage is the least size of the records replaced or deleted
+	// that the file is compacted at.
+	fileCompactGarbage = 1 << 20
+)
+
+// FileStorage is a Storage kept in an append-only file, so that state
+// committed through NewStorageWriter survives restarts. Only the keys and
+// the file offsets of their values are held in memory. Every Set and Delete
+// appends a record of the operation, the key, the value and a CRC-32 of
+// them; on open the records are replayed. A record at the end of the file
+// that is cut short or fails its checksum was torn by a crash and is cut
+// off; an invalid record anywhere else fails OpenFileStorage with
+// ErrorCorruptStorage instead of dropping the records after it.
+//
+// FileStorage is a BatchStorage: the records of a batch are enclosed in
+// begin and end records and replayed only if the end record is there, so
+// a Commit through NewStorageWriter survives a crash whole or not at all.
+//
+// The format is kept here rather than in Badger or LevelDB so that the
+// module depends on the standard library only; either is plugged in with an
+// adapter implementing Storage, and BatchStorage with its write batches.
+//
+// Once the records of replaced and deleted values take up more than half of
+// the file, and at least a MiB, the file is compacted: rewritten with the
+// live values only and renamed over the old one, so that a crash leaves
+// either. A failed compaction keeps the old file and is retried by the next
+// write; Compact reports its error.
+type FileStorage struct {
+	mu    sync.RWMutex
+	path  string
+	file  *os.File
+	size  int64
+	index map[string]fileValue
+	// size of the records replaced or deleted
+	garbage   int64
+	compactAt int64
+	batch     *fileBatch
+}
+
+// fileBatch is a batch not yet ended, with what is needed to drop it.
+type fileBatch struct {
+	start   int64
+	garbage int64
+	// undo holds the entries replaced by the batch, nil for absent keys
+	undo map[string]*fileValue
+}
+
+type fileValue struct {
+	offset int64
+	length int
+	// size of the whole record
+	record int64
+}
+
+// OpenFileStorage opens the storage file at path, creating it if needed.
+func OpenFileStorage(path string) (*FileStorage, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	storage := &FileStorage{path: path, file: file, index: map[string]fileValue{}, compactAt: fileCompactGarbage}
+	if err := storage.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if storage.wasteful() {
+		if err := storage.compact(); err != nil {
+			storage.file.Close()
+			return nil, err
+		}
+	}
+	return storage, nil
+}
+
+func (f *FileStorage) replay() error {
+	info, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	type entry struct {
+		op  byte
+		key string
+		ref fileValue
+	}
+	var batch []entry
+	inBatch := false
+	r := &countingReader{r: bufio.NewReader(f.file)}
+	for {
+		start := r.n
+		op, key, value, err := readFileRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err == errTornRecord || err == errFileChecksum && r.n == info.Size() {
+			break
+		}
+		if err == errFileChecksum || err == errInvalidRecord {
+			return fmt.Errorf("%w: record at offset %d", ErrorCorruptStorage, start)
+		}
+		if err != nil {
+			return err
+		}
+
+		ref := fileValue{offset: r.n - 4 - int64(len(value)), length: len(value), record: r.n - start}
+		switch op {
+		case fileRecordBegin:
+			if inBatch {
+				return fmt.Errorf("%w: record at offset %d", ErrorCorruptStorage, start)
+			}
+			inBatch = true
+			f.garbage += ref.record
+		case fileRecordEnd:
+			if !inBatch {
+				return fmt.Errorf("%w: record at offset %d", ErrorCorruptStorage, start)
+			}
+			for _, entry := range batch {
+				f.apply(entry.op, entry.key, entry.ref)
+			}
+			batch, inBatch = nil, false
+			f.garbage += ref.record
+			f.size = r.n
+		default:
+			if inBatch {
+				batch = append(batch, entry{op: op, key: string(key), ref: ref})
+				continue
+			}
+			f.apply(op, string(key), ref)
+			f.size = r.n
+		}
+	}
+	// the records after the last complete one, a batch never ended included,
+	// are cut off
+	if err := f.file.Truncate(f.size); err != nil {
+		return err
+	}
+	_, err = f.file.Seek(f.size, io.SeekStart)
+	return err
+}
+
+var (
+	// errTornRecord is a record cut short by the end of the file
+	errTornRecord = errors.New("torn record")
+	// errFileChecksum is a complete record failing its checksum
+	errFileChecksum  = errors.New("record checksum mismatch")
+	errInvalidRecord = errors.New("invalid record")
+)
+
+// readFileRecord reads the next record, failing with io.EOF at the end of
+// the file, errTornRecord, errFileChecksum, errInvalidRecord or the error of
+// r.
+func readFileRecord(r *countingReader) (op byte, key, value []byte, err error) {
+	h := crc32.NewIEEE()
+	tee := io.TeeReader(r, h)
+	torn := func(err error) error {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return errTornRecord
+		}
+		return err
+	}
+	var head [1]byte
+	if _, err := io.ReadFull(tee, head[:]); err != nil {
+		return 0, nil, nil, err
+	}
+	op = head[0]
+	if op < fileRecordSet || op > fileRecordEnd {
+		return 0, nil, nil, errInvalidRecord
+	}
+	read := func() ([]byte, error) {
+		n, err := binary.ReadUvarint(byteReader{tee})
+		if err != nil {
+			return nil, torn(err)
+		}
+		if n > 1<<30 {
+			return nil, errInvalidRecord
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(tee, buf); err != nil {
+			return nil, torn(err)
+		}
+		return buf, nil
+	}
+	if key, err = read(); err != nil {
+		return 0, nil, nil, err
+	}
+	if value, err = read(); err != nil {
+		return 0, nil, nil, err
+	}
+	var sum [4]byte
+	if _, err := io.ReadFull(r, sum[:]); err != nil {
+		return 0, nil, nil, torn(err)
+	}
+	if binary.BigEndian.Uint32(sum[:]) != h.Sum32() {
+		return 0, nil, nil, errFileChecksum
+	}
+	return op, key, value, nil
+}
+
+// apply indexes a set or delete record of key. The caller holds the write
+// lock.
+func (f *FileStorage) apply(op byte, key string, ref fileValue) {
+	old, ok := f.index[key]
+	if ok {
+		f.garbage += old.record
+	}
+	if f.batch != nil {
+		if _, saved := f.batch.undo[key]; !saved {
+			f.batch.undo[key] = nil
+			if ok {
+				f.batch.undo[key] = &old
+			}
+		}
+	}
+	if op == fileRecordSet {
+		f.index[key] = ref
+	} else {
+		delete(f.index, key)
+		f.garbage += ref.record
+	}
+}
+
+func (f *FileStorage) Get(key []byte) ([]byte, bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.get(string(key))
+}
+
+func (f *FileStorage) get(key string) ([]byte, bool, error) {
+	ref, ok := f.index[key]
+	if !ok {
+		return nil, false, nil
+	}
+	value := make([]byte, ref.length)
+	if _, err := f.file.ReadAt(value, ref.offset); err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (f *FileStorage) Set(key, value []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.append(fileRecordSet, key, value)
+}
+
+func (f *FileStorage) Delete(key []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.index[string(key)]; !ok {
+		return nil
+	}
+	return f.append(fileRecordDelete, key, nil)
+}
+
+func (f *FileStorage) append(op byte, key, value []byte) error {
+	record, err := f.write(op, key, value)
+	if err != nil {
+		return err
+	}
+	f.apply(op, string(key), fileValue{offset: f.size - 4 - int64(len(value)), length: len(value), record: record})
+	if f.batch == nil && f.wasteful() {
+		// the write is done; a failed compaction is retried by the next one
+		f.compact()
+	}
+	return nil
+}
+
+// write appends a record and returns its size. The caller holds the write
+// lock.
+func (f *FileStorage) write(op byte, key, value []byte) (int64, error) {
+	record := fileRecord(op, key, value)
+	if _, err := f.file.WriteAt(record, f.size); err != nil {
+		// drop whatever part of the record was written
+		f.file.Truncate(f.size)
+		return 0, err
+	}
+	f.size += int64(len(record))
+	return int64(len(record)), nil
+}
+
+// BeginBatch starts a batch of the writes up to EndBatch, failing with
+// ErrorBatchInProgress if one is started already.
+func (f *FileStorage) BeginBatch() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.batch != nil {
+		return ErrorBatchInProgress
+	}
+	start, garbage := f.size, f.garbage
+	record, err := f.write(fileRecordBegin, nil, nil)
+	if err != nil {
+		return err
+	}
+	f.garbage += record
+	f.batch = &fileBatch{start: start, garbage: garbage, undo: map[string]*fileValue{}}
+	return nil
+}
+
+// EndBatch ends the batch, writing its end record and syncing the file if
+// err is nil and dropping its writes otherwise, as it does if the end record
+// cannot be written or synced. It returns nil only if the batch is applied.
+func (f *FileStorage) EndBatch(err error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	batch := f.batch
+	if batch == nil {
+		return err
+	}
+	if err == nil {
+		var record int64
+		if record, err = f.write(fileRecordEnd, nil, nil); err == nil {
+			f.garbage += record
+			err = f.file.Sync()
+		}
+	}
+	f.batch = nil
+	if err != nil {
+		for key, old := range batch.undo {
+			if old == nil {
+				delete(f.index, key)
+			} else {
+				f.index[key] = *old
+			}
+		}
+		f.size, f.garbage = batch.start, batch.garbage
+		f.file.Truncate(f.size)
+		return err
+	}
+	if f.wasteful() {
+		f.compact()
+	}
+	return nil
+}
+
+func fileRecord(op byte, key, value []byte) []byte {
+	e := &encoder{}
+	e.byte(op)
+	e.bytes(key)
+	e.bytes(value)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE(e.buf))
+	return append(e.buf, sum[:]...)
+}
+
+// wasteful reports whether the file is due for compaction. The caller holds
+// the lock.
+func (f *FileStorage) wasteful() bool {
+	return f.garbage >= f.compactAt && f.garbage*2 > f.size
+}
+
+// Compact rewrites the file with the live values only, failing with
+// ErrorBatchInProgress within a batch.
+func (f *FileStorage) Compact() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.batch != nil {
+		return ErrorBatchInProgress
+	}
+	return f.compact()
+}
+
+// compact writes the live values to a new file, in key order, and renames it
+// over the old one. The caller holds the write lock.
+func (f *FileStorage) compact() error {
+	path := f.path + ".compact"
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+
+	keys := make([]string, 0, len(f.index))
+	for key := range f.index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	index := make(map[string]fileValue, len(keys))
+	size := int64(0)
+	w := bufio.NewWriter(file)
+	for _, key := range keys {
+		value, _, err := f.get(key)
+		if err != nil {
+			return fail(err)
+		}
+		record := fileRecord(fileRecordSet, []byte(key), value)
+		if _, err := w.Write(record); err != nil {
+			return fail(err)
+		}
+		size += int64(len(record))
+		index[key] = fileValue{offset: size - 4 - int64(len(value)), length: len(value), record: int64(len(record))}
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if err := file.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(path, f.path); err != nil {
+		return fail(err)
+	}
+
+	f.file.Close()
+	f.file, f.size, f.index, f.garbage = file, size, index, 0
+	return nil
+}
+
+func (f *FileStorage) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	f.mu.RLock()
+	keys := make([]string, 0, len(f.index))
+	for key := range f.index {
+		if strings.HasPrefix(key, string(prefix)) {
+			keys = append(keys, key)
+		}
+	}
+	f.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		f.mu.RLock()
+		value, ok, err := f.get(key)
+		f.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := fn([]byte(key), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync flushes the written records to disk.
+func (f *FileStorage) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Sync()
+}
+
+// Close syncs and closes the file.
+func (f *FileStorage) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.file.Sync(); err != nil {
+		f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type byteReader struct {
+	io.Reader
+}
+
+func (b byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(b.Reader, buf[:])
+	return buf[0], err
+}
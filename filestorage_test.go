@@ -0,0 +1,258 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e15), big.NewInt(0), big.NewInt(0), big.NewInt(3e15))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Set([]byte("x"), []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Delete([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a record torn by a crash is cut off
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte{fileRecordSet, 3, 'a'}); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	storage, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	if _, ok, _ := storage.Get([]byte("x")); ok {
+		t.Error("deleted key is replayed")
+	}
+	loaded := New()
+	if err := loaded.LoadStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	var want, got bytes.Buffer
+	if err := service.ExportJSON(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.ExportJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Errorf("state want\n%s\ngot\n%s", want.String(), got.String())
+	}
+
+	if err := storage.Set([]byte("y"), []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok, _ := storage.Get([]byte("y")); !ok || string(value) != "2" {
+		t.Errorf("value want 2, got %q", value)
+	}
+}
+
+func TestFileStorage_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.compactAt = 64
+	for i := 0; i < 100; i++ {
+		if err := storage.Set([]byte("a"), []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := storage.Set([]byte("b"), []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Delete([]byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != storage.size || storage.size > 128 {
+		t.Errorf("file of %d bytes with %d of garbage is not compacted", info.Size(), storage.garbage)
+	}
+	if err := storage.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if storage.garbage != 0 {
+		t.Errorf("garbage want 0, got %d", storage.garbage)
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".compact"); !os.IsNotExist(err) {
+		t.Errorf("compaction file is left: %v", err)
+	}
+
+	storage, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	if value, ok, _ := storage.Get([]byte("a")); !ok || !bytes.Equal(value, []byte{99}) {
+		t.Errorf("value want 99, got %v", value)
+	}
+	if _, ok, _ := storage.Get([]byte("b")); ok {
+		t.Error("deleted key is kept")
+	}
+}
+
+func TestFileStorage_corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c"} {
+		if err := storage.Set([]byte(key), []byte("value")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := len(fileRecord(fileRecordSet, []byte("a"), []byte("value")))
+
+	// a checksum failing at the end is a torn write
+	torn := append([]byte(nil), data...)
+	torn[len(torn)-1] ^= 1
+	if err := ioutil.WriteFile(path, torn, 0644); err != nil {
+		t.Fatal(err)
+	}
+	storage, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := storage.Get([]byte("c")); ok || storage.size != int64(2*record) {
+		t.Errorf("torn record is not cut off: size %d", storage.size)
+	}
+	storage.Close()
+
+	// anywhere else it is corruption, and nothing is cut off
+	corrupt := append([]byte(nil), data...)
+	corrupt[record+3] ^= 1
+	if err := ioutil.WriteFile(path, corrupt, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileStorage(path); !errors.Is(err, ErrorCorruptStorage) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorCorruptStorage)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != int64(len(data)) {
+		t.Errorf("corrupt file is truncated: %v", err)
+	}
+}
+
+func TestFileStorage_batch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	storage, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Set([]byte("a"), []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	errWrite := errors.New("write")
+	if err := storage.BeginBatch(); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.BeginBatch(); err != ErrorBatchInProgress {
+		t.Fatalf("failed with %v; want error %v", err, ErrorBatchInProgress)
+	}
+	if err := storage.Set([]byte("a"), []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Set([]byte("b"), []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.EndBatch(errWrite); err != errWrite {
+		t.Fatalf("failed with %v; want error %v", err, errWrite)
+	}
+	if value, _, _ := storage.Get([]byte("a")); string(value) != "1" {
+		t.Errorf("value of the dropped batch is kept: %q", value)
+	}
+	if _, ok, _ := storage.Get([]byte("b")); ok {
+		t.Error("key of the dropped batch is kept")
+	}
+
+	if err := storage.BeginBatch(); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Delete([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Set([]byte("b"), []byte("3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.EndBatch(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// a batch cut short by a crash is dropped
+	if err := storage.BeginBatch(); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Set([]byte("b"), []byte("4")); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	storage, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.Close()
+	if _, ok, _ := storage.Get([]byte("a")); ok {
+		t.Error("key deleted by the batch is kept")
+	}
+	if value, _, _ := storage.Get([]byte("b")); string(value) != "3" {
+		t.Errorf("value want 3, got %q", value)
+	}
+}
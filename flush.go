@@ -0,0 +1,101 @@
+package uniswapV2
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryPolicy says how Flush retries a failed commit.
+type RetryPolicy struct {
+	// MaxAttempts is the number of commits tried, at least one.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled for every next one
+	// up to MaxBackoff if that is set.
+	Backoff, MaxBackoff time.Duration
+	// Retryable reports whether a commit failing with err may be retried,
+	// IsTemporary if not set.
+	Retryable func(err error) bool
+}
+
+// Flush commits the changes to writer like Commit, retrying the commit
+// according to policy while it fails with a retryable error. The dirty flags
+// stay set after a failed attempt, so every attempt and the next Flush write
+// whatever is not persisted yet. The last error is returned.
+func (s *UniswapV2) Flush(writer StateWriter, policy RetryPolicy) error {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsTemporary
+	}
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := s.Commit(writer)
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+type temporaryError struct {
+	err error
+}
+
+func (e *temporaryError) Error() string   { return e.err.Error() }
+func (e *temporaryError) Unwrap() error   { return e.err }
+func (e *temporaryError) Temporary() bool { return true }
+
+// Temporary marks err, e.g. returned by a Storage, as retryable.
+func Temporary(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &temporaryError{err: err}
+}
+
+// IsTemporary reports whether err or an error it wraps has a Temporary
+// method returning true, like the errors of Temporary and net.Error.
+func IsTemporary(err error) bool {
+	var temporary interface{ Temporary() bool }
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// FaultyStorage wraps a Storage to inject failures, e.g. to test how state
+// is persisted when the storage fails. Fault is called before every
+// operation with its name, "get", "set", "delete" or "iterate", and the key
+// or prefix; an error it returns fails the operation.
+type FaultyStorage struct {
+	Storage
+	Fault func(op string, key []byte) error
+}
+
+func (f *FaultyStorage) Get(key []byte) ([]byte, bool, error) {
+	if err := f.Fault("get", key); err != nil {
+		return nil, false, err
+	}
+	return f.Storage.Get(key)
+}
+
+func (f *FaultyStorage) Set(key, value []byte) error {
+	if err := f.Fault("set", key); err != nil {
+		return err
+	}
+	return f.Storage.Set(key, value)
+}
+
+func (f *FaultyStorage) Delete(key []byte) error {
+	if err := f.Fault("delete", key); err != nil {
+		return err
+	}
+	return f.Storage.Delete(key)
+}
+
+func (f *FaultyStorage) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	if err := f.Fault("iterate", prefix); err != nil {
+		return err
+	}
+	return f.Storage.Iterate(prefix, fn)
+}
@@ -0,0 +1,79 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestUniswapV2_Flush(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errDisk := errors.New("disk")
+	memory := NewMemoryStorage()
+	sets := 0
+	storage := &FaultyStorage{Storage: memory, Fault: func(op string, key []byte) error {
+		if op != "set" {
+			return nil
+		}
+		sets++
+		if sets <= 3 {
+			return Temporary(errDisk)
+		}
+		return nil
+	}}
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+
+	if err := service.Flush(NewStorageWriter(storage), policy); !errors.Is(err, errDisk) || !IsTemporary(err) {
+		t.Fatalf("failed with %v; want temporary error %v", err, errDisk)
+	}
+	if sets != 3 || !pair.isDirty {
+		t.Errorf("attempts want 3 with dirty pair, got %d, dirty %v", sets, pair.isDirty)
+	}
+
+	policy.MaxAttempts = 2
+	if err := service.Flush(NewStorageWriter(storage), policy); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New()
+	if err := loaded.LoadStorage(memory); err != nil {
+		t.Fatal(err)
+	}
+	var want, got bytes.Buffer
+	if err := service.ExportJSON(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.ExportJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Errorf("state want\n%s\ngot\n%s", want.String(), got.String())
+	}
+
+	_, err = pair.Mint("bob", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets = 0
+	storage.Fault = func(op string, key []byte) error {
+		sets++
+		return errDisk
+	}
+	policy.MaxAttempts = 5
+	if err := service.Flush(NewStorageWriter(storage), policy); err != errDisk {
+		t.Fatalf("failed with %v; want error %v", err, errDisk)
+	}
+	if sets != 1 {
+		t.Errorf("fatal error is retried, attempts %d", sets)
+	}
+}
@@ -0,0 +1,141 @@
+package uniswapV2
+
+import "math/big"
+
+// PairSpec describes a pair created by CreatePairs. Amounts are in the order
+// of TokenA and TokenB.
+type PairSpec struct {
+	TokenA, TokenB Token
+	// FeeTier creates a CreatePairWithFee pair when not zero.
+	FeeTier uint32
+	// Fee of a FeeTier zero pair, DefaultFee if not set.
+	Fee Fee
+	// ReserveA and ReserveB are the initial reserves, if any.
+	ReserveA, ReserveB *big.Int
+	// Balances are the LP allocations; their sum is the total supply.
+	// Addresses are normalized, and the balances of addresses normalized
+	// alike are added up.
+	Balances map[Address]*big.Int
+}
+
+// CreatePairs creates all pairs at once, e.g. from genesis, under one lock
+// acquisition. Specs are validated as a whole first and no pair is created
+// if any of them is invalid. Pairs are returned in the token order of their
+// specs.
+func (s *UniswapV2) CreatePairs(specs []PairSpec) ([]*Pair, error) {
+	keys := make([]pairKey, len(specs))
+	datas := make([]pairData, len(specs))
+	balances := make([]map[Address]*big.Int, len(specs))
+	fees := make([]Fee, len(specs))
+	seen := make(map[pairKey]bool, len(specs))
+	for i, spec := range specs {
+		if spec.TokenA == spec.TokenB {
+			return nil, ErrorIdenticalAddresses
+		}
+		key := pairKey{TokenA: spec.TokenA, TokenB: spec.TokenB, Fee: spec.FeeTier}
+		if seen[key.sort()] {
+			return nil, ErrorPairExists
+		}
+		seen[key.sort()] = true
+
+		fee := spec.Fee
+		if spec.FeeTier != 0 {
+			fee = Fee{Numerator: int64(spec.FeeTier), Denominator: 10000}
+		} else if fee == (Fee{}) {
+			fee = DefaultFee
+		}
+		if !fee.valid() {
+			return nil, ErrorInvalidFee
+		}
+
+		data := pairData{reserve0: big.NewInt(0), reserve1: big.NewInt(0), totalSupply: big.NewInt(0)}
+		if spec.ReserveA != nil {
+			data.reserve0.Set(spec.ReserveA)
+		}
+		if spec.ReserveB != nil {
+			data.reserve1.Set(spec.ReserveB)
+		}
+		if data.reserve0.Sign() == -1 || data.reserve1.Sign() == -1 || data.reserve0.Cmp(maxReserve) == 1 || data.reserve1.Cmp(maxReserve) == 1 {
+			return nil, ErrorInvalidReserves
+		}
+		balances[i] = make(map[Address]*big.Int, len(spec.Balances))
+		for address, balance := range spec.Balances {
+			if balance == nil {
+				return nil, ErrorNilAmount
+			}
+			if balance.Sign() == -1 {
+				return nil, ErrorInvalidTotalSupply
+			}
+			if err := normalizeAddresses(&address); err != nil {
+				return nil, err
+			}
+			if balances[i][address] == nil {
+				balances[i][address] = big.NewInt(0)
+			}
+			balances[i][address].Add(balances[i][address], balance)
+			data.totalSupply.Add(data.totalSupply, balance)
+		}
+		if (data.totalSupply.Sign() == 0) != (data.reserve0.Sign() == 0 && data.reserve1.Sign() == 0) {
+			return nil, ErrorInvalidTotalSupply
+		}
+		if data.totalSupply.Sign() == 1 && (data.reserve0.Sign() == 0 || data.reserve1.Sign() == 0) {
+			return nil, ErrorInvalidReserves
+		}
+
+		if s.admission != nil {
+			if err := s.admission(spec.TokenA, spec.TokenB); err != nil {
+				return nil, err
+			}
+		}
+		keys[i], datas[i], fees[i] = key, data, fee
+	}
+
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+
+	for _, key := range keys {
+		if _, ok := s.pair(key); ok {
+			return nil, ErrorPairExists
+		}
+		if stored, err := s.inStorage(key); err != nil {
+			return nil, err
+		} else if stored {
+			return nil, ErrorPairExists
+		}
+	}
+	if s.maxPairs > 0 && len(s.pairs)+len(keys) > s.maxPairs {
+		return nil, ErrorTooManyPairs
+	}
+
+	pairs := make([]*Pair, len(specs))
+	sorted := make([]pairKey, len(keys))
+	isDirtyKeyPairs := s.isDirtyKeyPairs
+	for i, key := range keys {
+		pair := s.addPair(key, datas[i], balances[i], fees[i])
+		for address, balance := range balances[i] {
+			s.updatePosition(address, pair.key, balance)
+		}
+		if len(balances[i]) > 0 {
+			pair.isDirty = true
+			pair.touchBalances()
+		}
+		pair.audit("create_pair", addressZero, nil)
+		pair.log(LogInfo, "pair created", addressZero, LogField{"fee", pair.fee})
+		if !key.isSorted() {
+			pair = pair.revert()
+		}
+		pairs[i] = pair
+		sorted[i] = key.sort()
+	}
+	s.keyPairs = append(s.keyPairs, sorted...)
+	s.isDirtyKeyPairs = true
+	s.undoOp(func() {
+		s.muPairs.Lock()
+		defer s.muPairs.Unlock()
+		for _, key := range sorted {
+			s.dropPair(s.pairs[key])
+		}
+		s.isDirtyKeyPairs = isDirtyKeyPairs
+	})
+	return pairs, nil
+}
@@ -0,0 +1,120 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestUniswapV2_CreatePairs(t *testing.T) {
+	service := New()
+	pairs, err := service.CreatePairs([]PairSpec{
+		{TokenA: 1, TokenB: 0, ReserveA: big.NewInt(1e18), ReserveB: big.NewInt(4e18), Balances: map[Address]*big.Int{"a": big.NewInt(1e18), "b": big.NewInt(1e18)}},
+		{TokenA: 0, TokenB: 1, FeeTier: 5},
+		{TokenA: 2, TokenB: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 3 || len(service.keyPairs) != 3 {
+		t.Fatalf("pairs want 3, got %d/%d", len(pairs), len(service.keyPairs))
+	}
+
+	pair := service.Pair(0, 1)
+	reserve0, reserve1 := pair.Reserves()
+	if reserve0.Cmp(big.NewInt(4e18)) != 0 || reserve1.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserves want 4e18/1e18, got %s/%s", reserve0, reserve1)
+	}
+	if reserve0, _ := pairs[0].Reserves(); reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserve0 want 1e18, got %s", reserve0)
+	}
+	if pair.TotalSupply().Cmp(big.NewInt(2e18)) != 0 {
+		t.Errorf("total supply want 2e18, got %s", pair.TotalSupply())
+	}
+	if balance := pair.Balance("a"); balance.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("balance want 1e18, got %s", balance)
+	}
+	if len(service.PositionsOf("b")) != 1 {
+		t.Errorf("positions want 1, got %d", len(service.PositionsOf("b")))
+	}
+	if service.PairWithFee(1, 0, 5) == nil {
+		t.Error("fee tier pair is not created")
+	}
+
+	amount0, amount1, err := pairs[0].Burn("a", big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount0.Cmp(big.NewInt(5e17)) != 0 || amount1.Cmp(big.NewInt(2e18)) != 0 {
+		t.Errorf("burned want 5e17/2e18, got %s/%s", amount0, amount1)
+	}
+}
+
+func TestUniswapV2_CreatePairs_invalid(t *testing.T) {
+	service := New(WithMaxPairs(2))
+	if _, err := service.CreatePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		specs []PairSpec
+		err   error
+	}{
+		{[]PairSpec{{TokenA: 1, TokenB: 1}}, ErrorIdenticalAddresses},
+		{[]PairSpec{{TokenA: 2, TokenB: 3}, {TokenA: 3, TokenB: 2}}, ErrorPairExists},
+		{[]PairSpec{{TokenA: 1, TokenB: 0}}, ErrorPairExists},
+		{[]PairSpec{{TokenA: 2, TokenB: 3, ReserveA: big.NewInt(1), ReserveB: big.NewInt(1)}}, ErrorInvalidTotalSupply},
+		{[]PairSpec{{TokenA: 2, TokenB: 3, ReserveA: big.NewInt(1), Balances: map[Address]*big.Int{"a": big.NewInt(1)}}}, ErrorInvalidReserves},
+		{[]PairSpec{{TokenA: 2, TokenB: 3}, {TokenA: 4, TokenB: 5}}, ErrorTooManyPairs},
+		{[]PairSpec{{TokenA: 2, TokenB: 3, ReserveA: big.NewInt(1), ReserveB: big.NewInt(1), Balances: map[Address]*big.Int{"a": nil}}}, ErrorNilAmount},
+	}
+	for _, test := range tests {
+		if _, err := service.CreatePairs(test.specs); err != test.err {
+			t.Fatalf("failed with %v; want error %v", err, test.err)
+		}
+	}
+	if len(service.keyPairs) != 1 {
+		t.Errorf("pairs want 1, got %d", len(service.keyPairs))
+	}
+}
+
+func TestUniswapV2_CreatePairs_normalized(t *testing.T) {
+	defer func(format AddressFormat) { NormalizeAddress = format }(NormalizeAddress)
+	NormalizeAddress = HexAddress
+
+	service := New()
+	alice := "0x52908400098527886E0F7030069857D2E4169EE7"
+	_, err := service.CreatePairs([]PairSpec{{
+		TokenA: 0, TokenB: 1, ReserveA: big.NewInt(1e18), ReserveB: big.NewInt(1e18),
+		Balances: map[Address]*big.Int{Address(alice): big.NewInt(1e18), Address(strings.ToLower(alice)): big.NewInt(1e18)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := service.Pair(0, 1).Balance(Address(strings.ToLower(alice))); balance == nil || balance.Cmp(big.NewInt(2e18)) != 0 {
+		t.Errorf("balance want 2e18, got %v", balance)
+	}
+	_, err = service.CreatePairs([]PairSpec{{
+		TokenA: 2, TokenB: 3, ReserveA: big.NewInt(1), ReserveB: big.NewInt(1),
+		Balances: map[Address]*big.Int{"alice": big.NewInt(1)},
+	}})
+	if err != ErrorInvalidAddress {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidAddress)
+	}
+}
+
+func TestUniswapV2_CreatePairs_lazy(t *testing.T) {
+	storage := NewMemoryStorage()
+	service := New()
+	if _, err := service.CreatePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	lazy := New(WithLazyStorage(storage, 1))
+	if _, err := lazy.CreatePairs([]PairSpec{{TokenA: 1, TokenB: 0}}); err != ErrorPairExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairExists)
+	}
+}
@@ -0,0 +1,107 @@
+package uniswapV2
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	ErrorGoldenMismatch = errors.New("GOLDEN_MISMATCH")
+)
+
+// GoldenRecord is one line of a golden file: the outcome of an operation of
+// the corpus and the StateRoot after it.
+type GoldenRecord struct {
+	Line      int      `json:"line"`
+	Operation string   `json:"operation"`
+	Results   []string `json:"results,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	StateRoot string   `json:"state_root"`
+}
+
+// WriteGolden runs the operations of corpus, in the format written by
+// WithWAL, on a new service and writes the outcome of every one to golden.
+// Failing operations are recorded with their error. Forks of the package
+// keep the corpus and golden file of upstream and check them with
+// VerifyGolden to detect any change in behaviour.
+func WriteGolden(corpus io.Reader, golden io.Writer) error {
+	encoder := json.NewEncoder(golden)
+	return runCorpus(corpus, func(record GoldenRecord) error {
+		return encoder.Encode(record)
+	})
+}
+
+// VerifyGolden runs corpus like WriteGolden and fails with
+// ErrorGoldenMismatch at the first outcome that differs from golden.
+func VerifyGolden(corpus, golden io.Reader) error {
+	decoder := json.NewDecoder(golden)
+	err := runCorpus(corpus, func(got GoldenRecord) error {
+		var want GoldenRecord
+		if err := decoder.Decode(&want); err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("%w: line %d is not in the golden file", ErrorGoldenMismatch, got.Line)
+			}
+			return err
+		}
+		wantJSON, _ := json.Marshal(want)
+		gotJSON, _ := json.Marshal(got)
+		if string(wantJSON) != string(gotJSON) {
+			return fmt.Errorf("%w: line %d want %s, got %s", ErrorGoldenMismatch, got.Line, wantJSON, gotJSON)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("%w: golden file has more lines than the corpus", ErrorGoldenMismatch)
+	}
+	return nil
+}
+
+func runCorpus(corpus io.Reader, fn func(record GoldenRecord) error) error {
+	service := New()
+	reader := bufio.NewReader(corpus)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(data) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		var operation walRecord
+		if err := json.Unmarshal(data, &operation); err != nil {
+			return fmt.Errorf("corpus line %d: %w", line, err)
+		}
+		service.clock = func() time.Time { return time.Unix(0, operation.Time) }
+
+		record := GoldenRecord{Line: line, Operation: operation.Operation}
+		results, err := service.replay(operation)
+		if err != nil {
+			// the sentinel only, as recorded before errors had context
+			var pairErr *PairError
+			if errors.As(err, &pairErr) {
+				err = pairErr.Err
+			}
+			record.Error = err.Error()
+		} else {
+			for _, result := range results {
+				record.Results = append(record.Results, result.String())
+			}
+		}
+		root, err := service.StateRoot()
+		if err != nil {
+			return err
+		}
+		record.StateRoot = hex.EncodeToString(root[:])
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
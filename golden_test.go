@@ -0,0 +1,45 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const goldenCorpus = `{"time":1600000000000000000,"operation":"create_pair","token0":1,"token1":0,"fee_tier":0,"fee":{"numerator":3,"denominator":1000}}
+{"time":1600000000000000000,"operation":"mint","address":"alice","token0":1,"token1":0,"fee_tier":0,"amounts":["1000000000000000000","4000000000000000000"]}
+{"time":1600000060000000000,"operation":"swap","token0":0,"token1":1,"fee_tier":0,"amounts":["10000000000000000","0","0","2000000000000000"]}
+{"time":1600000060000000000,"operation":"burn","address":"bob","token0":0,"token1":1,"fee_tier":0,"amounts":["1"]}
+`
+
+func TestVerifyGolden(t *testing.T) {
+	var golden bytes.Buffer
+	if err := WriteGolden(strings.NewReader(goldenCorpus), &golden); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(golden.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("golden lines want 4, got %d", len(lines))
+	}
+	if !strings.Contains(lines[1], `"results":["1999999999999999000"]`) {
+		t.Errorf("mint result is not recorded: %s", lines[1])
+	}
+	if !strings.Contains(lines[3], `"error":"INSUFFICIENT_LIQUIDITY_BURNED"`) {
+		t.Errorf("burn error is not recorded: %s", lines[3])
+	}
+
+	if err := VerifyGolden(strings.NewReader(goldenCorpus), bytes.NewReader(golden.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	drifted := strings.Replace(goldenCorpus, `"2000000000000000"`, `"2000000000000001"`, 1)
+	err := VerifyGolden(strings.NewReader(drifted), bytes.NewReader(golden.Bytes()))
+	if !errors.Is(err, ErrorGoldenMismatch) || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("failed with %v; want error %v at line 3", err, ErrorGoldenMismatch)
+	}
+	short := strings.Join(lines[:3], "\n")
+	if err := VerifyGolden(strings.NewReader(goldenCorpus), strings.NewReader(short)); !errors.Is(err, ErrorGoldenMismatch) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorGoldenMismatch)
+	}
+}
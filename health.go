@@ -0,0 +1,115 @@
+package uniswapV2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HealthStatus is the answer of NewHealthHandler: the height of the last
+// Commit and the outcome of the invariant and storage checks, "ok" or the
+// error, Storage being empty if none is checked.
+type HealthStatus struct {
+	Height     uint64 `json:"height"`
+	Storage    string `json:"storage,omitempty"`
+	Invariants string `json:"invariants"`
+}
+
+// NewHealthHandler serves the health of s to orchestration platforms:
+//
+//	GET /healthz
+//	GET /readyz
+//
+// Both answer with the height of the last Commit and the outcome of
+// ValidateReserves and ValidateTotalSupply, "ok" or the error. /readyz also
+// reads a key of storage, the lazy storage of s if nil and none if s runs
+// without one. /healthz fails with 503 Service Unavailable if an invariant
+// is broken, as the state is then not worth serving any longer, /readyz also
+// while storage is unreachable. Requests pass through middleware first, the
+// first one being the outermost.
+func NewHealthHandler(s *UniswapV2, storage Storage, middleware ...Middleware) http.Handler {
+	if storage == nil && s.lazy != nil {
+		storage = s.lazy.storage
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		status, ok := s.health(nil)
+		writeHealth(w, status, ok)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status, ok := s.health(storage)
+		writeHealth(w, status, ok)
+	})
+	return Chain(mux, middleware...)
+}
+
+// health checks the invariants of s and, if not nil, whether storage can be
+// read, reporting whether all checks pass.
+func (s *UniswapV2) health(storage Storage) (status HealthStatus, ok bool) {
+	status, ok = HealthStatus{Height: s.Height(), Invariants: "ok"}, true
+	for _, validate := range []Validation{ValidateReserves, ValidateTotalSupply} {
+		if err := validate(s); err != nil {
+			status.Invariants, ok = err.Error(), false
+			break
+		}
+	}
+	if storage != nil {
+		status.Storage = "ok"
+		if _, _, err := storage.Get([]byte(mainPrefix)); err != nil {
+			status.Storage, ok = err.Error(), false
+		}
+	}
+	return status, ok
+}
+
+func writeHealth(w http.ResponseWriter, status HealthStatus, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// HealthClient asks a service served by NewHealthHandler.
+type HealthClient struct {
+	// URL is the base URL of the handler.
+	URL    string
+	Client *http.Client
+}
+
+func NewHealthClient(url string) *HealthClient {
+	return &HealthClient{URL: url, Client: http.DefaultClient}
+}
+
+// Health returns the status of /healthz, failing if the service is
+// unhealthy.
+func (c *HealthClient) Health() (HealthStatus, error) {
+	return c.get("/healthz")
+}
+
+// Ready returns the status of /readyz, failing if the service is not ready.
+func (c *HealthClient) Ready() (HealthStatus, error) {
+	return c.get("/readyz")
+}
+
+func (c *HealthClient) get(path string) (status HealthStatus, err error) {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(c.URL + path)
+	if err != nil {
+		return status, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return status, fmt.Errorf("health client: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return status, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return status, fmt.Errorf("health client: %s", resp.Status)
+	}
+	return status, nil
+}
@@ -0,0 +1,83 @@
+package uniswapV2
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewHealthHandler(t *testing.T) {
+	storage := NewMemoryStorage()
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(handler http.Handler, path string) (int, HealthStatus) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		var status HealthStatus
+		if err := json.NewDecoder(recorder.Body).Decode(&status); err != nil {
+			t.Fatal(err)
+		}
+		return recorder.Code, status
+	}
+
+	handler := NewHealthHandler(service, storage)
+	for _, path := range []string{"/healthz", "/readyz"} {
+		code, status := get(handler, path)
+		if code != http.StatusOK || status.Height != 1 || status.Invariants != "ok" {
+			t.Errorf("%s want 200 at height 1, got %d with %+v", path, code, status)
+		}
+	}
+	if _, status := get(handler, "/readyz"); status.Storage != "ok" {
+		t.Errorf("storage want ok, got %q", status.Storage)
+	}
+
+	handler = NewHealthHandler(service, failingStorage{storage})
+	if code, _ := get(handler, "/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz want 200, got %d", code)
+	}
+	if code, status := get(handler, "/readyz"); code != http.StatusServiceUnavailable || status.Storage != errStorage.Error() {
+		t.Errorf("/readyz want 503 with %q, got %d with %+v", errStorage, code, status)
+	}
+
+	pair.muBalance.Lock()
+	pair.balances["address"] = big.NewInt(0)
+	pair.muBalance.Unlock()
+	code, status := get(NewHealthHandler(service, nil), "/healthz")
+	if code != http.StatusServiceUnavailable || status.Invariants != ErrorInvalidTotalSupply.Error() {
+		t.Errorf("/healthz want 503 with %q, got %d with %+v", ErrorInvalidTotalSupply, code, status)
+	}
+}
+
+func TestHealthClient(t *testing.T) {
+	service := New()
+	server := httptest.NewServer(NewHealthHandler(service, NewMemoryStorage()))
+	defer server.Close()
+	client := NewHealthClient(server.URL)
+
+	if status, err := client.Ready(); err != nil || status.Storage != "ok" {
+		t.Fatalf("ready failed with %v: %+v", err, status)
+	}
+	if _, err := service.CreatePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	pair := service.Pair(0, 1)
+	pair.muBalance.Lock()
+	pair.balances["address"] = big.NewInt(1)
+	pair.muBalance.Unlock()
+	if status, err := client.Health(); err == nil || status.Invariants != ErrorInvalidTotalSupply.Error() {
+		t.Fatalf("health want %q, got %+v with %v", ErrorInvalidTotalSupply, status, err)
+	}
+}
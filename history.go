@@ -0,0 +1,110 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+)
+
+var (
+	ErrorUnknownHeight = errors.New("UNKNOWN_HEIGHT")
+)
+
+// pairVersion is the state of a pair committed at height, or its removal.
+type pairVersion struct {
+	height                          uint64
+	reserve0, reserve1, totalSupply *big.Int
+	removed                         bool
+}
+
+// WithHistory keeps the reserves and total supply every Commit persists, for
+// PairAt. Every Commit is a new height, the first one being 1, unless made by
+// EndBlock at the height of its block.
+func WithHistory() Option {
+	return func(o *options) {
+		o.history = true
+	}
+}
+
+// Height returns the height of the last Commit, 0 before the first one.
+func (s *UniswapV2) Height() uint64 {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	return s.height
+}
+
+// PairAt returns the reserves, in the order of coinA and coinB, and the total
+// supply of the default tier pair as committed at height, when the service
+// runs WithHistory, failing with ErrorPairNotExists if the pair was not
+// created yet or removed by then.
+func (s *UniswapV2) PairAt(coinA, coinB Token, height uint64) (reserve0, reserve1, totalSupply *big.Int, err error) {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	if !s.history || height == 0 || height > s.height || height < s.prunedBelow {
+		return nil, nil, nil, ErrorUnknownHeight
+	}
+	key := pairKey{TokenA: coinA, TokenB: coinB}
+	versions := s.versions[key.sort()]
+	i := sort.Search(len(versions), func(i int) bool { return versions[i].height > height })
+	if i == 0 || versions[i-1].removed {
+		return nil, nil, nil, ErrorPairNotExists
+	}
+	version := versions[i-1]
+	reserve0, reserve1 = new(big.Int).Set(version.reserve0), new(big.Int).Set(version.reserve1)
+	if !key.isSorted() {
+		reserve0, reserve1 = reserve1, reserve0
+	}
+	return reserve0, reserve1, new(big.Int).Set(version.totalSupply), nil
+}
+
+// PruneHistory drops what PairAt needs only for heights below height.
+func (s *UniswapV2) PruneHistory(height uint64) {
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+
+	if height <= s.prunedBelow {
+		return
+	}
+	s.prunedBelow = height
+	for key, versions := range s.versions {
+		// keep the version in force at height, unless it is a removal
+		i := sort.Search(len(versions), func(i int) bool { return versions[i].height > height })
+		if i > 0 && versions[i-1].removed {
+			i++
+		}
+		switch {
+		case i > len(versions):
+			delete(s.versions, key)
+		case i > 1:
+			s.versions[key] = append(versions[:0:0], versions[i-1:]...)
+		}
+	}
+}
+
+// recordHistory starts height with the committed canonical pairs and the
+// removal of the committed ones removed. The caller holds the muPairs write
+// lock.
+func (s *UniswapV2) recordHistory(removed []pairKey, committed []*Pair, height uint64) {
+	s.height = height
+	if !s.history {
+		return
+	}
+	for _, key := range removed {
+		if len(s.versions[key]) != 0 {
+			s.versions[key] = append(s.versions[key], pairVersion{height: height, removed: true})
+		}
+	}
+	for _, pair := range committed {
+		pair.pairData.RLock()
+		version := pairVersion{
+			height:      s.height,
+			reserve0:    new(big.Int).Set(pair.reserve0),
+			reserve1:    new(big.Int).Set(pair.reserve1),
+			totalSupply: new(big.Int).Set(pair.totalSupply),
+		}
+		pair.pairData.RUnlock()
+		s.versions[pair.key] = append(s.versions[pair.key], version)
+	}
+}
@@ -0,0 +1,118 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_PairAt(t *testing.T) {
+	service := New(WithHistory())
+	writer := &recordingWriter{}
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CreatePair(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(3e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	if service.Height() != 3 {
+		t.Fatalf("height want 3, got %d", service.Height())
+	}
+
+	tests := []struct {
+		height             uint64
+		reserve0, reserve1 *big.Int
+	}{
+		{1, big.NewInt(1e18), big.NewInt(4e18)},
+		{2, big.NewInt(1e18), big.NewInt(4e18)},
+		{3, big.NewInt(1e18 + 1e16), big.NewInt(4e18 - 3e16)},
+	}
+	for _, test := range tests {
+		reserve0, reserve1, totalSupply, err := service.PairAt(1, 0, test.height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reserve0.Cmp(test.reserve0) != 0 || reserve1.Cmp(test.reserve1) != 0 {
+			t.Errorf("reserves at %d want %s/%s, got %s/%s", test.height, test.reserve0, test.reserve1, reserve0, reserve1)
+		}
+		if totalSupply.Cmp(big.NewInt(2e18)) != 0 {
+			t.Errorf("total supply at %d want 2e18, got %s", test.height, totalSupply)
+		}
+	}
+
+	if _, _, _, err := service.PairAt(2, 3, 1); err != ErrorPairNotExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+	if _, _, _, err := service.PairAt(0, 1, 4); err != ErrorUnknownHeight {
+		t.Fatalf("failed with %v; want error %v", err, ErrorUnknownHeight)
+	}
+
+	service.PruneHistory(2)
+	if _, _, _, err := service.PairAt(0, 1, 1); err != ErrorUnknownHeight {
+		t.Fatalf("failed with %v; want error %v", err, ErrorUnknownHeight)
+	}
+	if reserve0, _, _, err := service.PairAt(1, 0, 2); err != nil || reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserve0 at 2 want 1e18, got %s, %v", reserve0, err)
+	}
+	if len(service.versions[pairKey{TokenA: 0, TokenB: 1}]) != 2 {
+		t.Errorf("versions want 2, got %d", len(service.versions[pairKey{TokenA: 0, TokenB: 1}]))
+	}
+
+	if _, _, _, err := New().PairAt(0, 1, 1); err != ErrorUnknownHeight {
+		t.Fatalf("failed with %v; want error %v", err, ErrorUnknownHeight)
+	}
+}
+
+func TestUniswapV2_PairAt_removed(t *testing.T) {
+	service := New(WithHistory())
+	writer := &recordingWriter{}
+	if _, err := service.CreatePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RemovePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CreatePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(writer); err != nil {
+		t.Fatal(err)
+	}
+
+	for height, want := range map[uint64]error{1: nil, 2: ErrorPairNotExists, 3: nil} {
+		if _, _, _, err := service.PairAt(0, 1, height); err != want {
+			t.Errorf("at %d failed with %v; want error %v", height, err, want)
+		}
+	}
+
+	service.PruneHistory(2)
+	if _, _, _, err := service.PairAt(0, 1, 2); err != ErrorPairNotExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+	if versions := service.versions[pairKey{TokenA: 0, TokenB: 1}]; len(versions) != 1 || versions[0].height != 3 {
+		t.Errorf("versions want the one of height 3, got %v", versions)
+	}
+}
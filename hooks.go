@@ -0,0 +1,106 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+)
+
+// SwapHook is called by Swap with the requested amounts before the K check.
+// It returns the part of each input that the pair does not receive, e.g.
+// a transfer tax of the token, so that reserves only grow by what actually
+// arrived. Hooks apply to every fee tier of the token pair.
+type SwapHook func(amount0In, amount1In, amount0Out, amount1Out *big.Int) (tax0, tax1 *big.Int, err error)
+
+var (
+	ErrorInvalidTax = errors.New("INVALID_TAX")
+)
+
+func (s *UniswapV2) AddSwapHook(coinA, coinB Token, hook SwapHook) {
+	key := pairKey{TokenA: coinA, TokenB: coinB}
+	if !key.isSorted() {
+		hook = hook.revert()
+	}
+
+	s.muHooks.Lock()
+	defer s.muHooks.Unlock()
+
+	s.swapHooks[key.sort()] = append(s.swapHooks[key.sort()], hook)
+}
+
+func (h SwapHook) revert() SwapHook {
+	return func(amount0In, amount1In, amount0Out, amount1Out *big.Int) (tax0, tax1 *big.Int, err error) {
+		tax1, tax0, err = h(amount1In, amount0In, amount1Out, amount0Out)
+		return tax0, tax1, err
+	}
+}
+
+func (p *Pair) swapHooks() []SwapHook {
+	p.service.muHooks.RLock()
+	defer p.service.muHooks.RUnlock()
+
+	hooks := p.service.swapHooks[p.key.sort().tokens()]
+	if p.key.isSorted() {
+		return hooks
+	}
+	reverted := make([]SwapHook, 0, len(hooks))
+	for _, hook := range hooks {
+		reverted = append(reverted, hook.revert())
+	}
+	return reverted
+}
+
+func (p *Pair) swapTax(amount0In, amount1In, amount0Out, amount1Out *big.Int) (tax0, tax1 *big.Int, err error) {
+	tax0, tax1 = big.NewInt(0), big.NewInt(0)
+	for _, hook := range p.swapHooks() {
+		hookTax0, hookTax1, err := hook(new(big.Int).Set(amount0In), new(big.Int).Set(amount1In), new(big.Int).Set(amount0Out), new(big.Int).Set(amount1Out))
+		if err != nil {
+			return nil, nil, err
+		}
+		if hookTax0 != nil {
+			tax0.Add(tax0, hookTax0)
+		}
+		if hookTax1 != nil {
+			tax1.Add(tax1, hookTax1)
+		}
+	}
+
+	if tax0.Sign() == -1 || tax1.Sign() == -1 || tax0.Cmp(amount0In) == 1 || tax1.Cmp(amount1In) == 1 {
+		return nil, nil, ErrorInvalidTax
+	}
+	return tax0, tax1, nil
+}
+
+// Hooks are called around the operations of every pair of the service they
+// are added to, for policies such as limits or logging. Amounts are in the
+// token order of the pair view and must not be modified. An error of a
+// Before hook aborts the operation before anything is changed; After hooks
+// run once it succeeded. Nil hooks are skipped. Mint, Burn, Swap and Sync
+// hooks run while the operation holds the pair: they may start another one
+// on it through the pair they get, not through another view of it.
+type Hooks struct {
+	BeforeMint func(pair *Pair, address Address, amount0, amount1 *big.Int) error
+	AfterMint  func(pair *Pair, address Address, amount0, amount1, liquidity *big.Int)
+	BeforeBurn func(pair *Pair, address Address, liquidity *big.Int) error
+	AfterBurn  func(pair *Pair, address Address, liquidity, amount0, amount1 *big.Int)
+	// BeforeSwap gets the requested amounts, AfterSwap the amounts net of
+	// swap taxes and flash swap repayments included.
+	BeforeSwap func(pair *Pair, amounts SwapAmounts) error
+	AfterSwap  func(pair *Pair, amounts SwapAmounts)
+	BeforeSync func(pair *Pair, balance0, balance1 *big.Int) error
+	AfterSync  func(pair *Pair, balance0, balance1 *big.Int)
+}
+
+// AddHooks registers hooks for all pairs, run after those added before.
+func (s *UniswapV2) AddHooks(hooks Hooks) {
+	s.muHooks.Lock()
+	defer s.muHooks.Unlock()
+
+	s.hooks = append(s.hooks, hooks)
+}
+
+func (s *UniswapV2) operationHooks() []Hooks {
+	s.muHooks.RLock()
+	defer s.muHooks.RUnlock()
+
+	return s.hooks
+}
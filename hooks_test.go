@@ -0,0 +1,108 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_AddSwapHook(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(5e18), big.NewInt(5e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// token 1 burns 10% of every transfer into the pair
+	service.AddSwapHook(1, 0, func(amount0In, amount1In, amount0Out, amount1Out *big.Int) (tax0, tax1 *big.Int, err error) {
+		return new(big.Int).Div(amount0In, big.NewInt(10)), big.NewInt(0), nil
+	})
+
+	expectedOutputAmount := big.NewInt(760771878656334254)
+	_, _, err = pair.Swap(big.NewInt(0), big.NewInt(1e18), new(big.Int).Add(expectedOutputAmount, big.NewInt(1)), big.NewInt(0))
+	if !errors.Is(err, ErrorK) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorK)
+	}
+
+	amount0, amount1, err := pair.Swap(big.NewInt(0), big.NewInt(1e18), expectedOutputAmount, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount1.Cmp(big.NewInt(9e17)) != 0 {
+		t.Errorf("amount1 want %s, got %s", big.NewInt(9e17), amount1)
+	}
+	if amount0.Cmp(new(big.Int).Neg(expectedOutputAmount)) != 0 {
+		t.Errorf("amount0 want %s, got %s", new(big.Int).Neg(expectedOutputAmount), amount0)
+	}
+
+	_, reserve1 := pair.Reserves()
+	if reserve1.Cmp(big.NewInt(59e17)) != 0 {
+		t.Errorf("reserve1 want %s, got %s", big.NewInt(59e17), reserve1)
+	}
+
+	service.AddSwapHook(0, 1, func(amount0In, amount1In, amount0Out, amount1Out *big.Int) (tax0, tax1 *big.Int, err error) {
+		return nil, amount1In, nil
+	})
+	_, _, err = pair.Swap(big.NewInt(0), big.NewInt(1e18), big.NewInt(1), big.NewInt(0))
+	if !errors.Is(err, ErrorInvalidTax) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidTax)
+	}
+}
+
+func TestUniswapV2_AddHooks(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errorLimit := errors.New("LIMIT")
+	var log []string
+	service.AddHooks(Hooks{
+		BeforeSwap: func(pair *Pair, amounts SwapAmounts) error {
+			if amounts.Amount0Out.Cmp(big.NewInt(1e17)) == 1 {
+				return errorLimit
+			}
+			return nil
+		},
+		AfterMint: func(pair *Pair, address Address, amount0, amount1, liquidity *big.Int) {
+			log = append(log, "mint "+string(address)+" "+amount0.String())
+		},
+	})
+	service.AddHooks(Hooks{
+		AfterSwap: func(pair *Pair, amounts SwapAmounts) {
+			log = append(log, "swap "+amounts.Amount1In.String())
+		},
+		BeforeBurn: func(pair *Pair, address Address, liquidity *big.Int) error {
+			return errorLimit
+		},
+	})
+
+	_, err = service.Pair(1, 0).Mint("alice", big.NewInt(2e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(0), big.NewInt(1e18), big.NewInt(2e17), big.NewInt(0))
+	if !errors.Is(err, errorLimit) {
+		t.Fatalf("failed with %v; want error %v", err, errorLimit)
+	}
+	_, _, err = pair.Swap(big.NewInt(0), big.NewInt(1e17), big.NewInt(1e16), big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Burn("alice", big.NewInt(1))
+	if !errors.Is(err, errorLimit) {
+		t.Fatalf("failed with %v; want error %v", err, errorLimit)
+	}
+
+	if len(log) != 2 || log[0] != "mint alice 2000000000000000000" || log[1] != "swap 100000000000000000" {
+		t.Errorf("log want mint and swap, got %v", log)
+	}
+	if reserve0, _ := pair.Reserves(); reserve0.Cmp(new(big.Int).Sub(big.NewInt(1e18), big.NewInt(1e16))) != 0 {
+		t.Errorf("reserve0 want %d, got %s", int64(1e18-1e16), reserve0)
+	}
+}
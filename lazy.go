@@ -0,0 +1,267 @@
+package uniswapV2
+
+import (
+	"container/list"
+	"sort"
+)
+
+// lazyPairs keeps the pairs of a storage in memory on demand, evicting the
+// least recently used clean ones.
+type lazyPairs struct {
+	storage   Storage
+	maxLoaded int
+	recent    *list.List
+	elements  map[pairKey]*list.Element
+}
+
+// WithLazyStorage loads pairs from storage, as written by Commit with
+// NewStorageWriter, when Pair or PairWithFee asks for them, keeping at most
+// maxLoaded of them in memory. Once there are more, the least recently asked
+// for pairs without uncommitted changes are dropped, unless a Snapshot is
+// open or DeliverOp runs an operation, so a *Pair must not be kept but
+// asked for again before every use.
+// A dropped pair stays known to Pairs, PositionsOf, PairsByToken and path
+// search, which quote it from storage; pairs never asked for are unknown to
+// them. StateRoot and the exports read the pairs not in memory from storage.
+func WithLazyStorage(storage Storage, maxLoaded int) Option {
+	return func(o *options) {
+		o.lazy = &lazyPairs{storage: storage, maxLoaded: maxLoaded, recent: list.New(), elements: map[pairKey]*list.Element{}}
+	}
+}
+
+// loadPair is pair that loads the pair from the lazy storage if needed,
+// failing with the error of the storage. The caller holds the muPairs write
+// lock.
+func (s *UniswapV2) loadPair(key pairKey) (*Pair, bool, error) {
+	pair, ok, err := s.fetchPair(key)
+	if ok && s.lazy != nil {
+		s.evictPairs()
+	}
+	return pair, ok, err
+}
+
+// fetchPair is loadPair without evicting other pairs.
+func (s *UniswapV2) fetchPair(key pairKey) (*Pair, bool, error) {
+	pair, ok := s.pair(key)
+	if s.lazy == nil {
+		return pair, ok, nil
+	}
+	if ok {
+		s.lazy.use(key.sort())
+		return pair, true, nil
+	}
+
+	canonical := key.sort()
+	if _, removed := s.committed[canonical]; removed {
+		// removed since the last commit, still in storage until then
+		return nil, false, nil
+	}
+	loaded, metadata, ok, err := s.storedPair(canonical)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	pair = s.addStoredPair(loaded, metadata)
+	s.markCommitted([]*Pair{pair})
+	s.snapshotLoaded(pair)
+	s.lazy.use(canonical)
+	pair, _ = s.pair(key)
+	return pair, true, nil
+}
+
+// addStoredPair adds a canonical pair read from storage, with its metadata,
+// indexing it unless it is already, as a pair dropped by evictPairs is. The
+// caller holds the muPairs write lock.
+func (s *UniswapV2) addStoredPair(stored *Pair, metadata map[string]string) *Pair {
+	key := stored.key
+	indexed := false
+	for _, fee := range s.tiers[key.tokens()] {
+		indexed = indexed || fee == key.Fee
+	}
+	pair := s.addPair(key, stored.pairData, stored.balances, stored.fee)
+	pair.allowances, pair.nonces = stored.allowances, stored.nonces
+	if !indexed {
+		s.addKeyPair(key)
+		for address, balance := range pair.balances {
+			s.updatePosition(address, key, balance)
+		}
+	}
+	if len(metadata) != 0 {
+		s.muMetadata.Lock()
+		s.metadata[key] = metadata
+		s.muMetadata.Unlock()
+	}
+	return pair
+}
+
+// storedPair reads a canonical pair, its balances, allowances, nonces and
+// metadata from the lazy storage, ok being false if there is none.
+func (s *UniswapV2) storedPair(key pairKey) (pair *Pair, metadata map[string]string, ok bool, err error) {
+	storageKey := storagePairKey(key)
+	value, ok, err := s.lazy.storage.Get(storageKey)
+	if err != nil || !ok {
+		return nil, nil, false, err
+	}
+	pair, metadata, err = storagePair(storageKey, value)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	err = s.lazy.storage.Iterate(storageKey, func(key, value []byte) error {
+		if len(key) == len(storageKey) {
+			return nil
+		}
+		return readStorageEntry(pair, key[len(storageKey):], value)
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return pair, metadata, true, nil
+}
+
+// viewPair is pair that reads a pair dropped by evictPairs from the lazy
+// storage without loading it, for quotes under the muPairs read lock. Such a
+// pair must not be changed, see attachPairs. Errors of the storage are
+// logged and the pair taken as missing.
+func (s *UniswapV2) viewPair(key pairKey) (*Pair, bool) {
+	pair, ok := s.pair(key)
+	if ok || s.lazy == nil {
+		return pair, ok
+	}
+	pair, _, ok, err := s.storedPair(key.sort())
+	if err != nil {
+		s.logStorageError(key, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	pair.service = s
+	if !key.isSorted() {
+		return pair.revert(), true
+	}
+	return pair, true
+}
+
+// attachPairs replaces the pairs read by viewPair with the loaded ones, for
+// them to be changed. Nothing is evicted until the next load, so that the
+// pairs of a path stay loaded while it is traded.
+func (s *UniswapV2) attachPairs(pairs []*Pair) error {
+	if s.lazy == nil {
+		return nil
+	}
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+
+	for i, pair := range pairs {
+		if loaded, ok := s.pairs[pair.key.sort()]; ok && loaded.dirty == pair.dirty {
+			s.lazy.use(pair.key.sort())
+			continue
+		}
+		loaded, ok, err := s.fetchPair(pair.key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrorPairNotExists
+		}
+		pairs[i] = loaded
+	}
+	return nil
+}
+
+// logStorageError logs a failure of the lazy storage to read the pair of
+// key.
+func (s *UniswapV2) logStorageError(key pairKey, err error) {
+	if s.logs(LogError) {
+		key = key.sort()
+		s.logger.Log(LogError, "lazy storage", LogField{"token0", key.TokenA}, LogField{"token1", key.TokenB}, LogField{"fee_tier", key.Fee}, LogField{"error", err.Error()})
+	}
+}
+
+// statePairs returns every pair of the state in canonical key order: those
+// in memory and, with WithLazyStorage, the others as read from the lazy
+// storage, which must not be changed. The caller holds the muPairs lock.
+func (s *UniswapV2) statePairs() ([]*Pair, error) {
+	keys := s.sortedKeys()
+	pairs := make([]*Pair, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, s.pairs[key])
+	}
+	if s.lazy == nil {
+		return pairs, nil
+	}
+
+	stored, metadata, err := readStorage(s.lazy.storage)
+	if err != nil {
+		return nil, err
+	}
+	// the stored pairs are not known to s, so their metadata is kept by a
+	// service of their own
+	detached := &UniswapV2{metadata: map[pairKey]map[string]string{}}
+	for i, pair := range stored {
+		if _, ok := s.pairs[pair.key]; ok {
+			continue
+		}
+		if _, removed := s.committed[pair.key]; removed {
+			continue
+		}
+		pair.service = detached
+		detached.metadata[pair.key] = metadata[i]
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairKeyLess(pairs[i].key, pairs[j].key) })
+	return pairs, nil
+}
+
+// use marks the canonical key as the most recently used.
+func (l *lazyPairs) use(key pairKey) {
+	if element, ok := l.elements[key]; ok {
+		l.recent.MoveToFront(element)
+		return
+	}
+	l.elements[key] = l.recent.PushFront(key)
+}
+
+// inStorage reports whether a pair not in memory exists in the lazy storage
+// and was not removed since the last commit.
+func (s *UniswapV2) inStorage(key pairKey) (bool, error) {
+	if s.lazy == nil {
+		return false, nil
+	}
+	if _, removed := s.committed[key.sort()]; removed {
+		return false, nil
+	}
+	_, ok, err := s.lazy.storage.Get(storagePairKey(key.sort()))
+	return ok, err
+}
+
+// evictPairs drops the least recently used committed pairs over the limit,
+// keeping them in the indices of pairs, positions and routes. Nothing is
+// dropped while a snapshot is open, for Revert to find the pairs it saved
+// or that were loaded since, or while DeliverOp runs an operation.
+func (s *UniswapV2) evictPairs() {
+	if s.snapshotsOpen() || s.opOpen() {
+		return
+	}
+	element := s.lazy.recent.Back()
+	for s.lazy.recent.Len() > s.lazy.maxLoaded && element != nil {
+		key := element.Value.(pairKey)
+		previous := element.Prev()
+		pair, loaded := s.pairs[key]
+		_, committed := s.committed[key]
+		if !loaded {
+			// removed by Discard
+			s.lazy.recent.Remove(element)
+			delete(s.lazy.elements, key)
+		} else if committed && !pair.changed() {
+			delete(s.pairs, key)
+			s.muMetadata.Lock()
+			delete(s.metadata, key)
+			s.muMetadata.Unlock()
+			delete(s.committed, key)
+			delete(s.committedMetadata, key)
+			s.lazy.recent.Remove(element)
+			delete(s.lazy.elements, key)
+		}
+		element = previous
+	}
+}
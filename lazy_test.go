@@ -0,0 +1,243 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_WithLazyStorage(t *testing.T) {
+	storage := NewMemoryStorage()
+	service := New()
+	for _, tokens := range [][2]Token{{0, 1}, {2, 3}, {4, 5}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	lazy := New(WithLazyStorage(storage, 2))
+	if len(lazy.pairs) != 0 {
+		t.Fatalf("pairs want 0, got %d", len(lazy.pairs))
+	}
+	pair := lazy.Pair(1, 0)
+	if pair == nil {
+		t.Fatal("pair is not loaded")
+	}
+	if reserve0, _ := pair.Reserves(); reserve0.Cmp(big.NewInt(4e18)) != 0 {
+		t.Errorf("reserve0 want 4e18, got %s", reserve0)
+	}
+	if balance := pair.Balance("alice"); balance.Cmp(big.NewInt(2e18-1000)) != 0 {
+		t.Errorf("balance want %d, got %s", int64(2e18-1000), balance)
+	}
+	if _, err := lazy.CreatePair(1, 0); err != ErrorPairExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairExists)
+	}
+	if lazy.Pair(6, 7) != nil {
+		t.Error("unknown pair is loaded")
+	}
+
+	_, _, err := lazy.Pair(2, 3).Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(3e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy.Pair(4, 5)
+	if _, ok := lazy.pairs[pairKey{TokenA: 0, TokenB: 1}]; ok {
+		t.Error("least recently used pair is not evicted")
+	}
+	lazy.Pair(0, 1)
+	if _, ok := lazy.pairs[pairKey{TokenA: 2, TokenB: 3}]; !ok {
+		t.Error("changed pair is evicted")
+	}
+	if _, ok := lazy.pairs[pairKey{TokenA: 4, TokenB: 5}]; ok || len(lazy.pairs) != 2 {
+		t.Errorf("pairs want 0/1 and 2/3, got %d", len(lazy.pairs))
+	}
+
+	if err := lazy.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	lazy.Pair(4, 5)
+	if _, ok := lazy.pairs[pairKey{TokenA: 2, TokenB: 3}]; ok {
+		t.Error("committed pair is not evicted")
+	}
+	reserve0, _ := lazy.Pair(2, 3).Reserves()
+	if reserve0.Cmp(big.NewInt(1e18+1e16)) != 0 {
+		t.Errorf("reserve0 want %d, got %s", int64(1e18+1e16), reserve0)
+	}
+
+	var buf bytes.Buffer
+	if err := lazy.DumpPair(0, 1, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lazy.pairs[pairKey{TokenA: 0, TokenB: 1}]; !ok {
+		t.Error("dumped pair is not loaded")
+	}
+}
+
+func TestUniswapV2_WithLazyStorage_evicted(t *testing.T) {
+	storage := NewMemoryStorage()
+	service := New()
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}, {2, 3}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	lazy := New(WithLazyStorage(storage, 1))
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}, {2, 3}} {
+		if lazy.Pair(tokens[0], tokens[1]) == nil {
+			t.Fatalf("pair %d/%d is not loaded", tokens[0], tokens[1])
+		}
+	}
+	if len(lazy.pairs) != 1 {
+		t.Fatalf("pairs want 1, got %d", len(lazy.pairs))
+	}
+	if keys, _ := lazy.Pairs(); len(keys) != 3 {
+		t.Errorf("pairs want 3, got %v", keys)
+	}
+	if keys := lazy.PositionsOf("alice"); len(keys) != 3 {
+		t.Errorf("positions want 3, got %v", keys)
+	}
+	if keys := lazy.PairsByToken(1); len(keys) != 2 {
+		t.Errorf("pairs of token 1 want 2, got %v", keys)
+	}
+
+	wantRoot, want := stateRoot(t, service), marshal(t, service)
+	if root := stateRoot(t, lazy); root != wantRoot {
+		t.Error("state root differs from the stored state")
+	}
+	if !bytes.Equal(marshal(t, lazy), want) {
+		t.Error("encoding differs from the stored state")
+	}
+
+	path, amountOut, err := lazy.FindBestPath(0, 3, big.NewInt(1e16), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 4 {
+		t.Fatalf("path want 0/1/2/3, got %v", path)
+	}
+	amounts, err := NewRouter(lazy).Swap(big.NewInt(1e16), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amounts[3].Cmp(amountOut) != 0 {
+		t.Errorf("amount out want %s, got %s", amountOut, amounts[3])
+	}
+	if _, err := NewRouter(service).Swap(big.NewInt(1e16), path); err != nil {
+		t.Fatal(err)
+	}
+	if root := stateRoot(t, lazy); root != stateRoot(t, service) {
+		t.Error("state root differs after the swap")
+	}
+	if err := lazy.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	reserve0, _ := lazy.Pair(0, 1).Reserves()
+	if reserve0.Cmp(big.NewInt(1e18+1e16)) != 0 {
+		t.Errorf("reserve0 want %d, got %s", int64(1e18+1e16), reserve0)
+	}
+}
+
+var errStorage = errors.New("storage failed")
+
+type failingStorage struct {
+	*MemoryStorage
+}
+
+func (failingStorage) Get([]byte) ([]byte, bool, error) {
+	return nil, false, errStorage
+}
+
+func TestUniswapV2_WithLazyStorage_storageError(t *testing.T) {
+	storage := NewMemoryStorage()
+	service := New()
+	if _, err := service.CreatePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	lazy := New(WithLazyStorage(failingStorage{storage}, 1))
+	if err := lazy.DumpPair(0, 1, &bytes.Buffer{}); err != errStorage {
+		t.Fatalf("failed with %v; want error %v", err, errStorage)
+	}
+	if _, err := lazy.CreatePair(0, 1); err != errStorage {
+		t.Fatalf("failed with %v; want error %v", err, errStorage)
+	}
+	if err := lazy.RemovePair(0, 1); err != errStorage {
+		t.Fatalf("failed with %v; want error %v", err, errStorage)
+	}
+	if lazy.Pair(0, 1) != nil {
+		t.Error("pair is loaded")
+	}
+}
+
+func TestUniswapV2_WithLazyStorage_revert(t *testing.T) {
+	storage := NewMemoryStorage()
+	service := New()
+	for _, tokens := range [][2]Token{{0, 1}, {2, 3}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	root := stateRoot(t, service)
+
+	lazy := New(WithLazyStorage(storage, 1))
+	id := lazy.Snapshot()
+	_, _, err := lazy.Pair(0, 1).Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(3e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lazy.Pair(2, 3)
+	if _, ok := lazy.pairs[pairKey{TokenA: 0, TokenB: 1}]; !ok {
+		t.Error("pair is evicted while a snapshot is open")
+	}
+	if err := lazy.Revert(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := lazy.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := storage.Get(storagePairKey(pairKey{TokenA: 0, TokenB: 1})); !ok {
+		t.Fatal("pair 0/1 is deleted from storage")
+	}
+	if reserve0, _ := lazy.Pair(0, 1).Reserves(); reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserve0 want 1e18, got %s", reserve0)
+	}
+	if got := stateRoot(t, lazy); got != root {
+		t.Error("state root differs after the revert")
+	}
+
+	reloaded := New(WithLazyStorage(storage, 1))
+	if reserve0, _ := reloaded.Pair(0, 1).Reserves(); reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("stored reserve0 want 1e18, got %s", reserve0)
+	}
+	if got := stateRoot(t, reloaded); got != root {
+		t.Error("stored state root differs after the revert")
+	}
+}
@@ -0,0 +1,106 @@
+package uniswapV2
+
+import (
+	"math/big"
+)
+
+type LogLevel int
+
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarn:
+		return "warn"
+	case LogError:
+		return "error"
+	}
+	return "unknown"
+}
+
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives the log messages of the service it is passed to with
+// WithLogger. It may be called with locks of the service held and must not
+// call back into it.
+type Logger interface {
+	Log(level LogLevel, msg string, fields ...LogField)
+}
+
+// LoggerFunc adapts a function to Logger.
+type LoggerFunc func(level LogLevel, msg string, fields ...LogField)
+
+func (f LoggerFunc) Log(level LogLevel, msg string, fields ...LogField) {
+	f(level, msg, fields...)
+}
+
+// largeSwapShare is the share of a reserve, in percent, above which the
+// output of a swap is logged as a large swap.
+const largeSwapShare = 10
+
+// WithLogger logs the operations of the service at level and above:
+// pair creation at LogInfo, mints, burns and swaps at LogDebug, swaps taking
+// more than a tenth of a reserve at LogInfo and swaps failing the K check
+// at LogWarn. Pairs and amounts are logged in canonical token order.
+func WithLogger(logger Logger, level LogLevel) Option {
+	return func(o *options) {
+		o.logger, o.logLevel = logger, level
+	}
+}
+
+func (s *UniswapV2) logs(level LogLevel) bool {
+	return s.logger != nil && level >= s.logLevel
+}
+
+// log logs msg with the pair and address fields followed by fields.
+func (p *Pair) log(level LogLevel, msg string, address Address, fields ...LogField) {
+	if !p.service.logs(level) {
+		return
+	}
+	pairFields := p.fields()
+	if address != addressZero {
+		pairFields = append(pairFields, LogField{"address", address})
+	}
+	fields, logger := append(pairFields, fields...), p.service.logger
+	p.deliver(func() { logger.Log(level, msg, fields...) })
+}
+
+// fields returns the canonical key of the pair as the fields token0, token1
+// and fee_tier.
+func (p *Pair) fields() []LogField {
+	key := p.key.sort()
+	return []LogField{{"token0", key.TokenA}, {"token1", key.TokenB}, {"fee_tier", key.Fee}}
+}
+
+// amountFields returns view amounts as the fields <name>0 and <name>1 in
+// canonical order.
+func (p *Pair) amountFields(name string, amount0, amount1 *big.Int) []LogField {
+	if !p.key.isSorted() {
+		amount0, amount1 = amount1, amount0
+	}
+	return []LogField{{name + "0", amount0.String()}, {name + "1", amount1.String()}}
+}
+
+// largeSwap reports whether an output takes more than largeSwapShare percent
+// of its reserve.
+func largeSwap(reserve0, reserve1, amount0Out, amount1Out *big.Int) bool {
+	for _, amount := range [][2]*big.Int{{reserve0, amount0Out}, {reserve1, amount1Out}} {
+		share := new(big.Int).Mul(amount[1], big.NewInt(100))
+		if share.Cmp(new(big.Int).Mul(amount[0], big.NewInt(largeSwapShare))) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+type logRecord struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+func TestWithLogger(t *testing.T) {
+	var records []logRecord
+	logger := LoggerFunc(func(level LogLevel, msg string, fields ...LogField) {
+		record := logRecord{level: level, msg: msg, fields: map[string]interface{}{}}
+		for _, field := range fields {
+			record.fields[field.Key] = field.Value
+		}
+		records = append(records, record)
+	})
+	service := New(WithLogger(logger, LogInfo))
+
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(1e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(5e17), big.NewInt(0), big.NewInt(0), big.NewInt(5e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if !errors.Is(err, ErrorK) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorK)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("records want 3, got %v", records)
+	}
+	created, swap, failed := records[0], records[1], records[2]
+	if created.level != LogInfo || created.msg != "pair created" || created.fields["token0"] != Token(0) || created.fields["token1"] != Token(1) {
+		t.Errorf("first record want pair created, got %+v", created)
+	}
+	if swap.level != LogInfo || swap.msg != "swap" || swap.fields["amount_in1"] != "500000000000000000" || swap.fields["amount_out0"] != "500000000000000000" {
+		t.Errorf("second record want large swap, got %+v", swap)
+	}
+	if failed.level != LogWarn || failed.msg != "swap failed K check" {
+		t.Errorf("third record want K violation, got %+v", failed)
+	}
+}
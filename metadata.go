@@ -0,0 +1,75 @@
+package uniswapV2
+
+import "sort"
+
+// SetMetadata attaches a string value to the pair under key, shared by all
+// views of the pair and included in dumps. An empty value deletes the key.
+func (p *Pair) SetMetadata(key, value string) {
+	p.saveForOp()
+	p.pairData.Lock()
+	p.isDirty = true
+	p.pairData.Unlock()
+
+	s := p.service
+	s.muMetadata.Lock()
+	defer s.muMetadata.Unlock()
+
+	pk := p.key.sort()
+	if value == "" {
+		delete(s.metadata[pk], key)
+		if len(s.metadata[pk]) == 0 {
+			delete(s.metadata, pk)
+		}
+		return
+	}
+	if s.metadata[pk] == nil {
+		s.metadata[pk] = map[string]string{}
+	}
+	s.metadata[pk][key] = value
+}
+
+func (p *Pair) Metadata(key string) (value string, ok bool) {
+	p.service.muMetadata.RLock()
+	defer p.service.muMetadata.RUnlock()
+
+	value, ok = p.service.metadata[p.key.sort()][key]
+	return value, ok
+}
+
+// MetadataKeys returns the metadata keys of the pair in sorted order.
+func (p *Pair) MetadataKeys() []string {
+	p.service.muMetadata.RLock()
+	defer p.service.muMetadata.RUnlock()
+
+	metadata := p.service.metadata[p.key.sort()]
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (p *Pair) metadata() map[string]string {
+	p.service.muMetadata.RLock()
+	defer p.service.muMetadata.RUnlock()
+
+	metadata := make(map[string]string, len(p.service.metadata[p.key.sort()]))
+	for key, value := range p.service.metadata[p.key.sort()] {
+		metadata[key] = value
+	}
+	return metadata
+}
+
+// setMetadata replaces the metadata of the pair with metadata.
+func (p *Pair) setMetadata(metadata map[string]string) {
+	s := p.service
+	s.muMetadata.Lock()
+	defer s.muMetadata.Unlock()
+
+	if len(metadata) == 0 {
+		delete(s.metadata, p.key.sort())
+		return
+	}
+	s.metadata[p.key.sort()] = metadata
+}
@@ -0,0 +1,49 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPair_Metadata(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair.SetMetadata("listed", "true")
+	pair.SetMetadata("risk_tier", "2")
+	pair.SetMetadata("ui_order", "5")
+	pair.SetMetadata("ui_order", "")
+
+	if value, ok := service.Pair(0, 1).Metadata("risk_tier"); !ok || value != "2" {
+		t.Errorf("risk_tier want %q, got %q", "2", value)
+	}
+	if _, ok := pair.Metadata("ui_order"); ok {
+		t.Error("ui_order want deleted")
+	}
+	if keys := pair.MetadataKeys(); !reflect.DeepEqual(keys, []string{"listed", "risk_tier"}) {
+		t.Errorf("keys want %v, got %v", []string{"listed", "risk_tier"}, keys)
+	}
+
+	clone := service.clone()
+	pair.SetMetadata("listed", "false")
+	if value, _ := clone.Pair(0, 1).Metadata("listed"); value != "true" {
+		t.Errorf("cloned listed want %q, got %q", "true", value)
+	}
+
+	var buf bytes.Buffer
+	if err := service.DumpPair(0, 1, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var dump pairDump
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"listed": "false", "risk_tier": "2"}; !reflect.DeepEqual(dump.Metadata, want) {
+		t.Errorf("dump metadata want %v, got %v", want, dump.Metadata)
+	}
+}
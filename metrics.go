@@ -0,0 +1,167 @@
+package uniswapV2
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+)
+
+type pairMetrics struct {
+	swaps, failedK   uint64
+	volume0, volume1 *big.Int
+}
+
+// Metrics counts the swaps of the service it is passed to with WithMetrics.
+// Together with the reserves and lock counters of the pairs, they are
+// written by WriteMetrics in the Prometheus text format.
+type Metrics struct {
+	mu    sync.Mutex
+	pairs map[pairKey]*pairMetrics
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{pairs: map[pairKey]*pairMetrics{}}
+}
+
+// WithMetrics counts the swaps of every pair of the service in metrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+func (m *Metrics) pair(key pairKey) *pairMetrics {
+	metrics, ok := m.pairs[key]
+	if !ok {
+		metrics = &pairMetrics{volume0: big.NewInt(0), volume1: big.NewInt(0)}
+		m.pairs[key] = metrics
+	}
+	return metrics
+}
+
+// swap records a swap of the view p with its inputs net of swap taxes.
+func (m *Metrics) swap(p *Pair, amount0In, amount1In *big.Int) {
+	if !p.key.isSorted() {
+		amount0In, amount1In = amount1In, amount0In
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metrics := m.pair(p.key.sort())
+	metrics.swaps++
+	metrics.volume0.Add(metrics.volume0, amount0In)
+	metrics.volume1.Add(metrics.volume1, amount1In)
+}
+
+func (m *Metrics) failK(p *Pair) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pair(p.key.sort()).failedK++
+}
+
+type metricSample struct {
+	key   pairKey
+	token string
+	value string
+}
+
+// WriteMetrics writes the metrics of the service in the Prometheus text
+// exposition format, every sample labelled with the pair:
+//
+//	uniswap_v2_swaps_total             successful swaps
+//	uniswap_v2_swap_failed_k_total     swaps rejected by the K check
+//	uniswap_v2_volume_total            swapped inputs by token
+//	uniswap_v2_reserve                 reserves by token
+//	uniswap_v2_lock_reads_total              read locks of the pair data
+//	uniswap_v2_lock_writes_total             write locks of the pair data
+//	uniswap_v2_lock_read_wait_seconds_total  time spent waiting for read locks
+//	uniswap_v2_lock_write_wait_seconds_total time spent waiting for write locks
+//
+// The swap metrics are only written with WithMetrics. Lock counters show
+// the busiest pairs, as HotPairs does, and lock waits the pairs contended
+// for.
+func (s *UniswapV2) WriteMetrics(w io.Writer) error {
+	s.muPairs.RLock()
+	keys := s.sortedKeys()
+	pairs := make([]*Pair, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, s.pairs[key])
+	}
+	s.muPairs.RUnlock()
+
+	var swaps, failedK, volume, reserves, reads, writes, readWaits, writeWaits []metricSample
+	if m := s.metrics; m != nil {
+		m.mu.Lock()
+		for _, key := range keys {
+			metrics, ok := m.pairs[key]
+			if !ok {
+				continue
+			}
+			swaps = append(swaps, metricSample{key: key, value: fmt.Sprint(metrics.swaps)})
+			failedK = append(failedK, metricSample{key: key, value: fmt.Sprint(metrics.failedK)})
+			volume = append(volume,
+				metricSample{key: key, token: fmt.Sprint(key.TokenA), value: metricValue(metrics.volume0)},
+				metricSample{key: key, token: fmt.Sprint(key.TokenB), value: metricValue(metrics.volume1)})
+		}
+		m.mu.Unlock()
+	}
+	for _, pair := range pairs {
+		reserve0, reserve1 := pair.Reserves()
+		access := pair.AccessStats()
+		reserves = append(reserves,
+			metricSample{key: pair.key, token: fmt.Sprint(pair.key.TokenA), value: metricValue(reserve0)},
+			metricSample{key: pair.key, token: fmt.Sprint(pair.key.TokenB), value: metricValue(reserve1)})
+		reads = append(reads, metricSample{key: pair.key, value: fmt.Sprint(access.Reads)})
+		writes = append(writes, metricSample{key: pair.key, value: fmt.Sprint(access.Writes)})
+		readWaits = append(readWaits, metricSample{key: pair.key, value: fmt.Sprint(access.ReadWait.Seconds())})
+		writeWaits = append(writeWaits, metricSample{key: pair.key, value: fmt.Sprint(access.WriteWait.Seconds())})
+	}
+
+	b := bufio.NewWriter(w)
+	for _, metric := range []struct {
+		name, kind, help string
+		samples          []metricSample
+	}{
+		{"uniswap_v2_swaps_total", "counter", "Successful swaps of the pair.", swaps},
+		{"uniswap_v2_swap_failed_k_total", "counter", "Swaps of the pair rejected by the K check.", failedK},
+		{"uniswap_v2_volume_total", "counter", "Swapped inputs of the pair, net of swap taxes.", volume},
+		{"uniswap_v2_reserve", "gauge", "Reserves of the pair.", reserves},
+		{"uniswap_v2_lock_reads_total", "counter", "Read locks of the pair data.", reads},
+		{"uniswap_v2_lock_writes_total", "counter", "Write locks of the pair data.", writes},
+		{"uniswap_v2_lock_read_wait_seconds_total", "counter", "Time spent waiting for read locks of the pair data.", readWaits},
+		{"uniswap_v2_lock_write_wait_seconds_total", "counter", "Time spent waiting for write locks of the pair data.", writeWaits},
+	} {
+		if len(metric.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		for _, sample := range metric.samples {
+			fmt.Fprintf(b, "%s{token0=\"%d\",token1=\"%d\",fee_tier=\"%d\"", metric.name, sample.key.TokenA, sample.key.TokenB, sample.key.Fee)
+			if sample.token != "" {
+				fmt.Fprintf(b, ",token=\"%s\"", sample.token)
+			}
+			fmt.Fprintf(b, "} %s\n", sample.value)
+		}
+	}
+	return b.Flush()
+}
+
+// metricValue formats an amount as a float, the only value type of the
+// format.
+func metricValue(v *big.Int) string {
+	f, _ := new(big.Float).SetInt(v).Float64()
+	return fmt.Sprint(f)
+}
+
+// NewMetricsHandler serves WriteMetrics of s to Prometheus scrapes.
+func NewMetricsHandler(s *UniswapV2) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.WriteMetrics(w)
+	})
+}
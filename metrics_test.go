@@ -0,0 +1,58 @@
+package uniswapV2
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUniswapV2_WriteMetrics(t *testing.T) {
+	service := New(WithMetrics(NewMetrics()))
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e18))
+	if !errors.Is(err, ErrorK) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorK)
+	}
+
+	recorder := httptest.NewRecorder()
+	NewMetricsHandler(service).ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE uniswap_v2_swaps_total counter",
+		`uniswap_v2_swaps_total{token0="0",token1="1",fee_tier="0"} 1`,
+		`uniswap_v2_swap_failed_k_total{token0="0",token1="1",fee_tier="0"} 1`,
+		`uniswap_v2_volume_total{token0="0",token1="1",fee_tier="0",token="1"} 1e+17`,
+		`uniswap_v2_volume_total{token0="0",token1="1",fee_tier="0",token="0"} 0`,
+		`uniswap_v2_reserve{token0="0",token1="1",fee_tier="0",token="1"} 1.1e+18`,
+		"# TYPE uniswap_v2_reserve gauge",
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("metrics want %q, got\n%s", line, body)
+		}
+	}
+
+	var text strings.Builder
+	if err := New().WriteMetrics(&text); err != nil {
+		t.Fatal(err)
+	}
+	if text.Len() != 0 {
+		t.Errorf("metrics want none, got\n%s", text.String())
+	}
+}
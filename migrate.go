@@ -0,0 +1,175 @@
+package uniswapV2
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	ErrorPairNotEmpty = errors.New("PAIR_NOT_EMPTY")
+)
+
+// MigrationSigner signs the digest of a migration report.
+type MigrationSigner func(digest []byte) (signature []byte, err error)
+
+type MigratedBalance struct {
+	Address   Address  `json:"address"`
+	Liquidity *big.Int `json:"liquidity"`
+}
+
+// MigrationReport records a pair moved from one fee tier to another, in
+// canonical token order. Signature is over Digest.
+type MigrationReport struct {
+	Token0      Token             `json:"token0"`
+	Token1      Token             `json:"token1"`
+	FromTier    uint32            `json:"from_tier"`
+	ToTier      uint32            `json:"to_tier"`
+	FromFee     Fee               `json:"from_fee"`
+	ToFee       Fee               `json:"to_fee"`
+	Reserve0    *big.Int          `json:"reserve0"`
+	Reserve1    *big.Int          `json:"reserve1"`
+	TotalSupply *big.Int          `json:"total_supply"`
+	Balances    []MigratedBalance `json:"balances"`
+	Signature   []byte            `json:"signature,omitempty"`
+}
+
+// Digest is the SHA-256 of the JSON encoding of the report without its signature.
+func (r *MigrationReport) Digest() []byte {
+	unsigned := *r
+	unsigned.Signature = nil
+	data, _ := json.Marshal(unsigned)
+	digest := sha256.Sum256(data)
+	return digest[:]
+}
+
+// MigratePair moves the reserves and every LP position of the fromTier pair
+// (zero for the CreatePair one) to the toTier pair, creating it if needed.
+// LP balances are carried over unchanged, so every provider keeps its share.
+// The target pair must hold no liquidity. With a nil signer the report is
+// left unsigned; a signer error aborts the migration. A service running
+// WithAdminApprovals migrates only approved AdminMigratePair actions.
+func (s *UniswapV2) MigratePair(coinA, coinB Token, fromTier, toTier uint32, signer MigrationSigner) (*MigrationReport, error) {
+	if s.approvals != nil {
+		return nil, ErrorApprovalRequired
+	}
+	return s.migratePair(coinA, coinB, fromTier, toTier, signer)
+}
+
+func (s *UniswapV2) migratePair(coinA, coinB Token, fromTier, toTier uint32, signer MigrationSigner) (*MigrationReport, error) {
+	if fromTier == toTier {
+		return nil, ErrorInvalidFee
+	}
+	key := pairKey{TokenA: coinA, TokenB: coinB}.sort()
+	from, to := key, key
+	from.Fee, to.Fee = fromTier, toTier
+
+	s.muPairs.Lock()
+	source, ok, err := s.fetchPair(from)
+	if err != nil {
+		s.muPairs.Unlock()
+		return nil, err
+	}
+	target, exists, err := s.loadPair(to)
+	s.muPairs.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrorPairNotExists
+	}
+	if !exists {
+		if _, err := s.CreatePairWithFee(key.TokenA, key.TokenB, toTier); err != nil && err != ErrorPairExists {
+			return nil, err
+		}
+		s.muPairs.Lock()
+		target, _, err = s.loadPair(to)
+		s.muPairs.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	source.saveForOp()
+	target.saveForOp()
+	report, err := s.migrate(source, target, signer)
+	if err != nil {
+		return nil, err
+	}
+	amounts := auditAmounts{"liquidity": report.TotalSupply.String(), "to_tier": fmt.Sprint(toTier)}
+	source.audit("migrate", addressZero, amounts.set(source, "", report.Reserve0, report.Reserve1))
+	return report, nil
+}
+
+func (s *UniswapV2) migrate(source, target *Pair, signer MigrationSigner) (*MigrationReport, error) {
+	key, from, to := source.key.tokens(), source.key, target.key
+	fromTier, toTier := from.Fee, to.Fee
+
+	// pairs of one token pair are locked in tier order
+	first, second := source, target
+	if toTier < fromTier {
+		first, second = target, source
+	}
+	first.pairData.Lock()
+	defer first.pairData.Unlock()
+	second.pairData.Lock()
+	defer second.pairData.Unlock()
+	source.muBalance.Lock()
+	defer source.muBalance.Unlock()
+	target.muBalance.Lock()
+	defer target.muBalance.Unlock()
+
+	if target.totalSupply.Sign() != 0 || target.reserve0.Sign() != 0 || target.reserve1.Sign() != 0 {
+		return nil, ErrorPairNotEmpty
+	}
+
+	report := &MigrationReport{
+		Token0:      key.TokenA,
+		Token1:      key.TokenB,
+		FromTier:    fromTier,
+		ToTier:      toTier,
+		FromFee:     source.fee,
+		ToFee:       target.fee,
+		Reserve0:    new(big.Int).Set(source.reserve0),
+		Reserve1:    new(big.Int).Set(source.reserve1),
+		TotalSupply: new(big.Int).Set(source.totalSupply),
+		Balances:    make([]MigratedBalance, 0, len(source.balances)),
+	}
+	for _, address := range source.addresses() {
+		report.Balances = append(report.Balances, MigratedBalance{Address: address, Liquidity: new(big.Int).Set(source.balances[address])})
+	}
+	if signer != nil {
+		signature, err := signer(report.Digest())
+		if err != nil {
+			return nil, err
+		}
+		report.Signature = signature
+	}
+	if err := s.writeWAL(walRecord{Operation: "migrate", Token0: key.TokenA, Token1: key.TokenB, FeeTier: fromTier, ToTier: toTier}); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	source.accumulate(now)
+	target.accumulate(now)
+	target.reserve0.Set(source.reserve0)
+	target.reserve1.Set(source.reserve1)
+	target.totalSupply.Set(source.totalSupply)
+	source.reserve0.SetInt64(0)
+	source.reserve1.SetInt64(0)
+	source.totalSupply.SetInt64(0)
+	for _, balance := range report.Balances {
+		target.balances[balance.Address] = new(big.Int).Set(balance.Liquidity)
+		delete(source.balances, balance.Address)
+		source.touchBalance(balance.Address)
+		target.touchBalance(balance.Address)
+		s.updatePosition(balance.Address, from, nil)
+		s.updatePosition(balance.Address, to, balance.Liquidity)
+	}
+	source.isDirty, target.isDirty = true, true
+	s.routes.touch(key.TokenA, key.TokenB)
+
+	return report, nil
+}
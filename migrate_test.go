@@ -0,0 +1,74 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestUniswapV2_MigratePair(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("bob", big.NewInt(1e17), big.NewInt(4e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := pair.Balance("alice"), pair.Balance("bob")
+
+	errSigner := errors.New("signer failed")
+	_, err = service.MigratePair(0, 1, 0, 5, func([]byte) ([]byte, error) { return nil, errSigner })
+	if err != errSigner {
+		t.Fatalf("failed with %v; want error %v", err, errSigner)
+	}
+	if pair.Balance("alice").Cmp(alice) != 0 {
+		t.Errorf("balance want %s, got %s", alice, pair.Balance("alice"))
+	}
+
+	var signed []byte
+	report, err := service.MigratePair(1, 0, 0, 5, func(digest []byte) ([]byte, error) {
+		signed = digest
+		return []byte("signature"), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(report.Digest(), signed) || string(report.Signature) != "signature" {
+		t.Errorf("signature want over %x, got %q over %x", report.Digest(), report.Signature, signed)
+	}
+	if report.ToFee != (Fee{Numerator: 5, Denominator: 10000}) || report.FromFee != DefaultFee {
+		t.Errorf("fees want %v -> %v, got %v -> %v", DefaultFee, Fee{Numerator: 5, Denominator: 10000}, report.FromFee, report.ToFee)
+	}
+
+	migrated := service.PairWithFee(1, 0, 5)
+	reserve0, reserve1 := migrated.Reserves()
+	if reserve0.Cmp(big.NewInt(11e17)) != 0 || reserve1.Cmp(big.NewInt(44e17)) != 0 {
+		t.Errorf("reserves want %s, %s, got %s, %s", big.NewInt(11e17), big.NewInt(44e17), reserve0, reserve1)
+	}
+	if migrated.Balance("alice").Cmp(alice) != 0 || migrated.Balance("bob").Cmp(bob) != 0 {
+		t.Errorf("balances want %s, %s, got %s, %s", alice, bob, migrated.Balance("alice"), migrated.Balance("bob"))
+	}
+	if pair.TotalSupply().Sign() != 0 || pair.Balance("alice") != nil {
+		t.Errorf("source pair want drained, got total supply %s", pair.TotalSupply())
+	}
+	if got := service.PositionsOf("bob"); !reflect.DeepEqual(got, []pairKey{{TokenA: 0, TokenB: 1, Fee: 5}}) {
+		t.Errorf("positions want %v, got %v", []pairKey{{TokenA: 0, TokenB: 1, Fee: 5}}, got)
+	}
+
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.MigratePair(0, 1, 0, 5, nil)
+	if err != ErrorPairNotEmpty {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotEmpty)
+	}
+}
@@ -0,0 +1,145 @@
+package uniswapV2
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+)
+
+//go:generate go run ./cmd/openapi -o openapi.json
+
+// WriteOpenAPI writes the OpenAPI 3.0 document of NewQuoteHandler and
+// NewHealthHandler to w, with the schemas of the answers derived from the Go
+// types they encode. openapi.json of the module is its output, for client
+// generators of other languages; RemoteQuoter and HealthClient are the Go
+// clients.
+func WriteOpenAPI(w io.Writer) error {
+	schemas := map[string]interface{}{}
+	for name, value := range map[string]interface{}{
+		"Quote":        remoteQuote{},
+		"PairVersion":  remotePairVersion{},
+		"HealthStatus": HealthStatus{},
+	} {
+		schemas[name] = openAPISchema(reflect.TypeOf(value))
+	}
+
+	health := func(summary string) map[string]interface{} {
+		return map[string]interface{}{"get": map[string]interface{}{
+			"summary": summary,
+			"responses": map[string]interface{}{
+				"200": openAPIResponse("OK", openAPIRef("HealthStatus")),
+				"503": openAPIResponse("Service Unavailable", openAPIRef("HealthStatus")),
+			},
+		}}
+	}
+	token := map[string]interface{}{"type": "integer", "format": "int32"}
+	amount := map[string]interface{}{"type": "string", "pattern": "^[0-9]+$"}
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info":    map[string]interface{}{"title": "uniswapV2", "version": "1"},
+		"paths": map[string]interface{}{
+			"/quote": map[string]interface{}{"get": map[string]interface{}{
+				"summary": "Best output of the amount of token in for token out, with the versions of the pairs on its path.",
+				"parameters": []interface{}{
+					openAPIParameter("in", token),
+					openAPIParameter("out", token),
+					openAPIParameter("amount", amount),
+				},
+				"responses": map[string]interface{}{
+					"200": openAPIResponse("OK", openAPIRef("Quote")),
+					"400": map[string]interface{}{"description": "Bad Request"},
+				},
+			}},
+			"/versions": map[string]interface{}{"get": map[string]interface{}{
+				"summary": "Current versions of pairs, 0 for the ones that do not exist.",
+				"parameters": []interface{}{map[string]interface{}{
+					"name":        "pair",
+					"in":          "query",
+					"description": "token0,token1,fee tier",
+					"schema":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string", "pattern": "^-?[0-9]+,-?[0-9]+,[0-9]+$"}},
+				}},
+				"responses": map[string]interface{}{
+					"200": openAPIResponse("OK", map[string]interface{}{"type": "array", "items": openAPISchema(reflect.TypeOf(uint64(0)))}),
+					"400": map[string]interface{}{"description": "Bad Request"},
+				},
+			}},
+			"/healthz": health("Height of the last commit and outcome of the invariant checks."),
+			"/readyz":  health("Health with the outcome of a read of the storage."),
+		},
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func openAPIParameter(name string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"name": name, "in": "query", "required": true, "schema": schema}
+}
+
+func openAPIResponse(description string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+	}
+}
+
+func openAPIRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// openAPISchemaNames are the schemas of the structs named in components.
+var openAPISchemaNames = map[reflect.Type]string{
+	reflect.TypeOf(remotePairVersion{}): "PairVersion",
+}
+
+// openAPISchema returns the schema of the JSON encoding of t.
+func openAPISchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": openAPISchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, options := field.Name, ""
+			if tag := field.Tag.Get("json"); tag != "" {
+				name = strings.Split(tag, ",")[0]
+				options = strings.TrimPrefix(tag, name)
+			}
+			if name == "-" || field.PkgPath != "" {
+				continue
+			}
+			properties[name] = openAPISchema(field.Type)
+			if field.Type.Kind() == reflect.Slice {
+				if named, ok := openAPISchemaNames[field.Type.Elem()]; ok {
+					properties[name] = map[string]interface{}{"type": "array", "items": openAPIRef(named)}
+				}
+			}
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) != 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
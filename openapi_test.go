@@ -0,0 +1,44 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteOpenAPI(t *testing.T) {
+	var document bytes.Buffer
+	if err := WriteOpenAPI(&document); err != nil {
+		t.Fatal(err)
+	}
+	shipped, err := ioutil.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(document.Bytes(), shipped) {
+		t.Fatal("openapi.json is stale, run go generate")
+	}
+
+	var spec struct {
+		Paths map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(shipped, &spec); err != nil {
+		t.Fatal(err)
+	}
+	service := New()
+	handlers := []http.Handler{NewQuoteHandler(service), NewHealthHandler(service, nil)}
+	for path := range spec.Paths {
+		served := false
+		for _, handler := range handlers {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+			served = served || recorder.Code != http.StatusNotFound
+		}
+		if !served {
+			t.Errorf("%s is not served", path)
+		}
+	}
+}
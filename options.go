@@ -0,0 +1,108 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+// AdmissionPolicy decides whether a new pair may be created. A non-nil
+// error rejects the pair and is returned by CreatePair.
+type AdmissionPolicy func(coinA, coinB Token) error
+
+type options struct {
+	maxPairs            int
+	admission           AdmissionPolicy
+	minInitialLiquidity *big.Int
+	reserveLimit        *big.Int
+	supplyLimit         *big.Int
+	clock               func() time.Time
+	swapVerifier        SwapVerifier
+	auditLog            *AuditLog
+	events              *Events
+	metrics             *Metrics
+	logger              Logger
+	logLevel            LogLevel
+	tracer              Tracer
+	wal                 *writeAheadLog
+	lazy                *lazyPairs
+	routeCache          *routeCache
+	history             bool
+	checkedAccumulators bool
+	approvals           *adminApprovals
+}
+
+type Option func(*options)
+
+var (
+	ErrorTooManyPairs                 = errors.New("TOO_MANY_PAIRS")
+	ErrorInsufficientInitialLiquidity = errors.New("INSUFFICIENT_INITIAL_LIQUIDITY")
+)
+
+// WithMaxPairs limits the number of pairs the service holds; zero means no limit.
+func WithMaxPairs(n int) Option {
+	return func(o *options) {
+		o.maxPairs = n
+	}
+}
+
+func WithAdmissionPolicy(policy AdmissionPolicy) Option {
+	return func(o *options) {
+		o.admission = policy
+	}
+}
+
+// WithMinInitialLiquidity rejects a first Mint on a pair that would give the
+// provider less than amount of liquidity, on top of the minimumLiquidity
+// that is always locked.
+func WithMinInitialLiquidity(amount *big.Int) Option {
+	return func(o *options) {
+		o.minInitialLiquidity = new(big.Int).Set(amount)
+	}
+}
+
+// WithReserveLimits makes Mint, Swap and Sync fail with ErrorOverflow
+// instead of taking a reserve above maxReserve or the total supply above
+// maxSupply, e.g. MaxUint112 for state read by 112-bit consumers. A nil
+// limit is no limit.
+func WithReserveLimits(maxReserve, maxSupply *big.Int) Option {
+	return func(o *options) {
+		o.reserveLimit, o.supplyLimit = nil, nil
+		if maxReserve != nil {
+			o.reserveLimit = new(big.Int).Set(maxReserve)
+		}
+		if maxSupply != nil {
+			o.supplyLimit = new(big.Int).Set(maxSupply)
+		}
+	}
+}
+
+// WithClock sets the time source used for price accumulators; time.Now by default.
+func WithClock(clock func() time.Time) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithSwapVerifier replaces VerifySwap as the invariant check of every swap.
+func WithSwapVerifier(verifier SwapVerifier) Option {
+	return func(o *options) {
+		o.swapVerifier = verifier
+	}
+}
+
+// WithCheckedAccumulators makes operations fail with an
+// *AccumulatorOverflowError instead of wrapping the price accumulators or
+// the timestamp as the contract does.
+func WithCheckedAccumulators() Option {
+	return func(o *options) {
+		o.checkedAccumulators = true
+	}
+}
+
+func (o *options) now() time.Time {
+	if o.clock == nil {
+		return time.Now()
+	}
+	return o.clock()
+}
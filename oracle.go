@@ -0,0 +1,89 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sync"
+	"time"
+)
+
+type priceObservation struct {
+	timestamp                          uint32
+	price0Cumulative, price1Cumulative *big.Int
+}
+
+// Oracle keeps periodic observations of the pair price accumulators and
+// computes time-weighted average prices from them, like the
+// ExampleSlidingWindowOracle of the periphery. Update has to be called at
+// least once per window for every consulted pair.
+type Oracle struct {
+	mu           sync.Mutex
+	maxWindow    uint32
+	observations map[pairKey][]priceObservation
+}
+
+// NewOracle returns an oracle keeping enough observations to consult
+// windows up to maxWindow.
+func NewOracle(maxWindow time.Duration) *Oracle {
+	return &Oracle{
+		maxWindow:    uint32(maxWindow / time.Second),
+		observations: map[pairKey][]priceObservation{},
+	}
+}
+
+func (o *Oracle) Update(pair *Pair) error {
+	observation := currentObservation(pair)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	key := pair.key.sort()
+	observations := o.observations[key]
+	if n := len(observations); n != 0 && observation.timestamp == observations[n-1].timestamp {
+		return ErrorInvalidTimestamp
+	}
+	for len(observations) > 1 && observation.timestamp-observations[1].timestamp >= o.maxWindow {
+		observations = observations[1:]
+	}
+	o.observations[key] = append(observations, observation)
+	return nil
+}
+
+// Consult returns the average price of token0 in token1 of the pair over at
+// least the last window, using the latest observation old enough.
+func (o *Oracle) Consult(pair *Pair, window time.Duration) (*big.Rat, error) {
+	current := currentObservation(pair)
+	seconds := uint32(window / time.Second)
+
+	o.mu.Lock()
+	var start *priceObservation
+	observations := o.observations[pair.key.sort()]
+	for i := len(observations) - 1; i >= 0; i-- {
+		if current.timestamp-observations[i].timestamp >= seconds {
+			start = &observations[i]
+			break
+		}
+	}
+	o.mu.Unlock()
+
+	if start == nil || start.timestamp == current.timestamp {
+		return nil, ErrorInsufficientObservations
+	}
+
+	cumulative, startCumulative := current.price0Cumulative, start.price0Cumulative
+	if !pair.key.isSorted() {
+		cumulative, startCumulative = current.price1Cumulative, start.price1Cumulative
+	}
+	delta := new(big.Int).Sub(cumulative, startCumulative)
+	delta.Mod(delta, cumulativeModulus)
+	elapsed := new(big.Int).SetUint64(uint64(current.timestamp - start.timestamp))
+	return new(big.Rat).SetFrac(delta, elapsed.Lsh(elapsed, resolution)), nil
+}
+
+// currentObservation reads the accumulators in canonical token order.
+func currentObservation(pair *Pair) priceObservation {
+	price0, price1, timestamp := pair.currentCumulativePrices(pair.service.now())
+	if !pair.key.isSorted() {
+		price0, price1 = price1, price0
+	}
+	return priceObservation{timestamp: timestamp, price0Cumulative: price0, price1Cumulative: price1}
+}
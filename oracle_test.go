@@ -0,0 +1,72 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestOracle_Consult(t *testing.T) {
+	now := time.Unix(1000, 0)
+	service := New(WithClock(func() time.Time { return now }))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oracle := NewOracle(time.Hour)
+	if err := oracle.Update(pair); err != nil {
+		t.Fatal(err)
+	}
+	if err := oracle.Update(pair); err != ErrorInvalidTimestamp {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidTimestamp)
+	}
+
+	now = now.Add(30 * time.Second)
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oracle.Update(pair); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(30 * time.Second)
+
+	price, err := oracle.Consult(pair, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := new(big.Int).Lsh(big.NewInt(19), resolution)
+	second.Quo(second, big.NewInt(11))
+	sum := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(2), resolution), second)
+	want := new(big.Rat).SetFrac(sum, new(big.Int).Lsh(big.NewInt(2), resolution))
+	if price.Cmp(want) != 0 {
+		t.Errorf("price want %s, got %s", want.FloatString(18), price.FloatString(18))
+	}
+
+	price, err = oracle.Consult(pair, 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = new(big.Rat).SetFrac(second, new(big.Int).Lsh(big.NewInt(1), resolution))
+	if price.Cmp(want) != 0 {
+		t.Errorf("price want %s, got %s", want.FloatString(18), price.FloatString(18))
+	}
+
+	_, err = oracle.Consult(pair, 2*time.Minute)
+	if err != ErrorInsufficientObservations {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientObservations)
+	}
+
+	reversed, err := oracle.Consult(service.Pair(1, 0), 30*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reversed.FloatString(6) != "0.578947" {
+		t.Errorf("reversed price want %s, got %s", "0.578947", reversed.FloatString(6))
+	}
+}
@@ -1,8 +1,10 @@
 package uniswapV2
 
 import (
+	"context"
 	"errors"
 	"math/big"
+	"sort"
 	"sync"
 )
 
@@ -13,15 +15,87 @@ type Address string
 
 const addressZero Address = ""
 
+// AddressLess is the canonical order of addresses used wherever balances
+// are iterated. Deployments with their own address format may replace it
+// before any service is created.
+var AddressLess = func(a, b Address) bool {
+	return a < b
+}
+
+// TokenLess is the canonical order of tokens. The lesser token of a pair is
+// its token0, which decides the order of reserves, accumulators and amounts
+// wherever pairs are exported, encoded or hashed, and reverse views are
+// relative to it. Pairs are listed in this order too. Like AddressLess it may
+// be replaced before any service is created, never while one is in use.
+var TokenLess = func(a, b Token) bool {
+	return a < b
+}
+
+func sortAddresses(addresses []Address) {
+	sort.Slice(addresses, func(i, j int) bool { return AddressLess(addresses[i], addresses[j]) })
+}
+
 type UniswapV2 struct {
+	options
+
 	muPairs         sync.RWMutex
 	pairs           map[pairKey]*Pair
 	keyPairs        []pairKey
 	isDirtyKeyPairs bool
+
+	tiers  map[pairKey][]uint32
+	routes *routingIndex
+
+	muPositions sync.RWMutex
+	positions   map[Address]map[pairKey]struct{}
+
+	muHooks   sync.RWMutex
+	swapHooks map[pairKey][]SwapHook
+	hooks     []Hooks
+
+	quoters quoters
+
+	muMetadata sync.RWMutex
+	metadata   map[pairKey]map[string]string
+
+	muTokens sync.RWMutex
+	tokens   map[Token]TokenInfo
+
+	committed         map[pairKey]*Pair
+	committedMetadata map[pairKey]map[string]string
+	height            uint64
+	prunedBelow       uint64
+	versions          map[pairKey][]pairVersion
+
+	muSnapshots sync.Mutex
+	snapshots   []snapshot
+	nextStateID StateID
+
+	muBlock sync.Mutex
+	block   *block
+
+	// op is the journal of the operation run by DeliverOp, nil outside it
+	muOp sync.Mutex
+	op   *journal
 }
 
-func New() *UniswapV2 {
-	return &UniswapV2{pairs: map[pairKey]*Pair{}}
+func New(options ...Option) *UniswapV2 {
+	s := &UniswapV2{
+		pairs:     map[pairKey]*Pair{},
+		tiers:     map[pairKey][]uint32{},
+		routes:    newRoutingIndex(),
+		positions: map[Address]map[pairKey]struct{}{},
+		swapHooks: map[pairKey][]SwapHook{},
+		metadata:  map[pairKey]map[string]string{},
+
+		committed:         map[pairKey]*Pair{},
+		committedMetadata: map[pairKey]map[string]string{},
+		versions:          map[pairKey][]pairVersion{},
+	}
+	for _, option := range options {
+		option(&s.options)
+	}
+	return s
 }
 
 var mainPrefix = "p"
@@ -32,10 +106,13 @@ type balance struct {
 }
 
 type pairData struct {
-	*sync.RWMutex
-	reserve0    *big.Int
-	reserve1    *big.Int
-	totalSupply *big.Int
+	*pairMutex
+	reserve0             *big.Int
+	reserve1             *big.Int
+	totalSupply          *big.Int
+	price0CumulativeLast *big.Int
+	price1CumulativeLast *big.Int
+	blockTimestampLast   *uint32
 }
 
 func (pd *pairData) TotalSupply() *big.Int {
@@ -52,10 +129,13 @@ func (pd *pairData) Reserves() (reserve0 *big.Int, reserve1 *big.Int) {
 
 func (pd *pairData) Revert() pairData {
 	return pairData{
-		RWMutex:     pd.RWMutex,
-		reserve0:    pd.reserve1,
-		reserve1:    pd.reserve0,
-		totalSupply: pd.totalSupply,
+		pairMutex:            pd.pairMutex,
+		reserve0:             pd.reserve1,
+		reserve1:             pd.reserve0,
+		totalSupply:          pd.totalSupply,
+		price0CumulativeLast: pd.price1CumulativeLast,
+		price1CumulativeLast: pd.price0CumulativeLast,
+		blockTimestampLast:   pd.blockTimestampLast,
 	}
 }
 
@@ -66,6 +146,49 @@ func (s *UniswapV2) Pairs() ([]pairKey, error) {
 	return s.keyPairs, nil
 }
 
+// PairsPage returns up to limit keys of Pairs from offset on, copying only
+// those.
+func (s *UniswapV2) PairsPage(offset, limit int) []pairKey {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	if offset < 0 || limit <= 0 || offset >= len(s.keyPairs) {
+		return nil
+	}
+	end := len(s.keyPairs)
+	if limit < end-offset {
+		end = offset + limit
+	}
+	return append([]pairKey(nil), s.keyPairs[offset:end]...)
+}
+
+func (s *UniswapV2) PairsCount() int {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	return len(s.keyPairs)
+}
+
+// SortedPairs returns the canonical keys of all pairs sorted by token0,
+// token1 and fee tier in TokenLess order, the same however the pairs were
+// created or imported, unlike Pairs which lists them in creation order.
+// Exports, encodings and StateRoot all follow this order.
+func (s *UniswapV2) SortedPairs() []pairKey {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	return s.sortedKeys()
+}
+
+func (s *UniswapV2) sortedKeys() []pairKey {
+	keys := make([]pairKey, 0, len(s.pairs))
+	for key := range s.pairs {
+		keys = append(keys, key)
+	}
+	sortPairKeys(keys)
+	return keys
+}
+
 func (s *UniswapV2) pair(key pairKey) (*Pair, bool) {
 	if key.isSorted() {
 		pair, ok := s.pairs[key]
@@ -75,12 +198,7 @@ func (s *UniswapV2) pair(key pairKey) (*Pair, bool) {
 	if !ok {
 		return nil, false
 	}
-	return &Pair{
-		muBalance: pair.muBalance,
-		pairData:  pair.pairData.Revert(),
-		balances:  pair.balances,
-		dirty:     pair.dirty,
-	}, true
+	return pair.revert(), true
 }
 
 func (s *UniswapV2) Pair(coinA, coinB Token) *Pair {
@@ -88,12 +206,17 @@ func (s *UniswapV2) Pair(coinA, coinB Token) *Pair {
 	defer s.muPairs.Unlock()
 
 	key := pairKey{TokenA: coinA, TokenB: coinB}
-	pair, _ := s.pair(key)
+	pair, _, err := s.loadPair(key)
+	if err != nil {
+		s.logStorageError(key, err)
+	}
 	return pair
 }
 
 type pairKey struct {
 	TokenA, TokenB Token
+	// Fee is the fee tier in basis points, zero for the pair created by CreatePair.
+	Fee uint32
 }
 
 func (pk pairKey) sort() pairKey {
@@ -104,11 +227,30 @@ func (pk pairKey) sort() pairKey {
 }
 
 func (pk pairKey) isSorted() bool {
-	return pk.TokenA < pk.TokenB
+	return TokenLess(pk.TokenA, pk.TokenB)
 }
 
 func (pk pairKey) Revert() pairKey {
-	return pairKey{TokenA: pk.TokenB, TokenB: pk.TokenA}
+	return pairKey{TokenA: pk.TokenB, TokenB: pk.TokenA, Fee: pk.Fee}
+}
+
+// tokens drops the fee tier, identifying all tiers of a token pair.
+func (pk pairKey) tokens() pairKey {
+	return pairKey{TokenA: pk.TokenA, TokenB: pk.TokenB}
+}
+
+func sortPairKeys(keys []pairKey) {
+	sort.Slice(keys, func(i, j int) bool { return pairKeyLess(keys[i], keys[j]) })
+}
+
+func pairKeyLess(a, b pairKey) bool {
+	if a.TokenA != b.TokenA {
+		return TokenLess(a.TokenA, b.TokenA)
+	}
+	if a.TokenB != b.TokenB {
+		return TokenLess(a.TokenB, b.TokenB)
+	}
+	return a.Fee < b.Fee
 }
 
 var (
@@ -116,51 +258,119 @@ var (
 	ErrorPairExists         = errors.New("PAIR_EXISTS")
 )
 
-func (s *UniswapV2) CreatePair(coinA, coinB Token) (*Pair, error) {
+func (s *UniswapV2) CreatePair(coinA, coinB Token, options ...PairOption) (*Pair, error) {
+	opts := pairOptions{fee: DefaultFee}
+	for _, option := range options {
+		option(&opts)
+	}
+	return s.createPair(pairKey{TokenA: coinA, TokenB: coinB}, opts)
+}
+
+func (s *UniswapV2) createPair(key pairKey, opts pairOptions) (*Pair, error) {
+	coinA, coinB := key.TokenA, key.TokenB
 	if coinA == coinB {
 		return nil, ErrorIdenticalAddresses
 	}
+	if !opts.fee.valid() {
+		return nil, ErrorInvalidFee
+	}
 
-	pair := s.Pair(coinA, coinB)
-	if pair != nil {
+	s.muPairs.RLock()
+	_, exists := s.pair(key)
+	s.muPairs.RUnlock()
+	if exists {
 		return nil, ErrorPairExists
 	}
+	if s.admission != nil {
+		if err := s.admission(coinA, coinB); err != nil {
+			return nil, err
+		}
+	}
 
 	totalSupply, reserve0, reserve1, balances := big.NewInt(0), big.NewInt(0), big.NewInt(0), map[Address]*big.Int{}
 
 	s.muPairs.Lock()
 	defer s.muPairs.Unlock()
 
-	key := pairKey{coinA, coinB}
-	pair = s.addPair(key, pairData{reserve0: reserve0, reserve1: reserve1, totalSupply: totalSupply}, balances)
+	if _, ok := s.pair(key); ok {
+		return nil, ErrorPairExists
+	}
+	if stored, err := s.inStorage(key); err != nil {
+		return nil, err
+	} else if stored {
+		return nil, ErrorPairExists
+	}
+	if s.maxPairs > 0 && len(s.pairs) >= s.maxPairs {
+		return nil, ErrorTooManyPairs
+	}
+	fee := opts.fee
+	if err := s.writeWAL(walRecord{Operation: "create_pair", Token0: coinA, Token1: coinB, FeeTier: key.Fee, Fee: &fee}); err != nil {
+		return nil, err
+	}
+
+	isDirtyKeyPairs := s.isDirtyKeyPairs
+	pair := s.addPair(key, pairData{reserve0: reserve0, reserve1: reserve1, totalSupply: totalSupply}, balances, opts.fee)
 	s.addKeyPair(key)
+	if _, ok := s.committed[key.sort()]; ok {
+		// replaces a removed pair that is still committed
+		pair.isDirty, pair.isDirtyAllowances = true, true
+		pair.touchBalances()
+	}
+	s.undoOp(func() {
+		s.muPairs.Lock()
+		defer s.muPairs.Unlock()
+		s.dropPair(pair)
+		s.isDirtyKeyPairs = isDirtyKeyPairs
+	})
+	if s.lazy != nil {
+		s.lazy.use(key.sort())
+		s.evictPairs()
+	}
+	view := pair.inJournal(opts.journal)
+	view.audit("create_pair", addressZero, nil)
+	view.log(LogInfo, "pair created", addressZero, LogField{"fee", pair.fee})
 	if !key.isSorted() {
-		return &Pair{
-			muBalance: pair.muBalance,
-			pairData:  pair.Revert(),
-			balances:  pair.balances,
-			dirty:     pair.dirty,
-		}, nil
+		return pair.revert(), nil
 	}
 	return pair, nil
 }
 
-func (s *UniswapV2) addPair(key pairKey, data pairData, balances map[Address]*big.Int) *Pair {
+func (s *UniswapV2) addPair(key pairKey, data pairData, balances map[Address]*big.Int, fee Fee) *Pair {
 	if !key.isSorted() {
-		key.Revert()
+		key = key.Revert()
 		data = data.Revert()
 	}
-	data.RWMutex = &sync.RWMutex{}
+	data.pairMutex = &pairMutex{}
+	if data.blockTimestampLast == nil {
+		data.price0CumulativeLast = big.NewInt(0)
+		data.price1CumulativeLast = big.NewInt(0)
+		data.blockTimestampLast = new(uint32)
+	}
 	pair := &Pair{
-		muBalance: &sync.RWMutex{},
-		pairData:  data,
-		balances:  balances,
+		key:        key,
+		service:    s,
+		fee:        fee,
+		muBalance:  &sync.RWMutex{},
+		pairData:   data,
+		balances:   balances,
+		allowances: map[Address]map[Address]*big.Int{},
+		nonces:     map[Address]uint64{},
 		dirty: &dirty{
 			isDirty:         false,
 			isDirtyBalances: false,
 		},
 	}
 	s.pairs[key] = pair
+	for _, fee := range s.tiers[key.tokens()] {
+		if fee == key.Fee {
+			// loaded again by WithLazyStorage
+			return pair
+		}
+	}
+	if len(s.tiers[key.tokens()]) == 0 {
+		s.routes.add(key.TokenA, key.TokenB)
+	}
+	s.tiers[key.tokens()] = append(s.tiers[key.tokens()], key.Fee)
 	return pair
 }
 
@@ -174,17 +384,73 @@ var (
 )
 
 type dirty struct {
-	isDirty         bool
-	isDirtyBalances bool
+	isDirty           bool
+	isDirtyBalances   bool
+	isDirtyAllowances bool
+	// addresses whose LP balance changed since the last commit, all of them
+	// if allBalances is set
+	dirtyAddresses map[Address]struct{}
+	allBalances    bool
+}
+
+// changed reports whether anything of the pair changed since the last
+// commit.
+func (d *dirty) changed() bool {
+	return d.isDirty || d.isDirtyBalances || d.isDirtyAllowances
 }
+
 type Pair struct {
 	pairData
-	muBalance *sync.RWMutex
-	balances  map[Address]*big.Int
+	key        pairKey
+	service    *UniswapV2
+	fee        Fee
+	muBalance  *sync.RWMutex
+	balances   map[Address]*big.Int
+	allowances map[Address]map[Address]*big.Int
+	nonces     map[Address]uint64
 	*dirty
+	ctx context.Context
+	// sender is the address swapping on this view, for the swap event
+	sender Address
+	// journal holds back the events, audit records, metrics and logs of
+	// the changes of this view until the operation it is part of commits
+	journal *journal
+	// opHeld is set on the views holding the operation lock of holdOp
+	opHeld bool
+}
+
+func (p *Pair) revert() *Pair {
+	return &Pair{
+		key:        p.key.Revert(),
+		service:    p.service,
+		fee:        p.fee,
+		muBalance:  p.muBalance,
+		pairData:   p.pairData.Revert(),
+		balances:   p.balances,
+		allowances: p.allowances,
+		nonces:     p.nonces,
+		dirty:      p.dirty,
+		ctx:        p.ctx,
+		sender:     p.sender,
+		journal:    p.journal,
+		opHeld:     p.opHeld,
+	}
+}
+
+func (p *Pair) addresses() []Address {
+	addresses := make([]Address, 0, len(p.balances))
+	for address := range p.balances {
+		addresses = append(addresses, address)
+	}
+	sortAddresses(addresses)
+	return addresses
 }
 
 func (p *Pair) Balance(address Address) (liquidity *big.Int) {
+	if err := normalizeAddresses(&address); err != nil {
+		return nil
+	}
+
 	p.muBalance.RLock()
 	defer p.muBalance.RUnlock()
 
@@ -197,24 +463,72 @@ func (p *Pair) Balance(address Address) (liquidity *big.Int) {
 }
 
 func (p *Pair) Mint(address Address, amount0, amount1 *big.Int) (liquidity *big.Int, err error) {
+	p, release := p.holdOp()
+	defer release()
+	defer p.wrapError(&err, "mint", func() errorAmounts {
+		return errorAmounts{}.set(p, "amount", amount0, amount1)
+	})
+	if err := checkPositive(amount0, amount1); err != nil {
+		return nil, err
+	}
+	if err := normalizeAddresses(&address); err != nil {
+		return nil, err
+	}
+	for _, hooks := range p.service.operationHooks() {
+		if hooks.BeforeMint != nil {
+			if err := hooks.BeforeMint(p, address, amount0, amount1); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := p.checkAccumulators(); err != nil {
+		return nil, err
+	}
+	p.saveForOp()
 	totalSupply := p.TotalSupply()
 	if totalSupply.Sign() == 0 {
 		liquidity = startingSupply(amount0, amount1)
 		if liquidity.Sign() != 1 {
 			return nil, ErrorInsufficientLiquidityMinted
 		}
+		if min := p.service.minInitialLiquidity; min != nil && liquidity.Cmp(min) == -1 {
+			return nil, ErrorInsufficientInitialLiquidity
+		}
+		if err := p.checkLimits(amount0, amount1, new(big.Int).Add(liquidity, big.NewInt(minimumLiquidity))); err != nil {
+			return nil, err
+		}
+		if err := p.writeWAL("mint", address, amount0, amount1); err != nil {
+			return nil, err
+		}
 		p.mint(addressZero, big.NewInt(minimumLiquidity))
 	} else {
 		reserve0, reserve1 := p.Reserves()
-		liquidity := new(big.Int).Div(new(big.Int).Mul(totalSupply, amount0), reserve0)
+		if reserve0.Sign() != 1 || reserve1.Sign() != 1 {
+			return nil, ErrorInsufficientLiquidity
+		}
+		liquidity = new(big.Int).Div(new(big.Int).Mul(totalSupply, amount0), reserve0)
 		liquidity1 := new(big.Int).Div(new(big.Int).Mul(totalSupply, amount1), reserve1)
 		if liquidity.Cmp(liquidity1) == 1 {
 			liquidity = liquidity1
 		}
+		if err := p.checkLimits(amount0, amount1, liquidity); err != nil {
+			return nil, err
+		}
+		if err := p.writeWAL("mint", address, amount0, amount1); err != nil {
+			return nil, err
+		}
 	}
 
 	p.mint(address, liquidity)
 	p.update(amount0, amount1)
+	p.audit("mint", address, auditAmounts{"liquidity": liquidity.String()}.set(p, "", amount0, amount1))
+	p.log(LogDebug, "mint", address, append(p.amountFields("amount", amount0, amount1), LogField{"liquidity", liquidity.String()})...)
+	p.emit(Event{Kind: EventMint, Address: address, Liquidity: liquidity, Amount0In: amount0, Amount1In: amount1})
+	for _, hooks := range p.service.operationHooks() {
+		if hooks.AfterMint != nil {
+			hooks.AfterMint(p, address, amount0, amount1, liquidity)
+		}
+	}
 
 	return new(big.Int).Set(liquidity), nil
 }
@@ -224,6 +538,35 @@ var (
 )
 
 func (p *Pair) Burn(address Address, liquidity *big.Int) (amount0 *big.Int, amount1 *big.Int, err error) {
+	return p.BurnTo(address, liquidity, address)
+}
+
+// BurnTo burns liquidity of address as Burn does and redeems the withdrawn
+// amounts to the to address, as the to parameter of the contract's burn.
+// The pair holds no token balances: the returned amounts are for the
+// embedding ledger to send to to, which events and the audit log name.
+func (p *Pair) BurnTo(address Address, liquidity *big.Int, to Address) (amount0 *big.Int, amount1 *big.Int, err error) {
+	p, release := p.holdOp()
+	defer release()
+	defer p.wrapError(&err, "burn", func() errorAmounts {
+		if liquidity == nil {
+			return errorAmounts{}
+		}
+		return errorAmounts{"liquidity": new(big.Int).Set(liquidity)}
+	})
+	if err := checkPositive(liquidity); err != nil {
+		return nil, nil, err
+	}
+	if err := normalizeAddresses(&address, &to); err != nil {
+		return nil, nil, err
+	}
+	for _, hooks := range p.service.operationHooks() {
+		if hooks.BeforeBurn != nil {
+			if err := hooks.BeforeBurn(p, address, liquidity); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
 	balance := p.Balance(address)
 	if balance == nil {
 		return nil, nil, ErrorInsufficientLiquidityBurned
@@ -238,9 +581,30 @@ func (p *Pair) Burn(address Address, liquidity *big.Int) (amount0 *big.Int, amou
 	if amount0.Sign() != 1 || amount1.Sign() != 1 {
 		return nil, nil, ErrorInsufficientLiquidityBurned
 	}
+	if err := p.checkAccumulators(); err != nil {
+		return nil, nil, err
+	}
+	p.saveForOp()
+	if err := p.writeWAL("burn", address, liquidity); err != nil {
+		return nil, nil, err
+	}
 
 	p.burn(address, liquidity)
 	p.update(new(big.Int).Neg(amount0), new(big.Int).Neg(amount1))
+	amounts := auditAmounts{"liquidity": liquidity.String()}.set(p, "", amount0, amount1)
+	fields := append(p.amountFields("amount", amount0, amount1), LogField{"liquidity", liquidity.String()})
+	if to != address {
+		amounts["to"] = string(to)
+		fields = append(fields, LogField{"to", to})
+	}
+	p.audit("burn", address, amounts)
+	p.log(LogDebug, "burn", address, fields...)
+	p.emit(Event{Kind: EventBurn, Address: address, To: to, Liquidity: liquidity, Amount0Out: amount0, Amount1Out: amount1})
+	for _, hooks := range p.service.operationHooks() {
+		if hooks.AfterBurn != nil {
+			hooks.AfterBurn(p, address, liquidity, amount0, amount1)
+		}
+	}
 
 	return amount0, amount1, nil
 }
@@ -250,12 +614,114 @@ var (
 	ErrorInsufficientInputAmount  = errors.New("INSUFFICIENT_INPUT_AMOUNT")
 	ErrorInsufficientOutputAmount = errors.New("INSUFFICIENT_OUTPUT_AMOUNT")
 	ErrorInsufficientLiquidity    = errors.New("INSUFFICIENT_LIQUIDITY")
+	ErrorInsufficientAmount       = errors.New("INSUFFICIENT_AMOUNT")
+	ErrorInactivePair             = errors.New("INACTIVE_PAIR")
 )
 
+// IsActive reports whether the pair has liquidity beyond the minimumLiquidity
+// locked by its first Mint. A pair whose providers burned everything keeps
+// only dust reserves and refuses swaps and quotes with ErrorInactivePair, so
+// that routers skip it. Burn and Sync never leave a reserve of such a pair at
+// zero, so a Mint priced against the dust revives it.
+func (p *Pair) IsActive() bool {
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+	return p.isActive()
+}
+
+func (pd *pairData) isActive() bool {
+	return pd.totalSupply.Cmp(big.NewInt(minimumLiquidity)) == 1 && pd.reserve0.Sign() == 1 && pd.reserve1.Sign() == 1
+}
+
+// drained reports whether the pair was active once but is not anymore.
+func (p *Pair) drained() bool {
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+	return p.totalSupply.Sign() == 1 && !p.isActive()
+}
+
 func (p *Pair) Swap(amount0In, amount1In, amount0Out, amount1Out *big.Int) (amount0, amount1 *big.Int, err error) {
+	return p.SwapWithCallback(amount0In, amount1In, amount0Out, amount1Out, nil)
+}
+
+// SwapFrom is Swap made by sender, whose address the swap event carries for
+// subscriptions filtering by address.
+func (p *Pair) SwapFrom(sender Address, amount0In, amount1In, amount0Out, amount1Out *big.Int) (amount0, amount1 *big.Int, err error) {
+	if err := normalizeAddresses(&sender); err != nil {
+		return nil, nil, err
+	}
+	return p.withSender(sender).Swap(amount0In, amount1In, amount0Out, amount1Out)
+}
+
+// withSender returns a view of the pair swapping for sender.
+func (p *Pair) withSender(sender Address) *Pair {
+	if sender == p.sender {
+		return p
+	}
+	view := *p
+	view.sender = sender
+	return &view
+}
+
+// inJournal returns a view of the pair whose changes deliver their events,
+// audit records, metrics and logs when j commits.
+func (p *Pair) inJournal(j *journal) *Pair {
+	if j == p.journal {
+		return p
+	}
+	view := *p
+	view.journal = j
+	return &view
+}
+
+// deliver runs fn, which delivers an event, audit record, metric or log of
+// a change, once the operation the change is part of commits.
+func (p *Pair) deliver(fn func()) {
+	if p.journal != nil {
+		service := p.service
+		p.journal.deliver(func() { service.deliver(fn) })
+		return
+	}
+	p.service.deliver(fn)
+}
+
+// SwapCallee is called by SwapWithCallback once the outputs are sent and
+// returns the amounts paid back to the pair, as IUniswapV2Callee does. It
+// runs while the swap holds the pair and must not start another Mint, Burn,
+// Swap or Sync of it, as the lock modifier of the contract forbids.
+type SwapCallee func(amount0Out, amount1Out *big.Int) (repay0, repay1 *big.Int, err error)
+
+// SwapWithCallback is a flash swap: the repaid amounts of callee are added to
+// the inputs before the K check, so the outputs may be borrowed and paid
+// back in either token within the call. A nil callee makes it a plain Swap.
+func (p *Pair) SwapWithCallback(amount0In, amount1In, amount0Out, amount1Out *big.Int, callee SwapCallee) (amount0, amount1 *big.Int, err error) {
+	return p.tracedSwap(nil, "pair.swap", amount0In, amount1In, amount0Out, amount1Out, callee)
+}
+
+func (p *Pair) swapWithCallback(amount0In, amount1In, amount0Out, amount1Out *big.Int, callee SwapCallee) (amount0, amount1 *big.Int, err error) {
+	p, release := p.holdOp()
+	defer release()
+	in0, in1 := amount0In, amount1In
+	defer p.wrapError(&err, "swap", func() errorAmounts {
+		return errorAmounts{}.set(p, "amount_in", in0, in1).set(p, "amount_out", amount0Out, amount1Out)
+	})
+	if err := checkAmounts(amount0In, amount1In, amount0Out, amount1Out); err != nil {
+		return nil, nil, err
+	}
 	if amount0Out.Sign() != 1 && amount1Out.Sign() != 1 {
 		return nil, nil, ErrorInsufficientOutputAmount
 	}
+	if p.drained() {
+		return nil, nil, ErrorInactivePair
+	}
+	for _, hooks := range p.service.operationHooks() {
+		if hooks.BeforeSwap != nil {
+			err := hooks.BeforeSwap(p, SwapAmounts{Amount0In: amount0In, Amount1In: amount1In, Amount0Out: amount0Out, Amount1Out: amount1Out})
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
 
 	reserve0, reserve1 := p.Reserves()
 
@@ -263,6 +729,35 @@ func (p *Pair) Swap(amount0In, amount1In, amount0Out, amount1Out *big.Int) (amou
 		return nil, nil, ErrorInsufficientLiquidity
 	}
 
+	if callee != nil {
+		repay0, repay1, err := callee(new(big.Int).Set(amount0Out), new(big.Int).Set(amount1Out))
+		if err != nil {
+			return nil, nil, err
+		}
+		if repay0 != nil {
+			if repay0.Sign() == -1 {
+				return nil, nil, ErrorInsufficientInputAmount
+			}
+			amount0In = new(big.Int).Add(amount0In, repay0)
+		}
+		if repay1 != nil {
+			if repay1.Sign() == -1 {
+				return nil, nil, ErrorInsufficientInputAmount
+			}
+			amount1In = new(big.Int).Add(amount1In, repay1)
+		}
+	}
+
+	tax0, tax1, err := p.swapTax(amount0In, amount1In, amount0Out, amount1Out)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the inputs are logged with the repayments and before the taxes,
+	// which are taken again on replay
+	walAmounts := []*big.Int{amount0In, amount1In, amount0Out, amount1Out}
+	amount0In = new(big.Int).Sub(amount0In, tax0)
+	amount1In = new(big.Int).Sub(amount1In, tax1)
+
 	amount0 = new(big.Int).Sub(amount0In, amount0Out)
 	amount1 = new(big.Int).Sub(amount1In, amount1Out)
 
@@ -270,18 +765,148 @@ func (p *Pair) Swap(amount0In, amount1In, amount0Out, amount1Out *big.Int) (amou
 		return nil, nil, ErrorInsufficientInputAmount
 	}
 
-	balance0Adjusted := new(big.Int).Sub(new(big.Int).Mul(new(big.Int).Add(amount0, reserve0), big.NewInt(1000)), new(big.Int).Mul(amount0In, big.NewInt(3)))
-	balance1Adjusted := new(big.Int).Sub(new(big.Int).Mul(new(big.Int).Add(amount1, reserve1), big.NewInt(1000)), new(big.Int).Mul(amount1In, big.NewInt(3)))
-
-	if new(big.Int).Mul(balance0Adjusted, balance1Adjusted).Cmp(new(big.Int).Mul(new(big.Int).Mul(reserve0, reserve1), big.NewInt(1000000))) == -1 {
-		return nil, nil, ErrorK
+	verify := VerifySwap
+	if p.service.swapVerifier != nil {
+		verify = p.service.swapVerifier
+	}
+	err = verify(reserve0, reserve1, SwapAmounts{Amount0In: amount0In, Amount1In: amount1In, Amount0Out: amount0Out, Amount1Out: amount1Out}, p.fee)
+	if err != nil {
+		if err == ErrorK {
+			// the failure is reported even if the operation is reverted
+			if p.service.metrics != nil {
+				p.service.metrics.failK(p)
+			}
+			p.inJournal(nil).log(LogWarn, "swap failed K check", addressZero, append(p.amountFields("amount_in", amount0In, amount1In), p.amountFields("amount_out", amount0Out, amount1Out)...)...)
+		}
+		return nil, nil, err
 	}
 
+	if err := p.checkLimits(amount0, amount1, big.NewInt(0)); err != nil {
+		return nil, nil, err
+	}
+	if err := p.checkAccumulators(); err != nil {
+		return nil, nil, err
+	}
+	p.saveForOp()
+	if err := p.writeWAL("swap", addressZero, walAmounts...); err != nil {
+		return nil, nil, err
+	}
 	p.update(amount0, amount1)
+	if metrics := p.service.metrics; metrics != nil {
+		p.deliver(func() { metrics.swap(p, amount0In, amount1In) })
+	}
+	if p.service.logger != nil {
+		level := LogDebug
+		if largeSwap(reserve0, reserve1, amount0Out, amount1Out) {
+			level = LogInfo
+		}
+		p.log(level, "swap", addressZero, append(p.amountFields("amount_in", amount0In, amount1In), p.amountFields("amount_out", amount0Out, amount1Out)...)...)
+	}
+	p.audit("swap", addressZero, auditAmounts{}.set(p, "_in", amount0In, amount1In).set(p, "_out", amount0Out, amount1Out))
+	p.emit(Event{Kind: EventSwap, Address: p.sender, Amount0In: amount0In, Amount1In: amount1In, Amount0Out: amount0Out, Amount1Out: amount1Out})
+	for _, hooks := range p.service.operationHooks() {
+		if hooks.AfterSwap != nil {
+			hooks.AfterSwap(p, SwapAmounts{Amount0In: amount0In, Amount1In: amount1In, Amount0Out: amount0Out, Amount1Out: amount1Out})
+		}
+	}
 
 	return amount0, amount1, nil
 }
 
+// SwapAmounts are the amounts of a swap, inputs net of swap taxes.
+type SwapAmounts struct {
+	Amount0In, Amount1In, Amount0Out, Amount1Out *big.Int
+}
+
+// SwapVerifier checks the invariant of a swap against the reserves before it.
+// Values are in the token order of the swapped pair view and must not be
+// modified.
+type SwapVerifier func(reserve0, reserve1 *big.Int, amounts SwapAmounts, fee Fee) error
+
+// VerifySwap is the constant product check of the contract: the balances,
+// less the fee on the inputs, must keep K from decreasing.
+func VerifySwap(reserve0, reserve1 *big.Int, amounts SwapAmounts, fee Fee) error {
+	balance0 := new(big.Int).Sub(new(big.Int).Add(reserve0, amounts.Amount0In), amounts.Amount0Out)
+	balance1 := new(big.Int).Sub(new(big.Int).Add(reserve1, amounts.Amount1In), amounts.Amount1Out)
+	balance0Adjusted := new(big.Int).Sub(new(big.Int).Mul(balance0, fee.denominator()), new(big.Int).Mul(amounts.Amount0In, fee.numerator()))
+	balance1Adjusted := new(big.Int).Sub(new(big.Int).Mul(balance1, fee.denominator()), new(big.Int).Mul(amounts.Amount1In, fee.numerator()))
+
+	if new(big.Int).Mul(balance0Adjusted, balance1Adjusted).Cmp(new(big.Int).Mul(new(big.Int).Mul(reserve0, reserve1), new(big.Int).Mul(fee.denominator(), fee.denominator()))) == -1 {
+		return ErrorK
+	}
+	return nil
+}
+
+func (p *Pair) Quote(amount0 *big.Int) (amount1 *big.Int, err error) {
+	if err := checkAmounts(amount0); err != nil {
+		return nil, err
+	}
+	reserve0, reserve1 := p.Reserves()
+	return quote(amount0, reserve0, reserve1)
+}
+
+func (p *Pair) GetAmountOut(amountIn *big.Int) (amountOut *big.Int, err error) {
+	if err := checkAmounts(amountIn); err != nil {
+		return nil, err
+	}
+	if p.drained() {
+		return nil, ErrorInactivePair
+	}
+	reserve0, reserve1 := p.Reserves()
+	return getAmountOut(amountIn, reserve0, reserve1, p.fee)
+}
+
+func (p *Pair) GetAmountIn(amountOut *big.Int) (amountIn *big.Int, err error) {
+	if err := checkAmounts(amountOut); err != nil {
+		return nil, err
+	}
+	if p.drained() {
+		return nil, ErrorInactivePair
+	}
+	reserve0, reserve1 := p.Reserves()
+	return getAmountIn(amountOut, reserve0, reserve1, p.fee)
+}
+
+func quote(amountA, reserveA, reserveB *big.Int) (*big.Int, error) {
+	if amountA.Sign() != 1 {
+		return nil, ErrorInsufficientAmount
+	}
+	if reserveA.Sign() != 1 || reserveB.Sign() != 1 {
+		return nil, ErrorInsufficientLiquidity
+	}
+
+	amountB := new(big.Int).Mul(amountA, reserveB)
+	return amountB.Div(amountB, reserveA), nil
+}
+
+func getAmountOut(amountIn, reserveIn, reserveOut *big.Int, fee Fee) (*big.Int, error) {
+	if amountIn.Sign() != 1 {
+		return nil, ErrorInsufficientInputAmount
+	}
+	if reserveIn.Sign() != 1 || reserveOut.Sign() != 1 {
+		return nil, ErrorInsufficientLiquidity
+	}
+
+	amountInWithFee := new(big.Int).Mul(amountIn, fee.remainder())
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Add(new(big.Int).Mul(reserveIn, fee.denominator()), amountInWithFee)
+	return numerator.Div(numerator, denominator), nil
+}
+
+func getAmountIn(amountOut, reserveIn, reserveOut *big.Int, fee Fee) (*big.Int, error) {
+	if amountOut.Sign() != 1 {
+		return nil, ErrorInsufficientOutputAmount
+	}
+	if reserveIn.Sign() != 1 || reserveOut.Cmp(amountOut) != 1 {
+		return nil, ErrorInsufficientLiquidity
+	}
+
+	numerator := new(big.Int).Mul(new(big.Int).Mul(reserveIn, amountOut), fee.denominator())
+	denominator := new(big.Int).Mul(new(big.Int).Sub(reserveOut, amountOut), fee.remainder())
+	amountIn := numerator.Div(numerator, denominator)
+	return amountIn.Add(amountIn, big.NewInt(1)), nil
+}
+
 func (p *Pair) mint(address Address, value *big.Int) {
 	p.pairData.Lock()
 	defer p.pairData.Unlock()
@@ -289,7 +914,7 @@ func (p *Pair) mint(address Address, value *big.Int) {
 	p.muBalance.Lock()
 	defer p.muBalance.Unlock()
 
-	p.isDirtyBalances = true
+	p.touchBalance(address)
 	p.isDirty = true
 	p.totalSupply.Add(p.totalSupply, value)
 	balance := p.balances[address]
@@ -297,6 +922,7 @@ func (p *Pair) mint(address Address, value *big.Int) {
 		p.balances[address] = big.NewInt(0)
 	}
 	p.balances[address].Add(p.balances[address], value)
+	p.service.updatePosition(address, p.key.sort(), p.balances[address])
 }
 
 func (p *Pair) burn(address Address, value *big.Int) {
@@ -305,10 +931,42 @@ func (p *Pair) burn(address Address, value *big.Int) {
 	p.muBalance.Lock()
 	defer p.muBalance.Unlock()
 
-	p.isDirtyBalances = true
+	p.touchBalance(address)
 	p.isDirty = true
 	p.balances[address].Sub(p.balances[address], value)
 	p.totalSupply.Sub(p.totalSupply, value)
+	p.service.updatePosition(address, p.key.sort(), p.balances[address])
+}
+
+// touchBalance marks the LP balance of address changed since the last
+// commit. The caller holds muBalance.
+func (p *Pair) touchBalance(address Address) {
+	p.isDirtyBalances = true
+	if p.dirtyAddresses == nil {
+		p.dirtyAddresses = map[Address]struct{}{}
+	}
+	p.dirtyAddresses[address] = struct{}{}
+}
+
+// touchBalances marks all LP balances changed since the last commit.
+func (p *Pair) touchBalances() {
+	p.isDirtyBalances, p.allBalances = true, true
+}
+
+// dirtyBalances returns the LP balances changed since the last commit, nil
+// for the addresses holding none any more.
+func (p *Pair) dirtyBalances() map[Address]*big.Int {
+	p.muBalance.RLock()
+	defer p.muBalance.RUnlock()
+
+	balances := make(map[Address]*big.Int, len(p.dirtyAddresses))
+	for address := range p.dirtyAddresses {
+		balances[address] = nil
+		if balance, ok := p.balances[address]; ok {
+			balances[address] = new(big.Int).Set(balance)
+		}
+	}
+	return balances
 }
 
 func (p *Pair) update(amount0, amount1 *big.Int) {
@@ -316,8 +974,32 @@ func (p *Pair) update(amount0, amount1 *big.Int) {
 	defer p.pairData.Unlock()
 
 	p.isDirty = true
+	p.accumulate(p.service.now())
 	p.reserve0.Add(p.reserve0, amount0)
 	p.reserve1.Add(p.reserve1, amount1)
+	p.service.routes.touch(p.key.TokenA, p.key.TokenB)
+}
+
+// checkLimits fails with ErrorOverflow if adding amount0 and amount1 to the
+// reserves or liquidity to the total supply would exceed the limits of
+// WithReserveLimits.
+func (p *Pair) checkLimits(amount0, amount1, liquidity *big.Int) error {
+	reserveLimit, supplyLimit := p.service.reserveLimit, p.service.supplyLimit
+	if reserveLimit == nil && supplyLimit == nil {
+		return nil
+	}
+
+	p.pairData.RLock()
+	defer p.pairData.RUnlock()
+	if reserveLimit != nil {
+		if new(big.Int).Add(p.reserve0, amount0).Cmp(reserveLimit) == 1 || new(big.Int).Add(p.reserve1, amount1).Cmp(reserveLimit) == 1 {
+			return ErrorOverflow
+		}
+	}
+	if supplyLimit != nil && new(big.Int).Add(p.totalSupply, liquidity).Cmp(supplyLimit) == 1 {
+		return ErrorOverflow
+	}
+	return nil
 }
 
 func (p *Pair) Amounts(liquidity *big.Int) (amount0 *big.Int, amount1 *big.Int) {
@@ -1,6 +1,8 @@
 package uniswapV2
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 	"reflect"
@@ -110,6 +112,29 @@ func TestPair_Mint(t *testing.T) {
 	}
 }
 
+func TestPair_Mint_secondDeposit(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	liquidity, err := pair.Mint("address2", big.NewInt(5e17), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if liquidity == nil || liquidity.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("liquidity want %s, got %s", big.NewInt(1e18), liquidity)
+	}
+	if pair.Balance("address2").Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("balance want %s, got %s", big.NewInt(1e18), pair.Balance("address2"))
+	}
+}
+
 func TestPair_Swap_token0(t *testing.T) {
 	tableTests := []struct {
 		token0, token1             Token
@@ -144,7 +169,7 @@ func TestPair_Swap_token0(t *testing.T) {
 			}
 
 			_, _, err = pair.Swap(tt.swap0Amount, tt.swap1Amount, tt.expected0OutputAmount, new(big.Int).Add(tt.expected1OutputAmount, big.NewInt(1)))
-			if err != ErrorK {
+			if !errors.Is(err, ErrorK) {
 				t.Fatalf("failed with %v; want error %v", err, ErrorK)
 			}
 
@@ -208,7 +233,7 @@ func TestPair_Swap_token1(t *testing.T) {
 			}
 
 			_, _, err = pair.Swap(tt.swap0Amount, tt.swap1Amount, new(big.Int).Add(tt.expected0OutputAmount, big.NewInt(1)), tt.expected1OutputAmount)
-			if err != ErrorK {
+			if !errors.Is(err, ErrorK) {
 				t.Fatalf("failed with %v; want error %v", err, ErrorK)
 			}
 			amount0, amount1, err := pair.Swap(tt.swap0Amount, tt.swap1Amount, tt.expected0OutputAmount, tt.expected1OutputAmount)
@@ -358,3 +383,522 @@ func TestSwap_Pair_reverseKey(t *testing.T) {
 		t.Error("isDirty not equal")
 	}
 }
+
+func TestUniswapV2_CreatePair_reverseKey(t *testing.T) {
+	service := New()
+	_, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if service.Pair(0, 1) == nil {
+		t.Fatal("pair is nil")
+	}
+	_, err = service.CreatePair(0, 1)
+	if err != ErrorPairExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairExists)
+	}
+}
+
+func TestPair_GetAmountOut(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.GetAmountOut(big.NewInt(1e18))
+	if err != ErrorInsufficientLiquidity {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+
+	_, err = pair.Mint("address", big.NewInt(5e18), new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amountOut, err := pair.GetAmountOut(big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountOut.Cmp(big.NewInt(1662497915624478906)) != 0 {
+		t.Errorf("amountOut want %s, got %s", big.NewInt(1662497915624478906), amountOut)
+	}
+
+	amountOut, err = service.Pair(2, 1).GetAmountOut(big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountOut.Cmp(big.NewInt(453305446940074565)) != 0 {
+		t.Errorf("amountOut want %s, got %s", big.NewInt(453305446940074565), amountOut)
+	}
+
+	_, err = pair.GetAmountOut(big.NewInt(0))
+	if err != ErrorInsufficientInputAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientInputAmount)
+	}
+}
+
+func TestPair_GetAmountIn(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(5e18), new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amountIn, err := pair.GetAmountIn(big.NewInt(1662497915624478906))
+	if err != nil {
+		t.Fatal(err)
+	}
+	amountOut, err := pair.GetAmountOut(amountIn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountOut.Cmp(big.NewInt(1662497915624478906)) == -1 {
+		t.Errorf("amountOut for amountIn %s is %s, want at least %s", amountIn, amountOut, big.NewInt(1662497915624478906))
+	}
+
+	_, _, err = pair.Swap(amountIn, big.NewInt(0), big.NewInt(0), big.NewInt(1662497915624478906))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, reserve1 := pair.Reserves()
+	_, err = pair.GetAmountIn(reserve1)
+	if err != ErrorInsufficientLiquidity {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+}
+
+func TestPair_Quote(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Quote(big.NewInt(1e18))
+	if err != ErrorInsufficientLiquidity {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amount1, err := pair.Quote(big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount1.Cmp(big.NewInt(4e17)) != 0 {
+		t.Errorf("amount1 want %s, got %s", big.NewInt(4e17), amount1)
+	}
+
+	amount0, err := service.Pair(1, 0).Quote(big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount0.Cmp(big.NewInt(25e15)) != 0 {
+		t.Errorf("amount0 want %s, got %s", big.NewInt(25e15), amount0)
+	}
+
+	_, err = pair.Quote(big.NewInt(0))
+	if err != ErrorInsufficientAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientAmount)
+	}
+}
+
+func TestPair_Swap_fee(t *testing.T) {
+	service := New()
+	_, err := service.CreatePair(1, 2, WithFee(1000, 1000))
+	if err != ErrorInvalidFee {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidFee)
+	}
+
+	pair, err := service.CreatePair(1, 2, WithFee(25, 10000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(5e18), new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedOutputAmount := big.NewInt(1663192997082117548)
+	amountOut, err := pair.GetAmountOut(big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountOut.Cmp(expectedOutputAmount) != 0 {
+		t.Errorf("amountOut want %s, got %s", expectedOutputAmount, amountOut)
+	}
+
+	_, _, err = pair.Swap(big.NewInt(1e18), big.NewInt(0), big.NewInt(0), new(big.Int).Add(expectedOutputAmount, big.NewInt(1)))
+	if !errors.Is(err, ErrorK) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorK)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e18), big.NewInt(0), big.NewInt(0), expectedOutputAmount)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUniswapV2_CreatePair_admission(t *testing.T) {
+	errDenied := errors.New("denied")
+	service := New(WithMaxPairs(2), WithAdmissionPolicy(func(coinA, coinB Token) error {
+		if coinA == 9 || coinB == 9 {
+			return errDenied
+		}
+		return nil
+	}))
+
+	_, err := service.CreatePair(0, 9)
+	if err != errDenied {
+		t.Fatalf("failed with %v; want error %v", err, errDenied)
+	}
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}} {
+		if _, err := service.CreatePair(tokens[0], tokens[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = service.CreatePair(1, 0)
+	if err != ErrorPairExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairExists)
+	}
+	_, err = service.CreatePair(2, 3)
+	if err != ErrorTooManyPairs {
+		t.Fatalf("failed with %v; want error %v", err, ErrorTooManyPairs)
+	}
+}
+
+func TestPair_Mint_minInitialLiquidity(t *testing.T) {
+	service := New(WithMinInitialLiquidity(big.NewInt(1e18)))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if !errors.Is(err, ErrorInsufficientInitialLiquidity) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientInitialLiquidity)
+	}
+	if pair.TotalSupply().Sign() != 0 {
+		t.Errorf("total supply want 0, got %s", pair.TotalSupply())
+	}
+
+	_, err = pair.Mint("address", big.NewInt(2e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e3), big.NewInt(1e3))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPair_SwapWithCallback(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	borrowed := big.NewInt(1e17)
+	errCallee := errors.New("callee failed")
+	for _, tt := range []struct {
+		repay0, repay1 *big.Int
+		calleeErr      error
+		err            error
+	}{
+		{big.NewInt(1e17 + 1), nil, nil, ErrorK},
+		{nil, nil, errCallee, errCallee},
+		{big.NewInt(-1), nil, nil, ErrorInsufficientInputAmount},
+		{big.NewInt(100301e12), nil, nil, nil},
+	} {
+		_, _, err = pair.SwapWithCallback(big.NewInt(0), big.NewInt(0), borrowed, big.NewInt(0), func(amount0Out, amount1Out *big.Int) (*big.Int, *big.Int, error) {
+			if amount0Out.Cmp(borrowed) != 0 || amount1Out.Sign() != 0 {
+				t.Errorf("outputs want %s, 0, got %s, %s", borrowed, amount0Out, amount1Out)
+			}
+			return tt.repay0, tt.repay1, tt.calleeErr
+		})
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("failed with %v; want error %v", err, tt.err)
+		}
+	}
+
+	reserve0, _ := pair.Reserves()
+	if reserve0.Cmp(big.NewInt(1000301e12)) != 0 {
+		t.Errorf("reserve0 want %s, got %s", big.NewInt(1000301e12), reserve0)
+	}
+}
+
+func TestPair_Swap_swapVerifier(t *testing.T) {
+	errSum := errors.New("SUM")
+	constantSum := func(reserve0, reserve1 *big.Int, amounts SwapAmounts, fee Fee) error {
+		before := new(big.Int).Add(reserve0, reserve1)
+		after := new(big.Int).Add(before, amounts.Amount0In)
+		after.Add(after, amounts.Amount1In).Sub(after, amounts.Amount0Out).Sub(after, amounts.Amount1Out)
+		if after.Cmp(before) == -1 {
+			return errSum
+		}
+		return nil
+	}
+
+	service := New(WithSwapVerifier(constantSum))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = pair.Swap(big.NewInt(5e17), big.NewInt(0), big.NewInt(0), big.NewInt(5e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17+1))
+	if !errors.Is(err, errSum) {
+		t.Fatalf("failed with %v; want error %v", err, errSum)
+	}
+
+	reserve0, reserve1 := pair.Reserves()
+	if err := VerifySwap(reserve0, reserve1, SwapAmounts{Amount0In: big.NewInt(1e17), Amount1In: big.NewInt(0), Amount0Out: big.NewInt(0), Amount1Out: big.NewInt(1e17)}, DefaultFee); err != ErrorK {
+		t.Fatalf("failed with %v; want error %v", err, ErrorK)
+	}
+}
+
+func TestUniswapV2_TokenLess(t *testing.T) {
+	defer func(less func(a, b Token) bool) { TokenLess = less }(TokenLess)
+	TokenLess = func(a, b Token) bool { return a > b }
+
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CreatePair(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := service.sortedKeys()
+	if len(keys) != 2 || keys[0] != (pairKey{TokenA: 2, TokenB: 1}) || keys[1] != (pairKey{TokenA: 1, TokenB: 0}) {
+		t.Errorf("pairs want 2/1, 1/0, got %v", keys)
+	}
+	if reserve0, _ := service.Pair(1, 0).Reserves(); reserve0.Cmp(big.NewInt(4e18)) != 0 {
+		t.Errorf("reserve0 want 4e18, got %s", reserve0)
+	}
+	state := service.pairs[pairKey{TokenA: 1, TokenB: 0}].state()
+	if state.Token0 != 1 || state.Reserve0.Cmp(big.NewInt(4e18)) != 0 {
+		t.Errorf("token0 want 1 with reserve 4e18, got %d with %s", state.Token0, state.Reserve0)
+	}
+
+	loaded := New()
+	if err := loaded.Unmarshal(marshal(t, service)); err != nil {
+		t.Fatal(err)
+	}
+	if stateRoot(t, loaded) != stateRoot(t, service) {
+		t.Error("state root of the loaded service differs")
+	}
+}
+
+func TestPair_IsActive(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.IsActive() {
+		t.Error("empty pair is active")
+	}
+	if _, _, err := pair.Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(1)); !errors.Is(err, ErrorInsufficientLiquidity) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+
+	liquidity, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pair.IsActive() {
+		t.Error("pair with liquidity is not active")
+	}
+	_, _, err = pair.Burn("alice", liquidity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.IsActive() {
+		t.Error("drained pair is active")
+	}
+	if _, _, err := pair.Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(1)); !errors.Is(err, ErrorInactivePair) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInactivePair)
+	}
+	if _, err := service.Pair(1, 0).GetAmountOut(big.NewInt(1e16)); err != ErrorInactivePair {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInactivePair)
+	}
+	if _, _, err := service.FindBestPath(0, 1, big.NewInt(1e16), 2); err != ErrorPathNotFound {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPathNotFound)
+	}
+
+	_, err = pair.Mint("bob", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pair.IsActive() {
+		t.Error("revived pair is not active")
+	}
+}
+
+func TestUniswapV2_SortedPairs(t *testing.T) {
+	first, second := New(), New()
+	for _, tokens := range [][2]Token{{3, 2}, {0, 1}, {1, 2}} {
+		if _, err := first.CreatePair(tokens[0], tokens[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tokens := range [][2]Token{{1, 2}, {2, 3}, {1, 0}} {
+		if _, err := second.CreatePair(tokens[0], tokens[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := second.CreatePairWithFee(0, 1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.CreatePairWithFee(1, 0, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := second.ExportJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	imported := New()
+	if err := imported.ImportJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []pairKey{{TokenA: 0, TokenB: 1}, {TokenA: 0, TokenB: 1, Fee: 5}, {TokenA: 1, TokenB: 2}, {TokenA: 2, TokenB: 3}}
+	for _, service := range []*UniswapV2{first, second, imported} {
+		if got := service.SortedPairs(); !reflect.DeepEqual(got, want) {
+			t.Errorf("pairs want %v, got %v", want, got)
+		}
+	}
+}
+
+func TestUniswapV2_PairsPage(t *testing.T) {
+	service := New()
+	for i := Token(0); i < 5; i++ {
+		if _, err := service.CreatePair(i, i+1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if service.PairsCount() != 5 {
+		t.Fatalf("count want 5, got %d", service.PairsCount())
+	}
+
+	tests := []struct {
+		offset, limit int
+		want          []pairKey
+	}{
+		{0, 2, []pairKey{{TokenA: 0, TokenB: 1}, {TokenA: 1, TokenB: 2}}},
+		{3, 10, []pairKey{{TokenA: 3, TokenB: 4}, {TokenA: 4, TokenB: 5}}},
+		{5, 2, nil},
+		{-1, 2, nil},
+		{0, 0, nil},
+	}
+	for _, test := range tests {
+		if got := service.PairsPage(test.offset, test.limit); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("page %d/%d want %v, got %v", test.offset, test.limit, test.want, got)
+		}
+	}
+}
+
+func TestPair_reserveLimits(t *testing.T) {
+	service := New(WithReserveLimits(big.NewInt(2e18), big.NewInt(15e17)))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18)); !errors.Is(err, ErrorOverflow) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorOverflow)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(5e17), big.NewInt(5e17)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := pair.Swap(big.NewInt(6e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17)); !errors.Is(err, ErrorOverflow) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorOverflow)
+	}
+	if err := pair.Sync(big.NewInt(21e17), big.NewInt(15e17)); !errors.Is(err, ErrorOverflow) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorOverflow)
+	}
+
+	reserve0, reserve1 := pair.Reserves()
+	if reserve0.Cmp(big.NewInt(15e17)) != 0 || reserve1.Cmp(big.NewInt(15e17)) != 0 {
+		t.Errorf("reserves want %s, %s, got %s, %s", big.NewInt(15e17), big.NewInt(15e17), reserve0, reserve1)
+	}
+	if totalSupply := pair.TotalSupply(); totalSupply.Cmp(big.NewInt(15e17)) != 0 {
+		t.Errorf("total supply want %s, got %s", big.NewInt(15e17), totalSupply)
+	}
+}
+
+func TestPair_BurnTo(t *testing.T) {
+	events := NewEvents()
+	var burns []Event
+	events.Listen(func(event Event) {
+		if event.Kind == EventBurn {
+			burns = append(burns, event)
+		}
+	})
+	service := New(WithEvents(events))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	liquidity, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amount0, amount1, err := pair.BurnTo("alice", big.NewInt(1e17), "router")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount0.Cmp(big.NewInt(1e17)) != 0 || amount1.Cmp(big.NewInt(1e17)) != 0 {
+		t.Errorf("amounts want 1e17, 1e17, got %s, %s", amount0, amount1)
+	}
+	if balance := pair.Balance("alice"); balance.Cmp(new(big.Int).Sub(liquidity, big.NewInt(1e17))) != 0 {
+		t.Errorf("balance of alice want %s, got %s", new(big.Int).Sub(liquidity, big.NewInt(1e17)), balance)
+	}
+	if balance := pair.Balance("router"); balance != nil {
+		t.Errorf("balance of router want none, got %s", balance)
+	}
+	if _, _, err := pair.BurnTo("router", big.NewInt(1), "alice"); !errors.Is(err, ErrorInsufficientLiquidityBurned) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidityBurned)
+	}
+	if _, _, err := pair.Burn("alice", big.NewInt(1e17)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(burns) != 2 {
+		t.Fatalf("burn events want 2, got %d", len(burns))
+	}
+	if burns[0].Address != "alice" || burns[0].To != "router" {
+		t.Errorf("BurnTo event want alice to router, got %s to %s", burns[0].Address, burns[0].To)
+	}
+	if burns[1].Address != "alice" || burns[1].To != "alice" {
+		t.Errorf("Burn event want alice to alice, got %s to %s", burns[1].Address, burns[1].To)
+	}
+}
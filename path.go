@@ -0,0 +1,120 @@
+package uniswapV2
+
+import (
+	"context"
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrorPathNotFound = errors.New("PATH_NOT_FOUND")
+)
+
+func (s *UniswapV2) FindBestPath(tokenIn, tokenOut Token, amountIn *big.Int, maxHops int) (path []Token, amountOut *big.Int, err error) {
+	path, amountOut, _, err = s.FindBestPathWithLimits(context.Background(), tokenIn, tokenOut, amountIn, maxHops, PathLimits{})
+	return path, amountOut, err
+}
+
+// PathLimits bounds the work of a path search. Zero means no limit.
+type PathLimits struct {
+	MaxPairs int
+}
+
+// FindBestPathWithLimits is FindBestPath that stops once limits.MaxPairs pairs
+// have been examined or ctx is done, returning the best path found so far.
+// truncated reports whether the search was cut short.
+func (s *UniswapV2) FindBestPathWithLimits(ctx context.Context, tokenIn, tokenOut Token, amountIn *big.Int, maxHops int, limits PathLimits) (path []Token, amountOut *big.Int, truncated bool, err error) {
+	if tokenIn == tokenOut || maxHops < 1 {
+		return nil, nil, false, ErrorInvalidPath
+	}
+	if err := checkAmounts(amountIn); err != nil {
+		return nil, nil, false, err
+	}
+	if amountIn.Sign() != 1 {
+		return nil, nil, false, ErrorInsufficientInputAmount
+	}
+
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	if !s.routes.connected(tokenIn, tokenOut) {
+		return nil, nil, false, ErrorPathNotFound
+	}
+	key := routeKey{tokenIn: tokenIn, tokenOut: tokenOut, maxHops: maxHops, magnitude: amountIn.BitLen()}
+	if s.routeCache != nil {
+		if path, amountOut, ok := s.cachedPath(key, amountIn); ok {
+			return path, amountOut, false, nil
+		}
+	}
+	search := &pathSearch{
+		ctx:      ctx,
+		service:  s,
+		tokenOut: tokenOut,
+		maxHops:  maxHops,
+		maxPairs: limits.MaxPairs,
+		visited:  map[Token]bool{tokenIn: true},
+	}
+	search.walk([]Token{tokenIn}, amountIn)
+
+	if search.best == nil {
+		return nil, nil, search.truncated, ErrorPathNotFound
+	}
+	if s.routeCache != nil && !search.truncated {
+		s.cachePath(key, append([]Token(nil), search.best...))
+	}
+	return search.best, search.bestAmount, search.truncated, nil
+}
+
+type pathSearch struct {
+	ctx        context.Context
+	service    *UniswapV2
+	tokenOut   Token
+	maxHops    int
+	maxPairs   int
+	examined   int
+	truncated  bool
+	visited    map[Token]bool
+	best       []Token
+	bestAmount *big.Int
+}
+
+func (ps *pathSearch) walk(path []Token, amount *big.Int) {
+	if len(path) > ps.maxHops {
+		return
+	}
+
+	last := path[len(path)-1]
+	for _, next := range ps.service.neighbours(last) {
+		if ps.visited[next] {
+			continue
+		}
+		if ps.exhausted() {
+			ps.truncated = true
+			return
+		}
+		ps.examined++
+		_, amountOut, err := ps.service.bestPairOut(last, next, amount)
+		if err != nil {
+			continue
+		}
+
+		nextPath := append(append([]Token(nil), path...), next)
+		if next == ps.tokenOut {
+			if ps.bestAmount == nil || amountOut.Cmp(ps.bestAmount) == 1 {
+				ps.best, ps.bestAmount = nextPath, amountOut
+			}
+			continue
+		}
+
+		ps.visited[next] = true
+		ps.walk(nextPath, amountOut)
+		ps.visited[next] = false
+	}
+}
+
+func (ps *pathSearch) exhausted() bool {
+	if ps.maxPairs > 0 && ps.examined >= ps.maxPairs {
+		return true
+	}
+	return ps.ctx.Err() != nil
+}
@@ -0,0 +1,102 @@
+package uniswapV2
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestUniswapV2_FindBestPath(t *testing.T) {
+	service := New()
+	for _, tt := range []struct {
+		tokenA, tokenB   Token
+		amountA, amountB int64
+	}{
+		{0, 1, 1e18, 1e18},
+		{1, 2, 5e18, 5e18},
+		{0, 2, 1e18, 1e17},
+		{2, 3, 5e18, 5e18},
+	} {
+		pair, err := service.CreatePair(tt.tokenA, tt.tokenB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("address", big.NewInt(tt.amountA), big.NewInt(tt.amountB))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, amountOut, err := service.FindBestPath(0, 2, big.NewInt(1e16), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(path, []Token{0, 1, 2}) {
+		t.Errorf("path want %v, got %v", []Token{0, 1, 2}, path)
+	}
+	amounts, _, err := service.amountsOut(big.NewInt(1e16), []Token{0, 1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountOut.Cmp(amounts[2]) != 0 {
+		t.Errorf("amountOut want %s, got %s", amounts[2], amountOut)
+	}
+
+	path, _, err = service.FindBestPath(0, 2, big.NewInt(1e16), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(path, []Token{0, 2}) {
+		t.Errorf("path want %v, got %v", []Token{0, 2}, path)
+	}
+
+	_, _, err = service.FindBestPath(0, 3, big.NewInt(1e16), 1)
+	if err != ErrorPathNotFound {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPathNotFound)
+	}
+	_, _, err = service.FindBestPath(0, 4, big.NewInt(1e16), 3)
+	if err != ErrorPathNotFound {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPathNotFound)
+	}
+}
+
+func TestUniswapV2_FindBestPathWithLimits(t *testing.T) {
+	service := New()
+	for _, key := range []pairKey{{TokenA: 0, TokenB: 1}, {TokenA: 1, TokenB: 2}, {TokenA: 0, TokenB: 2}} {
+		pair, err := service.CreatePair(key.TokenA, key.TokenB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, _, truncated, err := service.FindBestPathWithLimits(context.Background(), 0, 2, big.NewInt(1e16), 3, PathLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if truncated || !reflect.DeepEqual(path, []Token{0, 2}) {
+		t.Errorf("path want %v, got %v (truncated %v)", []Token{0, 2}, path, truncated)
+	}
+
+	path, _, truncated, err = service.FindBestPathWithLimits(context.Background(), 0, 2, big.NewInt(1e16), 3, PathLimits{MaxPairs: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !truncated || !reflect.DeepEqual(path, []Token{0, 1, 2}) {
+		t.Errorf("path want %v, got %v (truncated %v)", []Token{0, 1, 2}, path, truncated)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, truncated, err = service.FindBestPathWithLimits(ctx, 0, 2, big.NewInt(1e16), 3, PathLimits{})
+	if err != ErrorPathNotFound {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPathNotFound)
+	}
+	if !truncated {
+		t.Error("truncated want true, got false")
+	}
+}
@@ -0,0 +1,127 @@
+package uniswapV2
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrorExpired          = errors.New("EXPIRED")
+	ErrorInvalidSignature = errors.New("INVALID_SIGNATURE")
+	ErrorNilVerifier      = errors.New("NIL_VERIFIER")
+)
+
+// PermitVerifier returns the address whose key produced sig over digest,
+// e.g. by public key recovery of the embedding chain's signature scheme.
+type PermitVerifier func(digest, sig []byte) (signer Address, err error)
+
+// PermitDomain binds permits to one deployment, as the EIP-712 domain
+// separator of UniswapV2ERC20 does: a signature made for a service named
+// differently or running on another chain does not verify.
+type PermitDomain struct {
+	separator []byte
+	verify    PermitVerifier
+}
+
+// NewPermitDomain returns the domain of the service called name on the
+// chain chainID, verifying signatures with verify.
+func NewPermitDomain(name string, chainID uint64, verify PermitVerifier) (*PermitDomain, error) {
+	if verify == nil {
+		return nil, ErrorNilVerifier
+	}
+	hash := sha256.New()
+	hash.Write([]byte("PermitDomain"))
+	_ = binary.Write(hash, binary.BigEndian, uint64(len(name)))
+	hash.Write([]byte(name))
+	_ = binary.Write(hash, binary.BigEndian, chainID)
+	return &PermitDomain{separator: hash.Sum(nil), verify: verify}, nil
+}
+
+// Nonces returns the number of permits used by owner on the pair.
+func (p *Pair) Nonces(owner Address) uint64 {
+	if err := normalizeAddresses(&owner); err != nil {
+		return 0
+	}
+
+	p.muBalance.RLock()
+	defer p.muBalance.RUnlock()
+	return p.nonces[owner]
+}
+
+// PermitDigest is the message an owner signs to approve value to spender
+// within domain. It is the same for both views of the pair and every
+// spelling of the addresses.
+func (p *Pair) PermitDigest(domain *PermitDomain, owner, spender Address, value *big.Int, nonce uint64, deadline int64) []byte {
+	_ = normalizeAddresses(&owner, &spender)
+	key := p.key.sort()
+	hash := sha256.New()
+	hash.Write([]byte("Permit"))
+	if domain != nil {
+		hash.Write(domain.separator)
+	}
+	for _, v := range []uint64{uint64(key.TokenA), uint64(key.TokenB), uint64(key.Fee)} {
+		_ = binary.Write(hash, binary.BigEndian, v)
+	}
+	for _, address := range []Address{owner, spender} {
+		_ = binary.Write(hash, binary.BigEndian, uint64(len(address)))
+		hash.Write([]byte(address))
+	}
+	_ = binary.Write(hash, binary.BigEndian, uint64(len(value.Bytes())))
+	hash.Write(value.Bytes())
+	_ = binary.Write(hash, binary.BigEndian, nonce)
+	_ = binary.Write(hash, binary.BigEndian, deadline)
+	return hash.Sum(nil)
+}
+
+// Permit approves value of the LP tokens of owner to spender with a
+// signature of owner over PermitDigest with its current nonce, as
+// UniswapV2ERC20.permit. deadline is a unix timestamp.
+func (p *Pair) Permit(domain *PermitDomain, owner, spender Address, value *big.Int, deadline int64, sig []byte) error {
+	if domain == nil {
+		return ErrorNilVerifier
+	}
+	if p.service.now().Unix() > deadline {
+		return ErrorExpired
+	}
+	if value == nil {
+		return ErrorNilAmount
+	}
+	if value.Sign() == -1 || value.Cmp(MaxAllowance) == 1 {
+		return ErrorInvalidAmount
+	}
+	if err := normalizeAddresses(&owner, &spender); err != nil {
+		return err
+	}
+	p.saveForOp()
+	if err := p.permit(domain, owner, spender, value, deadline, sig); err != nil {
+		return err
+	}
+
+	p.audit("permit", owner, auditAmounts{"value": value.String(), "spender": string(spender)})
+	return nil
+}
+
+func (p *Pair) permit(domain *PermitDomain, owner, spender Address, value *big.Int, deadline int64, sig []byte) error {
+	p.muBalance.Lock()
+	defer p.muBalance.Unlock()
+
+	nonce := p.nonces[owner]
+	signer, err := domain.verify(p.PermitDigest(domain, owner, spender, value, nonce, deadline), sig)
+	if err != nil {
+		return err
+	}
+	if signer == addressZero || signer != owner {
+		return ErrorInvalidSignature
+	}
+	record := p.walRecord("permit", owner, value)
+	record.To = spender
+	if err := p.service.writeWAL(record); err != nil {
+		return err
+	}
+
+	p.nonces[owner] = nonce + 1
+	p.approve(owner, spender, value)
+	return nil
+}
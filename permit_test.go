@@ -0,0 +1,100 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func testSign(owner Address, digest []byte) []byte {
+	return append([]byte(owner+"|"), digest...)
+}
+
+func testVerify(digest, sig []byte) (Address, error) {
+	i := bytes.IndexByte(sig, '|')
+	if i < 0 || !bytes.Equal(sig[i+1:], digest) {
+		return addressZero, nil
+	}
+	return Address(sig[:i]), nil
+}
+
+func TestPair_Permit(t *testing.T) {
+	now := time.Unix(1000, 0)
+	service := New(WithClock(func() time.Time { return now }))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	domain, err := NewPermitDomain("uniswapV2", 1, testVerify)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := big.NewInt(100)
+	sig := testSign("owner", service.Pair(1, 0).PermitDigest(domain, "owner", "spender", value, 0, 2000))
+
+	for _, tt := range []struct {
+		owner    Address
+		value    *big.Int
+		deadline int64
+		err      error
+	}{
+		{"owner", value, 999, ErrorExpired},
+		{"other", value, 2000, ErrorInvalidSignature},
+		{"owner", big.NewInt(101), 2000, ErrorInvalidSignature},
+		{"owner", value, 2000, nil},
+		{"owner", value, 2000, ErrorInvalidSignature},
+	} {
+		err := pair.Permit(domain, tt.owner, "spender", tt.value, tt.deadline, sig)
+		if err != tt.err {
+			t.Fatalf("failed with %v; want error %v", err, tt.err)
+		}
+	}
+
+	if allowance := pair.Allowance("owner", "spender"); allowance.Cmp(value) != 0 {
+		t.Errorf("allowance want %s, got %s", value, allowance)
+	}
+	if nonce := pair.Nonces("owner"); nonce != 1 {
+		t.Errorf("nonce want %d, got %d", 1, nonce)
+	}
+}
+
+func TestNewPermitDomain(t *testing.T) {
+	if _, err := NewPermitDomain("uniswapV2", 1, nil); err != ErrorNilVerifier {
+		t.Fatalf("failed with %v; want error %v", err, ErrorNilVerifier)
+	}
+
+	service := New(WithClock(func() time.Time { return time.Unix(1000, 0) }))
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Permit(nil, "owner", "spender", big.NewInt(100), 2000, nil); err != ErrorNilVerifier {
+		t.Fatalf("failed with %v; want error %v", err, ErrorNilVerifier)
+	}
+
+	mainnet, err := NewPermitDomain("uniswapV2", 1, testVerify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, other := range []struct {
+		name    string
+		chainID uint64
+	}{
+		{"uniswapV2", 2},
+		{"fork", 1},
+	} {
+		domain, err := NewPermitDomain(other.name, other.chainID, testVerify)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest := pair.PermitDigest(domain, "owner", "spender", big.NewInt(100), 0, 2000)
+		if bytes.Equal(digest, pair.PermitDigest(mainnet, "owner", "spender", big.NewInt(100), 0, 2000)) {
+			t.Errorf("digest of domain %s/%d equals the one of uniswapV2/1", other.name, other.chainID)
+		}
+		if err := pair.Permit(mainnet, "owner", "spender", big.NewInt(100), 2000, testSign("owner", digest)); err != ErrorInvalidSignature {
+			t.Fatalf("failed with %v; want error %v", err, ErrorInvalidSignature)
+		}
+	}
+}
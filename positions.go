@@ -0,0 +1,41 @@
+package uniswapV2
+
+import (
+	"math/big"
+)
+
+func (s *UniswapV2) PositionsOf(address Address) []pairKey {
+	if err := normalizeAddresses(&address); err != nil {
+		return []pairKey{}
+	}
+
+	s.muPositions.RLock()
+	defer s.muPositions.RUnlock()
+
+	keys := make([]pairKey, 0, len(s.positions[address]))
+	for key := range s.positions[address] {
+		keys = append(keys, key)
+	}
+	sortPairKeys(keys)
+	return keys
+}
+
+func (s *UniswapV2) updatePosition(address Address, key pairKey, balance *big.Int) {
+	s.muPositions.Lock()
+	defer s.muPositions.Unlock()
+
+	if balance == nil || balance.Sign() != 1 {
+		delete(s.positions[address], key)
+		if len(s.positions[address]) == 0 {
+			delete(s.positions, address)
+		}
+		return
+	}
+
+	keys, ok := s.positions[address]
+	if !ok {
+		keys = map[pairKey]struct{}{}
+		s.positions[address] = keys
+	}
+	keys[key] = struct{}{}
+}
@@ -0,0 +1,48 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestUniswapV2_PositionsOf(t *testing.T) {
+	service := New()
+	pair01, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair21, err := service.CreatePair(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := Address("address")
+	if positions := service.PositionsOf(address); len(positions) != 0 {
+		t.Errorf("positions want none, got %v", positions)
+	}
+
+	liquidity, err := pair21.Mint(address, big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair01.Mint(address, big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []pairKey{{TokenA: 0, TokenB: 1}, {TokenA: 1, TokenB: 2}}
+	if positions := service.PositionsOf(address); !reflect.DeepEqual(positions, want) {
+		t.Errorf("positions want %v, got %v", want, positions)
+	}
+
+	_, _, err = pair21.Burn(address, liquidity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want = []pairKey{{TokenA: 0, TokenB: 1}}
+	if positions := service.PositionsOf(address); !reflect.DeepEqual(positions, want) {
+		t.Errorf("positions want %v, got %v", want, positions)
+	}
+}
@@ -0,0 +1,271 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Wire types and field numbers of uniswapv2.proto. The codec is written by
+// hand to keep the module free of dependencies; it is compatible with code
+// generated from the schema.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+
+	fieldStatePairs = 1
+
+	fieldPairToken0               = 1
+	fieldPairToken1               = 2
+	fieldPairFeeTier              = 3
+	fieldPairFeeNumerator         = 4
+	fieldPairFeeDenominator       = 5
+	fieldPairReserve0             = 6
+	fieldPairReserve1             = 7
+	fieldPairTotalSupply          = 8
+	fieldPairPrice0CumulativeLast = 9
+	fieldPairPrice1CumulativeLast = 10
+	fieldPairBlockTimestampLast   = 11
+	fieldPairBalances             = 12
+	fieldPairMetadata             = 13
+
+	fieldBalanceAddress = 1
+	fieldBalanceAmount  = 2
+
+	fieldEntryKey   = 1
+	fieldEntryValue = 2
+)
+
+// MarshalProto encodes all pairs as the State message of uniswapv2.proto.
+// Allowances and permit nonces are not part of the schema. With
+// WithLazyStorage the pairs not in memory are read from storage, failing
+// with its error.
+func (s *UniswapV2) MarshalProto() ([]byte, error) {
+	s.muPairs.RLock()
+	pairs, err := s.statePairs()
+	s.muPairs.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	e := &encoder{}
+	for _, pair := range pairs {
+		e.protoBytes(fieldStatePairs, pair.MarshalProto())
+	}
+	return e.buf, nil
+}
+
+// UnmarshalProto loads a State message into a service without pairs.
+func (s *UniswapV2) UnmarshalProto(data []byte) error {
+	var pairs []*Pair
+	var metadata []map[string]string
+	d := &decoder{buf: data}
+	for len(d.buf) != 0 && d.err == nil {
+		field, wire := d.protoTag()
+		if field != fieldStatePairs || wire != wireBytes {
+			d.protoSkip(wire)
+			continue
+		}
+		pair, pairMetadata, err := unmarshalProtoPair(d.bytes())
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, pair)
+		metadata = append(metadata, pairMetadata)
+	}
+	if d.err != nil {
+		return d.err
+	}
+	return s.load(pairs, metadata)
+}
+
+// MarshalProto encodes the pair as the Pair message of uniswapv2.proto.
+func (p *Pair) MarshalProto() []byte {
+	if !p.key.isSorted() {
+		p = p.revert()
+	}
+	state := p.state()
+
+	e := &encoder{}
+	e.protoVarint(fieldPairToken0, uint64(state.Token0))
+	e.protoVarint(fieldPairToken1, uint64(state.Token1))
+	e.protoVarint(fieldPairFeeTier, uint64(state.FeeTier))
+	e.protoVarint(fieldPairFeeNumerator, uint64(state.Fee.Numerator))
+	e.protoVarint(fieldPairFeeDenominator, uint64(state.Fee.Denominator))
+	e.protoBytes(fieldPairReserve0, state.Reserve0.Bytes())
+	e.protoBytes(fieldPairReserve1, state.Reserve1.Bytes())
+	e.protoBytes(fieldPairTotalSupply, state.TotalSupply.Bytes())
+	e.protoBytes(fieldPairPrice0CumulativeLast, state.Price0CumulativeLast.Bytes())
+	e.protoBytes(fieldPairPrice1CumulativeLast, state.Price1CumulativeLast.Bytes())
+	e.protoVarint(fieldPairBlockTimestampLast, uint64(state.BlockTimestampLast))
+
+	p.muBalance.RLock()
+	for _, address := range p.addresses() {
+		balance := &encoder{}
+		balance.protoBytes(fieldBalanceAddress, []byte(address))
+		balance.protoBytes(fieldBalanceAmount, p.balances[address].Bytes())
+		e.protoMessage(fieldPairBalances, balance.buf)
+	}
+	p.muBalance.RUnlock()
+
+	keys := make([]string, 0, len(state.Metadata))
+	for key := range state.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		entry := &encoder{}
+		entry.protoBytes(fieldEntryKey, []byte(key))
+		entry.protoBytes(fieldEntryValue, []byte(state.Metadata[key]))
+		e.protoMessage(fieldPairMetadata, entry.buf)
+	}
+	return e.buf
+}
+
+func unmarshalProtoPair(data []byte) (*Pair, map[string]string, error) {
+	var key pairKey
+	var fee Fee
+	var blockTimestampLast uint32
+	amounts := [5]*big.Int{new(big.Int), new(big.Int), new(big.Int), new(big.Int), new(big.Int)}
+	balances := map[Address]*big.Int{}
+	metadata := map[string]string{}
+
+	d := &decoder{buf: data}
+	for len(d.buf) != 0 && d.err == nil {
+		field, wire := d.protoTag()
+		switch {
+		case field == fieldPairToken0 && wire == wireVarint:
+			key.TokenA = Token(d.uvarint())
+		case field == fieldPairToken1 && wire == wireVarint:
+			key.TokenB = Token(d.uvarint())
+		case field == fieldPairFeeTier && wire == wireVarint:
+			key.Fee = uint32(d.uvarint())
+		case field == fieldPairFeeNumerator && wire == wireVarint:
+			fee.Numerator = int64(d.uvarint())
+		case field == fieldPairFeeDenominator && wire == wireVarint:
+			fee.Denominator = int64(d.uvarint())
+		case field >= fieldPairReserve0 && field <= fieldPairPrice1CumulativeLast && wire == wireBytes:
+			amounts[field-fieldPairReserve0].SetBytes(d.bytes())
+		case field == fieldPairBlockTimestampLast && wire == wireVarint:
+			blockTimestampLast = uint32(d.uvarint())
+		case field == fieldPairBalances && wire == wireBytes:
+			fields := d.protoEntry(fieldBalanceAddress, fieldBalanceAmount)
+			balances[Address(fields[0])] = new(big.Int).SetBytes(fields[1])
+		case field == fieldPairMetadata && wire == wireBytes:
+			fields := d.protoEntry(fieldEntryKey, fieldEntryValue)
+			metadata[string(fields[0])] = string(fields[1])
+		default:
+			d.protoSkip(wire)
+		}
+	}
+	if d.err != nil || !key.isSorted() || !fee.valid() {
+		return nil, nil, ErrorInvalidEncoding
+	}
+
+	sum := big.NewInt(0)
+	for _, balance := range balances {
+		sum.Add(sum, balance)
+	}
+	if sum.Cmp(amounts[2]) != 0 {
+		return nil, nil, ErrorInvalidTotalSupply
+	}
+	return &Pair{
+		key: key,
+		fee: fee,
+		pairData: pairData{
+			pairMutex:            &pairMutex{},
+			reserve0:             amounts[0],
+			reserve1:             amounts[1],
+			totalSupply:          amounts[2],
+			price0CumulativeLast: amounts[3],
+			price1CumulativeLast: amounts[4],
+			blockTimestampLast:   &blockTimestampLast,
+		},
+		balances:   balances,
+		allowances: map[Address]map[Address]*big.Int{},
+		nonces:     map[Address]uint64{},
+		dirty:      &dirty{isDirty: true, isDirtyBalances: true, isDirtyAllowances: true},
+	}, metadata, nil
+}
+
+func (e *encoder) protoTag(field, wire int) {
+	e.uvarint(uint64(field)<<3 | uint64(wire))
+}
+
+// protoVarint writes a varint field, omitting zero as proto3 does. Negative
+// values are sign extended to 64 bits.
+func (e *encoder) protoVarint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.protoTag(field, wireVarint)
+	e.uvarint(v)
+}
+
+// protoBytes writes a bytes or string field, omitting it when empty.
+func (e *encoder) protoBytes(field int, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	e.protoMessage(field, b)
+}
+
+// protoMessage writes an embedded message, even an empty one.
+func (e *encoder) protoMessage(field int, b []byte) {
+	e.protoTag(field, wireBytes)
+	e.bytes(b)
+}
+
+func (d *decoder) protoTag() (field, wire int) {
+	tag := d.uvarint()
+	if tag>>3 == 0 {
+		d.fail()
+	}
+	return int(tag >> 3), int(tag & 7)
+}
+
+func (d *decoder) protoSkip(wire int) {
+	switch wire {
+	case wireVarint:
+		d.uvarint()
+	case wireFixed64:
+		d.fixed(8)
+	case wireBytes:
+		d.bytes()
+	case wireFixed32:
+		d.fixed(4)
+	default:
+		d.fail()
+	}
+}
+
+func (d *decoder) fixed(n int) {
+	if d.err != nil || len(d.buf) < n {
+		d.fail()
+		return
+	}
+	d.buf = d.buf[n:]
+}
+
+// protoEntry reads an embedded message of two bytes fields, like Balance or
+// a map entry.
+func (d *decoder) protoEntry(first, second int) [2][]byte {
+	var fields [2][]byte
+	entry := &decoder{buf: d.bytes()}
+	for len(entry.buf) != 0 && entry.err == nil {
+		field, wire := entry.protoTag()
+		switch {
+		case field == first && wire == wireBytes:
+			fields[0] = entry.bytes()
+		case field == second && wire == wireBytes:
+			fields[1] = entry.bytes()
+		default:
+			entry.protoSkip(wire)
+		}
+	}
+	if entry.err != nil {
+		d.fail()
+	}
+	return fields
+}
@@ -0,0 +1,76 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_UnmarshalProto(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair.SetMetadata("listed", "true")
+	if _, err := service.CreatePairWithFee(-1, 1, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := service.MarshalProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := New()
+	if err := loaded.UnmarshalProto(data); err != nil {
+		t.Fatal(err)
+	}
+	if loadedData, err := loaded.MarshalProto(); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(loadedData, data) {
+		t.Error("encoding of the loaded service differs")
+	}
+	var want, got bytes.Buffer
+	if err := service.ExportJSON(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.ExportJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Errorf("state want\n%s\ngot\n%s", want.String(), got.String())
+	}
+
+	// unknown fields of every wire type are skipped
+	unknown := append([]byte{0xa0, 0x01, 0x01, 0xa9, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0xb2, 0x01, 0x00, 0xbd, 0x01, 0, 0, 0, 0}, data...)
+	if err := New().UnmarshalProto(unknown); err != nil {
+		t.Fatal(err)
+	}
+	for _, corrupted := range [][]byte{data[:len(data)-1], append(data, 0), {0x0b}} {
+		if err := New().UnmarshalProto(corrupted); err != ErrorInvalidEncoding {
+			t.Fatalf("failed with %v; want error %v", err, ErrorInvalidEncoding)
+		}
+	}
+}
+
+func TestPair_MarshalProto(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		0x08, 0x01, // token0
+		0x10, 0x02, // token1
+		0x20, 0x03, // fee_numerator
+		0x28, 0xe8, 0x07, // fee_denominator
+	}
+	if got := pair.MarshalProto(); !bytes.Equal(got, want) {
+		t.Errorf("encoding want %x, got %x", want, got)
+	}
+}
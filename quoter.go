@@ -0,0 +1,87 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sync"
+)
+
+// Quoter prices a swap on a pool source outside this service, e.g. another
+// AMM instance or a remote quoting service. *UniswapV2 is a Quoter itself.
+type Quoter interface {
+	QuoteOut(tokenIn, tokenOut Token, amountIn *big.Int) (amountOut *big.Int, err error)
+}
+
+type Quote struct {
+	Source    string
+	AmountOut *big.Int
+	Err       error
+}
+
+type quoters struct {
+	mu      sync.RWMutex
+	names   []string
+	quoters map[string]Quoter
+}
+
+// AddQuoter registers an external source under name, replacing any source
+// registered under the same name.
+func (s *UniswapV2) AddQuoter(name string, quoter Quoter) {
+	s.quoters.mu.Lock()
+	defer s.quoters.mu.Unlock()
+
+	if s.quoters.quoters == nil {
+		s.quoters.quoters = map[string]Quoter{}
+	}
+	if _, ok := s.quoters.quoters[name]; !ok {
+		s.quoters.names = append(s.quoters.names, name)
+	}
+	s.quoters.quoters[name] = quoter
+}
+
+// QuoteOut is the output of the best path of up to routerMaxHops hops.
+func (s *UniswapV2) QuoteOut(tokenIn, tokenOut Token, amountIn *big.Int) (amountOut *big.Int, err error) {
+	_, amountOut, err = s.FindBestPath(tokenIn, tokenOut, amountIn, routerMaxHops)
+	return amountOut, err
+}
+
+// CompareQuotes quotes the swap on this service and on every registered
+// source, in registration order. Failed quotes carry their error; an
+// invalid amountIn fails every quote without asking the sources.
+func (s *UniswapV2) CompareQuotes(tokenIn, tokenOut Token, amountIn *big.Int) (local Quote, external []Quote) {
+	local = Quote{Source: "local"}
+	local.AmountOut, local.Err = s.QuoteOut(tokenIn, tokenOut, amountIn)
+	amountErr := checkAmounts(amountIn)
+
+	s.quoters.mu.RLock()
+	names := append([]string(nil), s.quoters.names...)
+	sources := make([]Quoter, 0, len(names))
+	for _, name := range names {
+		sources = append(sources, s.quoters.quoters[name])
+	}
+	s.quoters.mu.RUnlock()
+
+	for i, quoter := range sources {
+		quote := Quote{Source: names[i], Err: amountErr}
+		if amountErr == nil {
+			quote.AmountOut, quote.Err = quoter.QuoteOut(tokenIn, tokenOut, new(big.Int).Set(amountIn))
+		}
+		external = append(external, quote)
+	}
+	return local, external
+}
+
+// BestQuote returns the successful quote with the largest output, preferring
+// the earliest on ties, and false if every quote failed.
+func BestQuote(quotes ...Quote) (Quote, bool) {
+	var best Quote
+	found := false
+	for _, quote := range quotes {
+		if quote.Err != nil || quote.AmountOut == nil {
+			continue
+		}
+		if !found || quote.AmountOut.Cmp(best.AmountOut) == 1 {
+			best, found = quote, true
+		}
+	}
+	return best, found
+}
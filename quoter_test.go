@@ -0,0 +1,74 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+type quoterFunc func(tokenIn, tokenOut Token, amountIn *big.Int) (*big.Int, error)
+
+func (f quoterFunc) QuoteOut(tokenIn, tokenOut Token, amountIn *big.Int) (*big.Int, error) {
+	return f(tokenIn, tokenOut, amountIn)
+}
+
+func TestUniswapV2_CompareQuotes(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := New()
+	pair, err = other.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errRemote := errors.New("remote unavailable")
+	service.AddQuoter("other", other)
+	service.AddQuoter("remote", quoterFunc(func(Token, Token, *big.Int) (*big.Int, error) {
+		return nil, errRemote
+	}))
+
+	local, external := service.CompareQuotes(0, 1, big.NewInt(1e16))
+	if local.Err != nil {
+		t.Fatal(local.Err)
+	}
+	if len(external) != 2 || external[0].Source != "other" || external[1].Source != "remote" {
+		t.Fatalf("external want quotes from other and remote, got %v", external)
+	}
+	if external[1].Err != errRemote {
+		t.Errorf("failed with %v; want error %v", external[1].Err, errRemote)
+	}
+
+	best, ok := BestQuote(append([]Quote{local}, external...)...)
+	if !ok || best.Source != "other" {
+		t.Errorf("best source want %s, got %s", "other", best.Source)
+	}
+	if best.AmountOut.Cmp(local.AmountOut) != 1 {
+		t.Errorf("best amount %s want more than local %s", best.AmountOut, local.AmountOut)
+	}
+
+	if _, ok := BestQuote(external[1]); ok {
+		t.Error("best quote of failed quotes want none")
+	}
+
+	local, external = service.CompareQuotes(0, 1, nil)
+	if local.Err != ErrorNilAmount {
+		t.Fatalf("failed with %v; want error %v", local.Err, ErrorNilAmount)
+	}
+	for _, quote := range external {
+		if quote.Err != ErrorNilAmount {
+			t.Errorf("%s failed with %v; want error %v", quote.Source, quote.Err, ErrorNilAmount)
+		}
+	}
+}
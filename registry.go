@@ -0,0 +1,109 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+var (
+	ErrorUnknownToken  = errors.New("UNKNOWN_TOKEN")
+	ErrorTooManyTokens = errors.New("TOO_MANY_TOKENS")
+)
+
+// TokenRegistry maps native token identifiers, e.g. string denoms or 20-byte
+// addresses, to the Tokens of the service, assigned in registration order
+// from zero. It is a TokenCodec, to export tokens as their identifiers, and
+// Less orders tokens by identifier for use as TokenLess. The assignment is
+// part of the state: a registry restored from IDs gives every identifier
+// its Token back.
+type TokenRegistry struct {
+	mu     sync.RWMutex
+	tokens map[string]Token
+	ids    []string
+}
+
+// NewTokenRegistry registers ids in order.
+func NewTokenRegistry(ids ...string) (*TokenRegistry, error) {
+	r := &TokenRegistry{tokens: map[string]Token{}}
+	for _, id := range ids {
+		if _, err := r.Register(id); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register returns the Token of id, assigning the next one to a new id.
+func (r *TokenRegistry) Register(id string) (Token, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token, ok := r.tokens[id]; ok {
+		return token, nil
+	}
+	if len(r.ids) > math.MaxInt32 {
+		return 0, ErrorTooManyTokens
+	}
+	token := Token(len(r.ids))
+	r.tokens[id] = token
+	r.ids = append(r.ids, id)
+	return token, nil
+}
+
+func (r *TokenRegistry) Token(id string) (Token, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[id]
+	return token, ok
+}
+
+func (r *TokenRegistry) ID(token Token) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if token < 0 || int(token) >= len(r.ids) {
+		return "", false
+	}
+	return r.ids[token], true
+}
+
+// IDs returns the registered identifiers in Token order.
+func (r *TokenRegistry) IDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]string(nil), r.ids...)
+}
+
+// Less orders registered tokens by identifier, unregistered ones by Token
+// after them. Used as TokenLess, tokens are registered before their pairs
+// are created, so that the order of a pair never changes.
+func (r *TokenRegistry) Less(a, b Token) bool {
+	idA, okA := r.ID(a)
+	idB, okB := r.ID(b)
+	if okA && okB {
+		return idA < idB
+	}
+	if okA != okB {
+		return okA
+	}
+	return a < b
+}
+
+func (r *TokenRegistry) EncodeToken(token Token) (string, error) {
+	id, ok := r.ID(token)
+	if !ok {
+		return "", ErrorUnknownToken
+	}
+	return id, nil
+}
+
+func (r *TokenRegistry) DecodeToken(text string) (Token, error) {
+	token, ok := r.Token(text)
+	if !ok {
+		return 0, ErrorUnknownToken
+	}
+	return token, nil
+}
@@ -0,0 +1,68 @@
+package uniswapV2
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestTokenRegistry(t *testing.T) {
+	registry, err := NewTokenRegistry("uosmo", "uatom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := registry.Register("uatom"); err != nil || token != 1 {
+		t.Fatalf("uatom want 1, got %d, %v", token, err)
+	}
+	if token, err := registry.Register("0x6b175474e89094c44da98b954eedeac495271d0f"); err != nil || token != 2 {
+		t.Fatalf("address want 2, got %d, %v", token, err)
+	}
+	if id, ok := registry.ID(0); !ok || id != "uosmo" {
+		t.Errorf("id of 0 want uosmo, got %q", id)
+	}
+	if _, ok := registry.ID(3); ok {
+		t.Error("id of 3 want unknown")
+	}
+	if _, err := registry.DecodeToken("ujuno"); err != ErrorUnknownToken {
+		t.Fatalf("failed with %v; want error %v", err, ErrorUnknownToken)
+	}
+
+	defer func(less func(a, b Token) bool) { TokenLess = less }(TokenLess)
+	TokenLess = registry.Less
+	defer func() { ExportTokenCodec = nil }()
+	ExportTokenCodec = registry
+
+	service := New()
+	osmo, _ := registry.Token("uosmo")
+	atom, _ := registry.Token("uatom")
+	pair, err := service.CreatePair(osmo, atom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(2e18)); err != nil {
+		t.Fatal(err)
+	}
+	keys := service.SortedPairs()
+	if len(keys) != 1 || keys[0].TokenA != atom || keys[0].TokenB != osmo {
+		t.Fatalf("sorted pairs want uatom/uosmo, got %v", keys)
+	}
+	data, err := json.Marshal(keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key pairKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		t.Fatal(err)
+	}
+	if key != keys[0] {
+		t.Errorf("decoded key want %v, got %v from %s", keys[0], key, data)
+	}
+
+	restored, err := NewTokenRegistry(registry.IDs()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, ok := restored.Token("0x6b175474e89094c44da98b954eedeac495271d0f"); !ok || token != 2 {
+		t.Errorf("restored address want 2, got %d", token)
+	}
+}
@@ -0,0 +1,259 @@
+package uniswapV2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Version of the pair, changing whenever the pair may have changed. Versions
+// start over when the state is loaded into another service.
+func (p *Pair) Version() uint64 {
+	return p.AccessStats().Writes
+}
+
+type remotePairVersion struct {
+	Token0  Token  `json:"token0"`
+	Token1  Token  `json:"token1"`
+	FeeTier uint32 `json:"fee_tier"`
+	Version uint64 `json:"version"`
+}
+
+type remoteQuote struct {
+	AmountOut string              `json:"amount_out,omitempty"`
+	Pairs     []remotePairVersion `json:"pairs,omitempty"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// remoteErrors are the errors of QuoteOut passed on by RemoteQuoter as they are.
+var remoteErrors = []error{ErrorInvalidPath, ErrorInsufficientInputAmount, ErrorPathNotFound}
+
+// NewQuoteHandler serves the quotes of s to RemoteQuoter clients:
+//
+//	GET /quote?in=<token>&out=<token>&amount=<amount>
+//	GET /versions?pair=<token0>,<token1>,<fee tier>&pair=...
+//
+// A quote is answered with the output and the versions of the pairs on its
+// path, unless they changed while quoting, versions with the current
+// version of every pair asked for, 0 if it does not exist. Requests pass
+// through middleware first, the first one being the outermost.
+func NewQuoteHandler(s *UniswapV2, middleware ...Middleware) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/quote", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		tokenIn, errIn := strconv.ParseInt(query.Get("in"), 10, 32)
+		tokenOut, errOut := strconv.ParseInt(query.Get("out"), 10, 32)
+		amountIn, ok := new(big.Int).SetString(query.Get("amount"), 10)
+		if errIn != nil || errOut != nil || !ok {
+			http.Error(w, "invalid quote request", http.StatusBadRequest)
+			return
+		}
+
+		var quote remoteQuote
+		path, amountOut, err := s.FindBestPath(Token(tokenIn), Token(tokenOut), amountIn, routerMaxHops)
+		if err == nil {
+			// quote again between two reads of the versions, the quote is
+			// only cacheable if nothing changed meanwhile
+			versions := s.pathVersions(path)
+			var again []Token
+			again, amountOut, err = s.FindBestPath(Token(tokenIn), Token(tokenOut), amountIn, routerMaxHops)
+			if err == nil && tokensEqual(path, again) && versionsEqual(versions, s.pathVersions(path)) {
+				quote.Pairs = versions
+			}
+		}
+		if err != nil {
+			quote.Error = err.Error()
+		} else {
+			quote.AmountOut = amountOut.String()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(quote)
+	})
+	mux.HandleFunc("/versions", func(w http.ResponseWriter, r *http.Request) {
+		var versions []uint64
+		for _, text := range r.URL.Query()["pair"] {
+			var key pairKey
+			if _, err := fmt.Sscanf(text, "%d,%d,%d", &key.TokenA, &key.TokenB, &key.Fee); err != nil {
+				http.Error(w, "invalid pair", http.StatusBadRequest)
+				return
+			}
+			s.muPairs.RLock()
+			pair, ok := s.pair(key)
+			s.muPairs.RUnlock()
+			version := uint64(0)
+			if ok {
+				version = pair.Version()
+			}
+			versions = append(versions, version)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(versions)
+	})
+	return Chain(mux, middleware...)
+}
+
+// Middleware wraps a handler, e.g. with authentication, rate limiting,
+// request logging or quotas.
+type Middleware func(next http.Handler) http.Handler
+
+// Chain wraps handler in middleware, the first one being the outermost.
+func Chain(handler http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// pathVersions returns the versions of every tier of every hop of path.
+func (s *UniswapV2) pathVersions(path []Token) []remotePairVersion {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	var versions []remotePairVersion
+	for i := 1; i < len(path); i++ {
+		for _, pair := range s.tierPairs(path[i-1], path[i]) {
+			key := pair.key.sort()
+			versions = append(versions, remotePairVersion{Token0: key.TokenA, Token1: key.TokenB, FeeTier: key.Fee, Version: pair.Version()})
+		}
+	}
+	return versions
+}
+
+func tokensEqual(a, b []Token) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func versionsEqual(a, b []remotePairVersion) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// RemoteQuoter is a Quoter asking a service served by NewQuoteHandler. Quotes
+// are cached and reused while the versions of the pairs on their path stay
+// the same, which costs one request for the versions instead of a path
+// search. A better path opened through other pairs is not noticed until the
+// cached quote is older than MaxAge.
+type RemoteQuoter struct {
+	// URL is the base URL of the handler.
+	URL    string
+	Client *http.Client
+	// MaxAge bounds how long a quote is reused, forever if zero.
+	MaxAge time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedQuote
+}
+
+type cachedQuote struct {
+	quote   remoteQuote
+	fetched time.Time
+}
+
+func NewRemoteQuoter(url string) *RemoteQuoter {
+	return &RemoteQuoter{URL: url, Client: http.DefaultClient}
+}
+
+func (q *RemoteQuoter) QuoteOut(tokenIn, tokenOut Token, amountIn *big.Int) (amountOut *big.Int, err error) {
+	key := fmt.Sprintf("%d,%d,%s", tokenIn, tokenOut, amountIn)
+	q.mu.Lock()
+	cached, ok := q.cache[key]
+	q.mu.Unlock()
+
+	if ok && (q.MaxAge == 0 || time.Since(cached.fetched) < q.MaxAge) {
+		current, err := q.versions(cached.quote.Pairs)
+		if err != nil {
+			return nil, err
+		}
+		if current {
+			amountOut, _ := new(big.Int).SetString(cached.quote.AmountOut, 10)
+			return amountOut, nil
+		}
+	}
+
+	var quote remoteQuote
+	query := url.Values{"in": {strconv.Itoa(int(tokenIn))}, "out": {strconv.Itoa(int(tokenOut))}, "amount": {amountIn.String()}}
+	if err := q.get("/quote", query, &quote); err != nil {
+		return nil, err
+	}
+	if quote.Error != "" {
+		for _, known := range remoteErrors {
+			if known.Error() == quote.Error {
+				return nil, known
+			}
+		}
+		return nil, errors.New(quote.Error)
+	}
+	amountOut, ok = new(big.Int).SetString(quote.AmountOut, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid remote quote %q", quote.AmountOut)
+	}
+
+	if len(quote.Pairs) == 0 {
+		return amountOut, nil
+	}
+	q.mu.Lock()
+	if q.cache == nil {
+		q.cache = map[string]cachedQuote{}
+	}
+	q.cache[key] = cachedQuote{quote: quote, fetched: time.Now()}
+	q.mu.Unlock()
+	return amountOut, nil
+}
+
+// versions reports whether the remote pairs are still at the given versions.
+func (q *RemoteQuoter) versions(pairs []remotePairVersion) (bool, error) {
+	query := url.Values{}
+	for _, pair := range pairs {
+		query.Add("pair", fmt.Sprintf("%d,%d,%d", pair.Token0, pair.Token1, pair.FeeTier))
+	}
+	var versions []uint64
+	if err := q.get("/versions", query, &versions); err != nil {
+		return false, err
+	}
+	if len(versions) != len(pairs) {
+		return false, nil
+	}
+	for i, pair := range pairs {
+		if versions[i] != pair.Version {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (q *RemoteQuoter) get(path string, query url.Values, v interface{}) error {
+	client := q.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(q.URL + path + "?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote quoter: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
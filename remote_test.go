@@ -0,0 +1,120 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteQuoter(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := map[string]int{}
+	handler := NewQuoteHandler(service)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	quoter := NewRemoteQuoter(server.URL)
+
+	want, err := service.QuoteOut(0, 1, big.NewInt(1e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := quoter.QuoteOut(0, 1, big.NewInt(1e16))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("amount out want %s, got %s", want, got)
+		}
+	}
+	if requests["/quote"] != 1 || requests["/versions"] != 1 {
+		t.Errorf("cached quote is not reused: %v", requests)
+	}
+
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err = service.QuoteOut(0, 1, big.NewInt(1e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := quoter.QuoteOut(0, 1, big.NewInt(1e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cmp(want) != 0 || requests["/quote"] != 2 {
+		t.Errorf("amount out want %s, got %s after %v", want, got, requests)
+	}
+
+	if _, err := quoter.QuoteOut(0, 2, big.NewInt(1e16)); err != ErrorPathNotFound {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPathNotFound)
+	}
+}
+
+func TestNewQuoteHandler_middleware(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var order []string
+	logging := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "logging")
+			next.ServeHTTP(w, r)
+		})
+	}
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "auth")
+			if r.Header.Get("Authorization") != "secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	server := httptest.NewServer(NewQuoteHandler(service, logging, auth))
+	defer server.Close()
+
+	if _, err := NewRemoteQuoter(server.URL).QuoteOut(0, 1, big.NewInt(1e16)); err == nil {
+		t.Fatal("unauthorized quote succeeded")
+	}
+	if len(order) != 2 || order[0] != "logging" || order[1] != "auth" {
+		t.Errorf("middleware order want logging, auth, got %v", order)
+	}
+
+	quoter := NewRemoteQuoter(server.URL)
+	quoter.Client = &http.Client{Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+		r.Header.Set("Authorization", "secret")
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	if _, err := quoter.QuoteOut(0, 1, big.NewInt(1e16)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type roundTripper func(r *http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,159 @@
+package uniswapV2
+
+import "math/big"
+
+// PairDeleter is implemented by a StateWriter that can delete pairs. Commit
+// calls it for every pair removed since the last commit; without it removed
+// pairs stay in the persisted state.
+type PairDeleter interface {
+	DeletePair(token0, token1 Token, feeTier uint32) error
+}
+
+// RemovePair retires a pair without liquidity: never minted, or holding only
+// the minimumLiquidity locked by its first Mint, whose dust reserves are
+// dropped with it. Other pairs fail with ErrorPairNotEmpty. A service
+// running WithAdminApprovals removes only approved AdminRemovePair actions.
+func (s *UniswapV2) RemovePair(coinA, coinB Token) error {
+	if s.approvals != nil {
+		return ErrorApprovalRequired
+	}
+	return s.removePairKey(nil, pairKey{TokenA: coinA, TokenB: coinB})
+}
+
+func (s *UniswapV2) RemovePairWithFee(coinA, coinB Token, feeBps uint32) error {
+	if feeBps == 0 {
+		return ErrorInvalidFee
+	}
+	if s.approvals != nil {
+		return ErrorApprovalRequired
+	}
+	return s.removePairKey(nil, pairKey{TokenA: coinA, TokenB: coinB, Fee: feeBps})
+}
+
+// removePairKey removes the pair of key, delivering its audit record in j
+// if not nil.
+func (s *UniswapV2) removePairKey(j *journal, key pairKey) error {
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+
+	pair, ok, err := s.loadPair(key.sort())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrorPairNotExists
+	}
+	totalSupply := pair.TotalSupply()
+	if totalSupply.Sign() != 0 && totalSupply.Cmp(big.NewInt(minimumLiquidity)) != 0 {
+		return ErrorPairNotEmpty
+	}
+	if err := s.writeWAL(walRecord{Operation: "remove_pair", Token0: key.TokenA, Token1: key.TokenB, FeeTier: key.Fee}); err != nil {
+		return err
+	}
+
+	pair.inJournal(j).audit("remove_pair", addressZero, nil)
+	s.undoOp(s.readdPair(pair))
+	s.dropPair(pair)
+	return nil
+}
+
+// dropPair removes a canonical pair from memory and the indices. The caller
+// holds the muPairs write lock.
+func (s *UniswapV2) dropPair(pair *Pair) {
+	s.removePair(pair)
+	s.isDirtyKeyPairs = true
+	s.routes.rebuild(s.tokenPairs())
+	if s.lazy != nil {
+		if element, ok := s.lazy.elements[pair.key]; ok {
+			s.lazy.recent.Remove(element)
+			delete(s.lazy.elements, pair.key)
+		}
+	}
+}
+
+// readdPair returns a function adding back the canonical pair, about to be
+// removed, as it is now, with its positions, metadata and place in the
+// indices. The caller holds the muPairs write lock; the function takes it.
+func (s *UniswapV2) readdPair(pair *Pair) func() {
+	key := pair.key
+	keyPairs, isDirtyKeyPairs := append([]pairKey(nil), s.keyPairs...), s.isDirtyKeyPairs
+	tiers, metadata := append([]uint32(nil), s.tiers[key.tokens()]...), pair.metadata()
+	return func() {
+		s.muPairs.Lock()
+		defer s.muPairs.Unlock()
+
+		s.pairs[key] = pair
+		s.keyPairs, s.isDirtyKeyPairs = keyPairs, isDirtyKeyPairs
+		s.tiers[key.tokens()] = tiers
+		s.routes.rebuild(s.tokenPairs())
+		for address, balance := range pair.balancesCopy() {
+			s.updatePosition(address, key, balance)
+		}
+		pair.setMetadata(metadata)
+		if s.lazy != nil {
+			s.lazy.use(key)
+		}
+	}
+}
+
+// deleteRemovedPairs passes the committed pairs removed since to writer, if
+// it is a PairDeleter, and returns them. The caller holds the muPairs write
+// lock.
+func (s *UniswapV2) deleteRemovedPairs(writer StateWriter) ([]pairKey, error) {
+	deleter, ok := writer.(PairDeleter)
+	var removed []pairKey
+	for key := range s.committed {
+		if _, ok := s.pairs[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sortPairKeys(removed)
+	for _, key := range removed {
+		if ok {
+			if err := deleter.DeletePair(key.TokenA, key.TokenB, key.Fee); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return removed, nil
+}
+
+// forgetRemovedPairs drops the committed state of the pairs deleted by a
+// commit. The caller holds the muPairs write lock.
+func (s *UniswapV2) forgetRemovedPairs(removed []pairKey) {
+	for _, key := range removed {
+		delete(s.committed, key)
+		delete(s.committedMetadata, key)
+	}
+}
+
+// restoreRemovedPairs adds back the committed pairs removed since and
+// returns them. The caller holds the muPairs write lock.
+func (s *UniswapV2) restoreRemovedPairs() (restored []*Pair) {
+	var removed []pairKey
+	for key := range s.committed {
+		if _, ok := s.pairs[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sortPairKeys(removed)
+	for _, key := range removed {
+		saved := s.committed[key].clone()
+		pair := s.addPair(key, saved.pairData, saved.balances, saved.fee)
+		pair.allowances, pair.nonces = saved.allowances, saved.nonces
+		restored = append(restored, pair)
+		s.addKeyPair(key)
+		for address, balance := range pair.balances {
+			s.updatePosition(address, key, balance)
+		}
+		if metadata := s.committedMetadata[key]; len(metadata) != 0 {
+			s.muMetadata.Lock()
+			s.metadata[key] = make(map[string]string, len(metadata))
+			for k, v := range metadata {
+				s.metadata[key][k] = v
+			}
+			s.muMetadata.Unlock()
+		}
+	}
+	return restored
+}
@@ -0,0 +1,92 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_RemovePair(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	liquidity, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CreatePair(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	storage := NewMemoryStorage()
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.RemovePair(0, 1); err != ErrorPairNotEmpty {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotEmpty)
+	}
+	if err := service.RemovePair(0, 4); err != ErrorPairNotExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+	_, _, err = pair.Burn("alice", liquidity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RemovePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if service.Pair(0, 1) != nil || service.PairsCount() != 1 || len(service.PairsByToken(0)) != 0 {
+		t.Error("pair is not removed")
+	}
+	if len(service.PositionsOf(addressZero)) != 0 {
+		t.Errorf("positions want none, got %v", service.PositionsOf(addressZero))
+	}
+
+	service.Discard()
+	if service.Pair(0, 1) == nil || service.PairsCount() != 2 {
+		t.Fatal("removed pair is not added back by Discard")
+	}
+	if balance := service.Pair(0, 1).Balance("alice"); balance.Cmp(liquidity) != 0 {
+		t.Errorf("balance want %s, got %s", liquidity, balance)
+	}
+
+	id := service.Snapshot()
+	_, _, err = service.Pair(0, 1).Burn("alice", liquidity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RemovePair(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Revert(id); err != nil {
+		t.Fatal(err)
+	}
+	if service.Pair(0, 1) == nil || len(service.PairsByToken(0)) != 1 {
+		t.Fatal("removed pair is not added back by Revert")
+	}
+
+	_, _, err = service.Pair(0, 1).Burn("alice", liquidity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RemovePair(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	loaded := New()
+	if err := loaded.LoadStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.PairsCount() != 1 || loaded.Pair(0, 1) != nil {
+		t.Errorf("removed pair is persisted: %v", loaded.SortedPairs())
+	}
+	if _, err := service.CreatePair(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RemovePairWithFee(0, 1, 0); err != ErrorInvalidFee {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidFee)
+	}
+}
@@ -0,0 +1,136 @@
+package uniswapV2
+
+import (
+	"container/list"
+	"math/big"
+	"sync"
+)
+
+// routeKey identifies the searches a cached route answers: inputs of the
+// same bit length are routed alike.
+type routeKey struct {
+	tokenIn, tokenOut Token
+	maxHops           int
+	magnitude         int
+}
+
+type route struct {
+	key        routeKey
+	path       []Token
+	generation uint64
+	// reserves of every tier of every hop when the route was found
+	reserves [][2]*big.Int
+}
+
+// routeCache keeps the paths most recently found by FindBestPath.
+type routeCache struct {
+	mu        sync.Mutex
+	size      int
+	tolerance *big.Rat
+	recent    *list.List
+	elements  map[routeKey]*list.Element
+}
+
+func newRouteCache(size int, tolerance *big.Rat) *routeCache {
+	return &routeCache{size: size, tolerance: tolerance, recent: list.New(), elements: map[routeKey]*list.Element{}}
+}
+
+// WithRouteCache memoizes the size most recently found paths of
+// FindBestPath. A cached path is reused, its output computed anew, until a
+// pair is created or removed or a reserve of a pair on the path moves by
+// more than tolerance, e.g. 1/100 for 1%, from when it was found. Changes of
+// pairs off the path do not invalidate it, so a cached path may miss a
+// better one for a while.
+func WithRouteCache(size int, tolerance *big.Rat) Option {
+	return func(o *options) {
+		o.routeCache = newRouteCache(size, tolerance)
+	}
+}
+
+// cachedPath returns the cached path for the search with its current
+// output, if it is still valid. The caller holds the muPairs lock.
+func (s *UniswapV2) cachedPath(key routeKey, amountIn *big.Int) ([]Token, *big.Int, bool) {
+	c := s.routeCache
+	c.mu.Lock()
+	element, ok := c.elements[key]
+	if !ok {
+		c.mu.Unlock()
+		return nil, nil, false
+	}
+	cached := element.Value.(*route)
+	c.mu.Unlock()
+
+	reserves := s.routeReserves(cached.path)
+	valid := cached.generation == s.routes.generation() && len(reserves) == len(cached.reserves)
+	for i := 0; valid && i < len(reserves); i++ {
+		valid = c.within(cached.reserves[i][0], reserves[i][0]) && c.within(cached.reserves[i][1], reserves[i][1])
+	}
+	var amountOut *big.Int
+	if valid {
+		amountOut = amountIn
+		for i := 0; valid && i < len(cached.path)-1; i++ {
+			var err error
+			_, amountOut, err = s.bestPairOut(cached.path[i], cached.path[i+1], amountOut)
+			valid = err == nil
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.elements[key]; ok && element.Value == cached {
+		if !valid {
+			c.recent.Remove(element)
+			delete(c.elements, key)
+			return nil, nil, false
+		}
+		c.recent.MoveToFront(element)
+	}
+	if !valid {
+		return nil, nil, false
+	}
+	return append([]Token(nil), cached.path...), amountOut, true
+}
+
+// cachePath stores a path found by a search. The caller holds the muPairs
+// lock.
+func (s *UniswapV2) cachePath(key routeKey, path []Token) {
+	cached := &route{key: key, path: path, generation: s.routes.generation(), reserves: s.routeReserves(path)}
+
+	c := s.routeCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.elements[key]; ok {
+		element.Value = cached
+		c.recent.MoveToFront(element)
+		return
+	}
+	c.elements[key] = c.recent.PushFront(cached)
+	for c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.elements, oldest.Value.(*route).key)
+	}
+}
+
+// routeReserves returns the canonical reserves of every tier along path. The
+// caller holds the muPairs lock.
+func (s *UniswapV2) routeReserves(path []Token) [][2]*big.Int {
+	var reserves [][2]*big.Int
+	for i := 0; i < len(path)-1; i++ {
+		for _, pair := range s.tierPairs(path[i], path[i+1]) {
+			reserve0, reserve1 := pair.Reserves()
+			reserves = append(reserves, [2]*big.Int{reserve0, reserve1})
+		}
+	}
+	return reserves
+}
+
+// within reports whether current differs from cached by at most tolerance.
+func (c *routeCache) within(cached, current *big.Int) bool {
+	if cached.Sign() == 0 {
+		return current.Sign() == 0
+	}
+	change := relativeChange(new(big.Rat).SetInt(cached), new(big.Rat).SetInt(current))
+	return change.Cmp(c.tolerance) != 1
+}
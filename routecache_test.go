@@ -0,0 +1,81 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestWithRouteCache(t *testing.T) {
+	service := New(WithRouteCache(10, big.NewRat(1, 100)))
+	pairs := map[[2]Token]*Pair{}
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}, {0, 2}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		pairs[tokens] = pair
+	}
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}} {
+		_, err := pairs[tokens].Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := pairs[[2]Token{0, 2}].Mint("alice", big.NewInt(1e16), big.NewInt(1e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amountIn := big.NewInt(1e15)
+	path, _, err := service.FindBestPath(0, 2, amountIn, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(path, []Token{0, 1, 2}) {
+		t.Fatalf("path want [0 1 2], got %v", path)
+	}
+	path[1] = 5
+
+	// deeper liquidity off the path does not invalidate the cached path
+	_, err = pairs[[2]Token{0, 2}].Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, amountOut, err := service.FindBestPath(0, 2, big.NewInt(11e14), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(path, []Token{0, 1, 2}) {
+		t.Fatalf("cached path want [0 1 2], got %v", path)
+	}
+	amounts, err := NewRouter(service).GetAmountsOut(big.NewInt(11e14), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountOut.Cmp(amounts[2]) != 0 {
+		t.Errorf("amount out want %s, got %s", amounts[2], amountOut)
+	}
+
+	// a swap moving a reserve of the path by more than 1% does
+	_, _, err = pairs[[2]Token{0, 1}].Swap(big.NewInt(5e16), big.NewInt(0), big.NewInt(0), big.NewInt(4e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, _, err = service.FindBestPath(0, 2, amountIn, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(path, []Token{0, 2}) {
+		t.Fatalf("path want [0 2], got %v", path)
+	}
+
+	// so does a new pair
+	if _, err := service.CreatePair(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	key := routeKey{tokenIn: 0, tokenOut: 2, maxHops: 3, magnitude: amountIn.BitLen()}
+	if _, _, ok := service.cachedPath(key, amountIn); ok {
+		t.Error("cached path want invalid after a pair was created")
+	}
+}
@@ -0,0 +1,570 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrorInvalidPath          = errors.New("INVALID_PATH")
+	ErrorPairNotExists        = errors.New("PAIR_NOT_EXISTS")
+	ErrorInsufficientAAmount  = errors.New("INSUFFICIENT_A_AMOUNT")
+	ErrorInsufficientBAmount  = errors.New("INSUFFICIENT_B_AMOUNT")
+	ErrorExcessiveInputAmount = errors.New("EXCESSIVE_INPUT_AMOUNT")
+)
+
+const routerMaxHops = 3
+
+// Router trades along paths of pairs. Its operations fail with the error
+// types NoRouteError, LiquidityError, SlippageError and ExpiredError telling
+// what went wrong on which hop, each wrapping the Error sentinel to match
+// with errors.Is.
+type Router struct {
+	service *UniswapV2
+	// deadline is the unix time operations fail after, none if zero
+	deadline int64
+	// sender is the address the swap events carry
+	sender Address
+}
+
+func NewRouter(service *UniswapV2) *Router {
+	return &Router{service: service}
+}
+
+// WithDeadline returns a copy of the router whose operations changing the
+// state fail with an *ExpiredError once the clock of the service is past
+// deadline, a unix timestamp, as with the deadline of the Solidity router.
+func (r *Router) WithDeadline(deadline int64) *Router {
+	router := *r
+	router.deadline = deadline
+	return &router
+}
+
+// WithSender returns a copy of the router whose swaps are made by sender,
+// so that their events reach the subscriptions filtering by its address.
+// An invalid address is kept as is and its swaps match no such filter.
+func (r *Router) WithSender(sender Address) *Router {
+	router := *r
+	if normalized, err := NormalizeAddress(string(sender)); err == nil {
+		sender = normalized
+	}
+	router.sender = sender
+	return &router
+}
+
+func (r *Router) checkDeadline() error {
+	if r.deadline == 0 {
+		return nil
+	}
+	if now := r.service.now().Unix(); now > r.deadline {
+		return &ExpiredError{Deadline: r.deadline, Now: now}
+	}
+	return nil
+}
+
+// journal collects the inverse of every applied change, so that a failed
+// multi-step operation can be undone without discarding changes made
+// concurrently by others to the same pairs. The events, audit records,
+// metrics and logs of the changes are held back until commit delivers them;
+// revert drops them.
+type journal struct {
+	undo    []func()
+	effects []func()
+	// saved holds the pairs saved by the operation of DeliverOp, by the
+	// dirty state all views of a pair share
+	saved map[*dirty]struct{}
+}
+
+func (j *journal) add(undo func()) {
+	j.undo = append(j.undo, undo)
+}
+
+// deliver holds back fn until the journal commits.
+func (j *journal) deliver(fn func()) {
+	j.effects = append(j.effects, fn)
+}
+
+// revert undoes the changes in reverse order and drops what they and the
+// undos delivered.
+func (j *journal) revert() {
+	for i := len(j.undo) - 1; i >= 0; i-- {
+		j.undo[i]()
+	}
+	j.undo, j.effects = nil, nil
+}
+
+// commit delivers the held back effects in order.
+func (j *journal) commit() {
+	effects := j.effects
+	j.undo, j.effects = nil, nil
+	for _, fn := range effects {
+		fn()
+	}
+}
+
+// amountsOut computes the outputs along path, trading every hop on the fee
+// tier that pays the most.
+func (s *UniswapV2) amountsOut(amountIn *big.Int, path []Token) (amounts []*big.Int, pairs []*Pair, err error) {
+	if len(path) < 2 {
+		return nil, nil, ErrorInvalidPath
+	}
+	if err := checkAmounts(amountIn); err != nil {
+		return nil, nil, err
+	}
+
+	s.muPairs.RLock()
+	amounts = make([]*big.Int, len(path))
+	amounts[0] = new(big.Int).Set(amountIn)
+	pairs = make([]*Pair, len(path)-1)
+	for i := 0; i < len(path)-1; i++ {
+		pairs[i], amounts[i+1], err = s.bestPairOut(path[i], path[i+1], amounts[i])
+		if err != nil {
+			s.muPairs.RUnlock()
+			return nil, nil, hopError(i, path[i], path[i+1], err)
+		}
+	}
+	s.muPairs.RUnlock()
+
+	if err := s.attachPairs(pairs); err != nil {
+		return nil, nil, err
+	}
+	return amounts, pairs, nil
+}
+
+// amountsIn computes the inputs along path backwards from amountOut,
+// trading every hop on the fee tier that needs the least input.
+func (s *UniswapV2) amountsIn(amountOut *big.Int, path []Token) (amounts []*big.Int, pairs []*Pair, err error) {
+	if len(path) < 2 {
+		return nil, nil, ErrorInvalidPath
+	}
+	if err := checkAmounts(amountOut); err != nil {
+		return nil, nil, err
+	}
+
+	s.muPairs.RLock()
+	amounts = make([]*big.Int, len(path))
+	amounts[len(path)-1] = new(big.Int).Set(amountOut)
+	pairs = make([]*Pair, len(path)-1)
+	for i := len(path) - 2; i >= 0; i-- {
+		pairs[i], amounts[i], err = s.bestPairIn(path[i], path[i+1], amounts[i+1])
+		if err != nil {
+			s.muPairs.RUnlock()
+			return nil, nil, hopError(i, path[i], path[i+1], err)
+		}
+	}
+	s.muPairs.RUnlock()
+
+	if err := s.attachPairs(pairs); err != nil {
+		return nil, nil, err
+	}
+	return amounts, pairs, nil
+}
+
+// GetAmountsOut returns the amounts along path for selling amountIn of
+// path[0], as getAmountsOut of the Solidity library does, every hop on the
+// fee tier that pays the most. They are the amounts Swap would trade.
+func (r *Router) GetAmountsOut(amountIn *big.Int, path []Token) ([]*big.Int, error) {
+	amounts, _, err := r.service.amountsOut(amountIn, path)
+	return amounts, err
+}
+
+// Swap sells amountIn of path[0] for path[len(path)-1] through every pair
+// along the path. Either all hops are applied or none.
+func (r *Router) Swap(amountIn *big.Int, path []Token) (amounts []*big.Int, err error) {
+	if err := r.checkDeadline(); err != nil {
+		return nil, err
+	}
+	amounts, pairs, err := r.service.amountsOut(amountIn, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var j journal
+	if err := swap(&j, r.sender, amounts, pairs); err != nil {
+		j.revert()
+		return nil, err
+	}
+	j.commit()
+	return amounts, nil
+}
+
+// swap trades amounts along pairs for sender.
+func swap(j *journal, sender Address, amounts []*big.Int, pairs []*Pair) (err error) {
+	var span Span
+	if tracer := pairs[0].service.tracer; tracer != nil {
+		span = tracer.Start(nil, "router.swap")
+		span.SetAttributes(LogField{"hops", len(pairs)}, LogField{"amount_in", amounts[0].String()}, LogField{"amount_out", amounts[len(amounts)-1].String()})
+		defer func() { span.End(err) }()
+	}
+
+	for i, pair := range pairs {
+		amount0, amount1, err := pair.withSender(sender).inJournal(j).tracedSwap(span, "router.hop", amounts[i], big.NewInt(0), big.NewInt(0), amounts[i+1], nil)
+		if err != nil {
+			return hopError(i, pair.key.TokenA, pair.key.TokenB, err)
+		}
+		pair := pair
+		j.add(func() {
+			pair.rollbackUpdate(new(big.Int).Neg(amount0), new(big.Int).Neg(amount1))
+		})
+	}
+	return nil
+}
+
+// AddLiquidity deposits the largest amounts not exceeding the desired ones
+// that match the current reserve ratio, creating the pair if needed.
+func (r *Router) AddLiquidity(tokenA, tokenB Token, amountADesired, amountBDesired, amountAMin, amountBMin *big.Int, to Address) (amountA, amountB, liquidity *big.Int, err error) {
+	if err := r.checkDeadline(); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := checkAmounts(amountADesired, amountBDesired, amountAMin, amountBMin); err != nil {
+		return nil, nil, nil, err
+	}
+	var j journal
+	pair := r.service.Pair(tokenA, tokenB)
+	if pair == nil {
+		key := pairKey{TokenA: tokenA, TokenB: tokenB}
+		pair, err = r.service.createPair(key, pairOptions{fee: DefaultFee, journal: &j})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		j.add(func() {
+			r.service.removePairKey(&j, key)
+		})
+	}
+
+	amountA, amountB, err = addLiquidityAmounts(pair, amountADesired, amountBDesired, amountAMin, amountBMin)
+	if err != nil {
+		j.revert()
+		return nil, nil, nil, err
+	}
+
+	liquidity, err = pair.inJournal(&j).Mint(to, amountA, amountB)
+	if err != nil {
+		j.revert()
+		return nil, nil, nil, err
+	}
+	j.commit()
+	return amountA, amountB, liquidity, nil
+}
+
+func addLiquidityAmounts(pair *Pair, amountADesired, amountBDesired, amountAMin, amountBMin *big.Int) (amountA, amountB *big.Int, err error) {
+	reserveA, reserveB := pair.Reserves()
+	if reserveA.Sign() == 0 && reserveB.Sign() == 0 {
+		return new(big.Int).Set(amountADesired), new(big.Int).Set(amountBDesired), nil
+	}
+
+	amountBOptimal, err := quote(amountADesired, reserveA, reserveB)
+	if err != nil {
+		return nil, nil, err
+	}
+	if amountBOptimal.Cmp(amountBDesired) != 1 {
+		if amountBOptimal.Cmp(amountBMin) == -1 {
+			return nil, nil, ErrorInsufficientBAmount
+		}
+		return new(big.Int).Set(amountADesired), amountBOptimal, nil
+	}
+
+	amountAOptimal, err := quote(amountBDesired, reserveB, reserveA)
+	if err != nil {
+		return nil, nil, err
+	}
+	if amountAOptimal.Cmp(amountAMin) == -1 {
+		return nil, nil, ErrorInsufficientAAmount
+	}
+	return amountAOptimal, new(big.Int).Set(amountBDesired), nil
+}
+
+// RemoveLiquidity burns liquidity of address and fails without changing the
+// pair if less than the minimum amounts would be withdrawn.
+func (r *Router) RemoveLiquidity(tokenA, tokenB Token, liquidity, amountAMin, amountBMin *big.Int, address Address) (amountA, amountB *big.Int, err error) {
+	if err := r.checkDeadline(); err != nil {
+		return nil, nil, err
+	}
+	if err := checkAmounts(amountAMin, amountBMin); err != nil {
+		return nil, nil, err
+	}
+	pair := r.service.Pair(tokenA, tokenB)
+	if pair == nil {
+		return nil, nil, ErrorPairNotExists
+	}
+
+	var j journal
+	amountA, amountB, err = removeLiquidity(&j, pair, liquidity, amountAMin, amountBMin, address)
+	if err != nil {
+		j.revert()
+		return nil, nil, err
+	}
+	j.commit()
+	return amountA, amountB, nil
+}
+
+func removeLiquidity(j *journal, pair *Pair, liquidity, amountAMin, amountBMin *big.Int, address Address) (amountA, amountB *big.Int, err error) {
+	if err := normalizeAddresses(&address); err != nil {
+		return nil, nil, err
+	}
+	pair = pair.inJournal(j)
+	burnedA, burnedB, err := pair.Burn(address, liquidity)
+	if err != nil {
+		return nil, nil, err
+	}
+	j.add(func() {
+		pair.rollbackMint(address, liquidity)
+		pair.rollbackUpdate(burnedA, burnedB)
+	})
+
+	amountA, amountB = new(big.Int).Set(burnedA), new(big.Int).Set(burnedB)
+	if amountA.Cmp(amountAMin) == -1 {
+		return nil, nil, ErrorInsufficientAAmount
+	}
+	if amountB.Cmp(amountBMin) == -1 {
+		return nil, nil, ErrorInsufficientBAmount
+	}
+	return amountA, amountB, nil
+}
+
+// RemoveLiquidityAndSwap burns liquidity of address in the tokenA/tokenB pair
+// and sells both withdrawn amounts for targetToken along the best paths.
+func (r *Router) RemoveLiquidityAndSwap(address Address, tokenA, tokenB Token, liquidity *big.Int, targetToken Token, amountOutMin *big.Int) (amountOut *big.Int, err error) {
+	if err := r.checkDeadline(); err != nil {
+		return nil, err
+	}
+	if err := checkAmounts(amountOutMin); err != nil {
+		return nil, err
+	}
+	pair := r.service.Pair(tokenA, tokenB)
+	if pair == nil {
+		return nil, ErrorPairNotExists
+	}
+
+	var j journal
+	amountOut, err = r.removeLiquidityAndSwap(&j, pair, address, tokenA, tokenB, liquidity, targetToken)
+	if err == nil && amountOut.Cmp(amountOutMin) == -1 {
+		err = &SlippageError{Hop: -1, Amount: amountOut, Limit: amountOutMin, Err: ErrorInsufficientOutputAmount}
+	}
+	if err != nil {
+		j.revert()
+		return nil, err
+	}
+	j.commit()
+	return amountOut, nil
+}
+
+func (r *Router) removeLiquidityAndSwap(j *journal, pair *Pair, address Address, tokenA, tokenB Token, liquidity *big.Int, targetToken Token) (*big.Int, error) {
+	amountA, amountB, err := removeLiquidity(j, pair, liquidity, big.NewInt(0), big.NewInt(0), address)
+	if err != nil {
+		return nil, err
+	}
+
+	outA, err := r.swapBest(j, amountA, tokenA, targetToken)
+	if err != nil {
+		return nil, err
+	}
+	outB, err := r.swapBest(j, amountB, tokenB, targetToken)
+	if err != nil {
+		return nil, err
+	}
+	return outA.Add(outA, outB), nil
+}
+
+func (r *Router) swapBest(j *journal, amountIn *big.Int, tokenIn, tokenOut Token) (*big.Int, error) {
+	if tokenIn == tokenOut {
+		return new(big.Int).Set(amountIn), nil
+	}
+
+	path, _, err := r.service.FindBestPath(tokenIn, tokenOut, amountIn, routerMaxHops)
+	if err != nil {
+		return nil, hopError(-1, tokenIn, tokenOut, err)
+	}
+	amounts, pairs, err := r.service.amountsOut(amountIn, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := swap(j, r.sender, amounts, pairs); err != nil {
+		return nil, err
+	}
+	return amounts[len(amounts)-1], nil
+}
+
+func (r *Router) SwapExactTokensForTokens(amountIn, amountOutMin *big.Int, path []Token) (amounts []*big.Int, err error) {
+	if err := r.checkDeadline(); err != nil {
+		return nil, err
+	}
+	if err := checkAmounts(amountOutMin); err != nil {
+		return nil, err
+	}
+	amounts, pairs, err := r.service.amountsOut(amountIn, path)
+	if err != nil {
+		return nil, err
+	}
+	if amounts[len(amounts)-1].Cmp(amountOutMin) == -1 {
+		return nil, &SlippageError{Hop: len(pairs) - 1, Amount: amounts[len(amounts)-1], Limit: amountOutMin, Err: ErrorInsufficientOutputAmount}
+	}
+
+	var j journal
+	if err := swap(&j, r.sender, amounts, pairs); err != nil {
+		j.revert()
+		return nil, err
+	}
+	j.commit()
+	return amounts, nil
+}
+
+type ZapReceipt struct {
+	AmountIn         *big.Int
+	AmountA, AmountB *big.Int
+	Liquidity        *big.Int
+	// RefundA and RefundB are the swapped amounts that did not fit the
+	// reserve ratio and are left to the caller.
+	RefundA, RefundB *big.Int
+}
+
+// AddLiquidityFromToken sells half of amount of sourceToken for tokenA and
+// the other half for tokenB along the best paths and deposits the proceeds
+// into the tokenA/tokenB pair for address.
+func (r *Router) AddLiquidityFromToken(address Address, sourceToken Token, amount *big.Int, tokenA, tokenB Token, minLiquidity *big.Int) (*ZapReceipt, error) {
+	if err := r.checkDeadline(); err != nil {
+		return nil, err
+	}
+	if err := checkAmounts(amount, minLiquidity); err != nil {
+		return nil, err
+	}
+	pair := r.service.Pair(tokenA, tokenB)
+	if pair == nil {
+		return nil, ErrorPairNotExists
+	}
+
+	var j journal
+	receipt, err := r.addLiquidityFromToken(&j, pair, address, sourceToken, amount, tokenA, tokenB)
+	if err == nil && receipt.Liquidity.Cmp(minLiquidity) == -1 {
+		err = ErrorInsufficientLiquidityMinted
+	}
+	if err != nil {
+		j.revert()
+		return nil, err
+	}
+	j.commit()
+	return receipt, nil
+}
+
+func (r *Router) addLiquidityFromToken(j *journal, pair *Pair, address Address, sourceToken Token, amount *big.Int, tokenA, tokenB Token) (*ZapReceipt, error) {
+	halfA := new(big.Int).Div(amount, big.NewInt(2))
+	halfB := new(big.Int).Sub(amount, halfA)
+
+	desiredA, err := r.swapBest(j, halfA, sourceToken, tokenA)
+	if err != nil {
+		return nil, err
+	}
+	desiredB, err := r.swapBest(j, halfB, sourceToken, tokenB)
+	if err != nil {
+		return nil, err
+	}
+
+	amountA, amountB, err := addLiquidityAmounts(pair, desiredA, desiredB, big.NewInt(0), big.NewInt(0))
+	if err != nil {
+		return nil, err
+	}
+	liquidity, err := mint(j, pair, address, amountA, amountB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ZapReceipt{
+		AmountIn:  new(big.Int).Set(amount),
+		AmountA:   amountA,
+		AmountB:   amountB,
+		Liquidity: liquidity,
+		RefundA:   desiredA.Sub(desiredA, amountA),
+		RefundB:   desiredB.Sub(desiredB, amountB),
+	}, nil
+}
+
+func mint(j *journal, pair *Pair, to Address, amount0, amount1 *big.Int) (*big.Int, error) {
+	if err := normalizeAddresses(&to); err != nil {
+		return nil, err
+	}
+	pair = pair.inJournal(j)
+	initial := pair.TotalSupply().Sign() == 0
+	liquidity, err := pair.Mint(to, amount0, amount1)
+	if err != nil {
+		return nil, err
+	}
+	j.add(func() {
+		pair.rollbackBurn(to, liquidity)
+		if initial {
+			pair.rollbackBurn(addressZero, big.NewInt(minimumLiquidity))
+		}
+		pair.rollbackUpdate(new(big.Int).Neg(amount0), new(big.Int).Neg(amount1))
+	})
+	return new(big.Int).Set(liquidity), nil
+}
+
+func (r *Router) SwapTokensForExactTokens(amountOut, amountInMax *big.Int, path []Token) (amounts []*big.Int, err error) {
+	if err := r.checkDeadline(); err != nil {
+		return nil, err
+	}
+	if err := checkAmounts(amountInMax); err != nil {
+		return nil, err
+	}
+	amounts, pairs, err := r.service.amountsIn(amountOut, path)
+	if err != nil {
+		return nil, err
+	}
+	if amounts[0].Cmp(amountInMax) == 1 {
+		return nil, &SlippageError{Hop: 0, Amount: amounts[0], Limit: amountInMax, Err: ErrorExcessiveInputAmount}
+	}
+
+	var j journal
+	if err := swap(&j, r.sender, amounts, pairs); err != nil {
+		j.revert()
+		return nil, err
+	}
+	j.commit()
+	return amounts, nil
+}
+
+// HopFee is the LP fee charged by one hop of a swap, in its input token.
+type HopFee struct {
+	TokenIn, TokenOut   Token
+	FeeTier             uint32
+	Fee                 Fee
+	AmountIn, AmountOut *big.Int
+	LPFee               *big.Int
+}
+
+// FeeQuote is a swap quote with the fees paid along its path.
+type FeeQuote struct {
+	Amounts []*big.Int
+	Hops    []HopFee
+	// Fees sums the fees of all hops per token they are paid in.
+	Fees map[Token]*big.Int
+}
+
+// QuoteWithFees quotes a swap of amountIn along path like Swap and attributes
+// the LP fee of every hop: the share of its input kept by the pair, rounded
+// down.
+func (r *Router) QuoteWithFees(amountIn *big.Int, path []Token) (*FeeQuote, error) {
+	amounts, pairs, err := r.service.amountsOut(amountIn, path)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &FeeQuote{Amounts: amounts, Hops: make([]HopFee, 0, len(pairs)), Fees: map[Token]*big.Int{}}
+	for i, pair := range pairs {
+		lpFee := new(big.Int).Mul(amounts[i], pair.fee.numerator())
+		lpFee.Quo(lpFee, pair.fee.denominator())
+		quote.Hops = append(quote.Hops, HopFee{
+			TokenIn:   path[i],
+			TokenOut:  path[i+1],
+			FeeTier:   pair.key.Fee,
+			Fee:       pair.fee,
+			AmountIn:  amounts[i],
+			AmountOut: amounts[i+1],
+			LPFee:     lpFee,
+		})
+		if quote.Fees[path[i]] == nil {
+			quote.Fees[path[i]] = big.NewInt(0)
+		}
+		quote.Fees[path[i]].Add(quote.Fees[path[i]], lpFee)
+	}
+	return quote, nil
+}
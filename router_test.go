@@ -0,0 +1,424 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestRouter_Swap(t *testing.T) {
+	service := New()
+	for _, tokens := range [][2]Token{{0, 1}, {2, 1}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("address", big.NewInt(5e18), big.NewInt(5e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := NewRouter(service)
+	amounts, err := router.Swap(big.NewInt(1e18), []Token{0, 1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(amounts) != 3 {
+		t.Fatalf("amounts want 3, got %d", len(amounts))
+	}
+
+	reserve0, reserve1 := service.Pair(0, 1).Reserves()
+	if reserve0.Cmp(big.NewInt(6e18)) != 0 {
+		t.Errorf("reserve0 want %s, got %s", big.NewInt(6e18), reserve0)
+	}
+	if want := new(big.Int).Sub(big.NewInt(5e18), amounts[1]); reserve1.Cmp(want) != 0 {
+		t.Errorf("reserve1 want %s, got %s", want, reserve1)
+	}
+	reserve1, reserve2 := service.Pair(1, 2).Reserves()
+	if want := new(big.Int).Add(big.NewInt(5e18), amounts[1]); reserve1.Cmp(want) != 0 {
+		t.Errorf("reserve1 want %s, got %s", want, reserve1)
+	}
+	if want := new(big.Int).Sub(big.NewInt(5e18), amounts[2]); reserve2.Cmp(want) != 0 {
+		t.Errorf("reserve2 want %s, got %s", want, reserve2)
+	}
+
+	_, err = router.Swap(big.NewInt(1e18), []Token{0, 3})
+	if !errors.Is(err, ErrorPairNotExists) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+	_, err = router.Swap(big.NewInt(1e18), []Token{0})
+	if err != ErrorInvalidPath {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidPath)
+	}
+}
+
+func TestRouter_Swap_rollback(t *testing.T) {
+	service := New()
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("address", big.NewInt(5e18), big.NewInt(5e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	errHook := errors.New("hook")
+	service.AddSwapHook(1, 2, func(amount0In, amount1In, amount0Out, amount1Out *big.Int) (tax0, tax1 *big.Int, err error) {
+		return nil, nil, errHook
+	})
+
+	_, err := NewRouter(service).Swap(big.NewInt(1e18), []Token{0, 1, 2})
+	if !errors.Is(err, errHook) {
+		t.Fatalf("failed with %v; want error %v", err, errHook)
+	}
+
+	reserve0, reserve1 := service.Pair(0, 1).Reserves()
+	if reserve0.Cmp(big.NewInt(5e18)) != 0 || reserve1.Cmp(big.NewInt(5e18)) != 0 {
+		t.Errorf("reserves want %s/%s, got %s/%s", big.NewInt(5e18), big.NewInt(5e18), reserve0, reserve1)
+	}
+}
+
+func TestRouter_AddLiquidity(t *testing.T) {
+	service := New()
+	router := NewRouter(service)
+
+	amountA, amountB, liquidity, err := router.AddLiquidity(1, 0, big.NewInt(1e18), big.NewInt(4e18), big.NewInt(0), big.NewInt(0), "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountA.Cmp(big.NewInt(1e18)) != 0 || amountB.Cmp(big.NewInt(4e18)) != 0 {
+		t.Errorf("amounts want %s/%s, got %s/%s", big.NewInt(1e18), big.NewInt(4e18), amountA, amountB)
+	}
+	if want := new(big.Int).Sub(big.NewInt(2e18), big.NewInt(minimumLiquidity)); liquidity.Cmp(want) != 0 {
+		t.Errorf("liquidity want %s, got %s", want, liquidity)
+	}
+
+	amountA, amountB, _, err = router.AddLiquidity(1, 0, big.NewInt(1e18), big.NewInt(1e18), big.NewInt(0), big.NewInt(0), "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountA.Cmp(big.NewInt(25e16)) != 0 || amountB.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("amounts want %s/%s, got %s/%s", big.NewInt(25e16), big.NewInt(1e18), amountA, amountB)
+	}
+
+	reserveA, reserveB := service.Pair(1, 0).Reserves()
+	if reserveA.Cmp(big.NewInt(125e16)) != 0 || reserveB.Cmp(big.NewInt(5e18)) != 0 {
+		t.Errorf("reserves want %s/%s, got %s/%s", big.NewInt(125e16), big.NewInt(5e18), reserveA, reserveB)
+	}
+
+	_, _, _, err = router.AddLiquidity(1, 0, big.NewInt(1e18), big.NewInt(1e18), big.NewInt(5e17), big.NewInt(0), "address")
+	if err != ErrorInsufficientAAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientAAmount)
+	}
+	_, _, _, err = router.AddLiquidity(1, 0, big.NewInt(1e17), big.NewInt(1e18), big.NewInt(0), big.NewInt(5e17), "address")
+	if err != ErrorInsufficientBAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientBAmount)
+	}
+}
+
+func TestRouter_AddLiquidity_removesCreatedPair(t *testing.T) {
+	service := New(WithMinInitialLiquidity(big.NewInt(1e18)))
+	router := NewRouter(service)
+
+	_, _, _, err := router.AddLiquidity(1, 0, big.NewInt(1e17), big.NewInt(4e17), big.NewInt(0), big.NewInt(0), "address")
+	if !errors.Is(err, ErrorInsufficientInitialLiquidity) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientInitialLiquidity)
+	}
+	if keys, _ := service.Pairs(); service.Pair(0, 1) != nil || len(keys) != 0 {
+		t.Error("pair created by the failed AddLiquidity is kept")
+	}
+
+	_, _, _, err = router.AddLiquidity(1, 0, big.NewInt(1e18), big.NewInt(4e18), big.NewInt(0), big.NewInt(0), "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, err = router.AddLiquidity(1, 0, big.NewInt(1e17), big.NewInt(1e18), big.NewInt(0), big.NewInt(5e17), "address")
+	if err != ErrorInsufficientBAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientBAmount)
+	}
+	if service.Pair(0, 1) == nil {
+		t.Error("existing pair is removed by a failed AddLiquidity")
+	}
+}
+
+func TestRouter_RemoveLiquidity(t *testing.T) {
+	service := New()
+	router := NewRouter(service)
+
+	_, _, liquidity, err := router.AddLiquidity(1, 0, big.NewInt(1e18), big.NewInt(4e18), big.NewInt(0), big.NewInt(0), "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := new(big.Int).Div(liquidity, big.NewInt(2))
+
+	_, _, err = router.RemoveLiquidity(1, 0, half, big.NewInt(0), big.NewInt(2e18), "address")
+	if err != ErrorInsufficientBAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientBAmount)
+	}
+	if balance := service.Pair(0, 1).Balance("address"); balance.Cmp(liquidity) != 0 {
+		t.Errorf("balance want %s, got %s", liquidity, balance)
+	}
+	if reserveA, _ := service.Pair(1, 0).Reserves(); reserveA.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserveA want %s, got %s", big.NewInt(1e18), reserveA)
+	}
+
+	amountA, amountB, err := router.RemoveLiquidity(1, 0, half, big.NewInt(4e17), big.NewInt(16e17), "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := new(big.Int).Div(new(big.Int).Mul(half, big.NewInt(1e18)), big.NewInt(2e18)); amountA.Cmp(want) != 0 {
+		t.Errorf("amountA want %s, got %s", want, amountA)
+	}
+	if want := new(big.Int).Div(new(big.Int).Mul(half, big.NewInt(4e18)), big.NewInt(2e18)); amountB.Cmp(want) != 0 {
+		t.Errorf("amountB want %s, got %s", want, amountB)
+	}
+
+	_, _, err = router.RemoveLiquidity(2, 0, half, big.NewInt(0), big.NewInt(0), "address")
+	if err != ErrorPairNotExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+}
+
+func TestRouter_RemoveLiquidityAndSwap(t *testing.T) {
+	service := New()
+	router := NewRouter(service)
+
+	_, _, liquidity, err := router.AddLiquidity(0, 1, big.NewInt(5e18), big.NewInt(5e18), big.NewInt(0), big.NewInt(0), "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, err = router.AddLiquidity(1, 2, big.NewInt(5e18), big.NewInt(5e18), big.NewInt(0), big.NewInt(0), "address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := new(big.Int).Div(liquidity, big.NewInt(2))
+
+	_, err = router.RemoveLiquidityAndSwap("address", 0, 1, half, 2, big.NewInt(5e18))
+	if !errors.Is(err, ErrorInsufficientOutputAmount) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientOutputAmount)
+	}
+	if balance := service.Pair(0, 1).Balance("address"); balance.Cmp(liquidity) != 0 {
+		t.Errorf("balance want %s, got %s", liquidity, balance)
+	}
+	if reserve1, reserve2 := service.Pair(1, 2).Reserves(); reserve1.Cmp(big.NewInt(5e18)) != 0 || reserve2.Cmp(big.NewInt(5e18)) != 0 {
+		t.Errorf("reserves want %s/%s, got %s/%s", big.NewInt(5e18), big.NewInt(5e18), reserve1, reserve2)
+	}
+
+	amountOut, err := router.RemoveLiquidityAndSwap("address", 0, 1, half, 1, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the token0 leg is sold into the already drained pair
+	amount := new(big.Int).Div(new(big.Int).Mul(half, big.NewInt(5e18)), big.NewInt(5e18))
+	reserve := new(big.Int).Sub(big.NewInt(5e18), amount)
+	swapped, err := getAmountOut(amount, reserve, reserve, DefaultFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := new(big.Int).Add(amount, swapped); amountOut.Cmp(want) != 0 {
+		t.Errorf("amountOut want %s, got %s", want, amountOut)
+	}
+}
+
+func TestRouter_SwapExactTokensForTokens(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(5e18), new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(service)
+
+	_, err = router.SwapExactTokensForTokens(big.NewInt(1e18), big.NewInt(1662497915624478907), []Token{1, 2})
+	if !errors.Is(err, ErrorInsufficientOutputAmount) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientOutputAmount)
+	}
+	if reserve1, _ := pair.Reserves(); reserve1.Cmp(big.NewInt(5e18)) != 0 {
+		t.Errorf("reserve1 want %s, got %s", big.NewInt(5e18), reserve1)
+	}
+
+	amounts, err := router.SwapExactTokensForTokens(big.NewInt(1e18), big.NewInt(1662497915624478906), []Token{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amounts[1].Cmp(big.NewInt(1662497915624478906)) != 0 {
+		t.Errorf("amountOut want %s, got %s", big.NewInt(1662497915624478906), amounts[1])
+	}
+}
+
+func TestRouter_AddLiquidityFromToken(t *testing.T) {
+	service := New()
+	router := NewRouter(service)
+	for _, tokens := range [][2]Token{{0, 1}, {0, 2}, {1, 2}} {
+		_, _, _, err := router.AddLiquidity(tokens[0], tokens[1], big.NewInt(5e18), big.NewInt(5e18), big.NewInt(0), big.NewInt(0), "address")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	supply := service.Pair(1, 2).TotalSupply()
+
+	_, err := router.AddLiquidityFromToken("zapper", 0, big.NewInt(1e18), 1, 2, supply)
+	if err != ErrorInsufficientLiquidityMinted {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidityMinted)
+	}
+	if reserve0, _ := service.Pair(0, 1).Reserves(); reserve0.Cmp(big.NewInt(5e18)) != 0 {
+		t.Errorf("reserve0 want %s, got %s", big.NewInt(5e18), reserve0)
+	}
+	if balance := service.Pair(1, 2).Balance("zapper"); balance != nil && balance.Sign() != 0 {
+		t.Errorf("balance want 0, got %s", balance)
+	}
+
+	receipt, err := router.AddLiquidityFromToken("zapper", 0, big.NewInt(1e18), 1, 2, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance := service.Pair(1, 2).Balance("zapper"); balance.Cmp(receipt.Liquidity) != 0 {
+		t.Errorf("balance want %s, got %s", receipt.Liquidity, balance)
+	}
+	if receipt.Liquidity.Sign() != 1 {
+		t.Errorf("liquidity want positive, got %s", receipt.Liquidity)
+	}
+	if receipt.RefundA.Sign() != 0 && receipt.RefundB.Sign() != 0 {
+		t.Errorf("refunds want at most one side, got %s/%s", receipt.RefundA, receipt.RefundB)
+	}
+}
+
+func TestRouter_SwapTokensForExactTokens(t *testing.T) {
+	service := New()
+	router := NewRouter(service)
+	for _, tokens := range [][2]Token{{0, 1}, {1, 2}} {
+		_, _, _, err := router.AddLiquidity(tokens[0], tokens[1], big.NewInt(5e18), big.NewInt(5e18), big.NewInt(0), big.NewInt(0), "address")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	amounts, _, err := service.amountsIn(big.NewInt(1e18), []Token{0, 1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = router.SwapTokensForExactTokens(big.NewInt(1e18), new(big.Int).Sub(amounts[0], big.NewInt(1)), []Token{0, 1, 2})
+	if !errors.Is(err, ErrorExcessiveInputAmount) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorExcessiveInputAmount)
+	}
+
+	swapped, err := router.SwapTokensForExactTokens(big.NewInt(1e18), amounts[0], []Token{0, 1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(swapped, amounts) {
+		t.Errorf("amounts want %v, got %v", amounts, swapped)
+	}
+	if _, reserve2 := service.Pair(1, 2).Reserves(); reserve2.Cmp(big.NewInt(4e18)) != 0 {
+		t.Errorf("reserve2 want %s, got %s", big.NewInt(4e18), reserve2)
+	}
+
+	_, err = router.SwapTokensForExactTokens(big.NewInt(4e18), big.NewInt(9e18), []Token{0, 1, 2})
+	if !errors.Is(err, ErrorInsufficientLiquidity) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+}
+
+func TestRouter_QuoteWithFees(t *testing.T) {
+	service := New()
+	for _, tt := range []struct {
+		tokenA, tokenB Token
+		feeBps         uint32
+	}{
+		{0, 1, 0},
+		{1, 2, 30},
+		{1, 2, 5},
+		{2, 0, 0},
+	} {
+		pair, err := service.CreatePair(tt.tokenA, tt.tokenB)
+		if tt.feeBps != 0 {
+			pair, err = service.CreatePairWithFee(tt.tokenA, tt.tokenB, tt.feeBps)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := NewRouter(service)
+	quote, err := router.QuoteWithFees(big.NewInt(1e16), []Token{0, 1, 2, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	amounts, _, err := service.amountsOut(big.NewInt(1e16), []Token{0, 1, 2, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(quote.Amounts, amounts) {
+		t.Errorf("amounts want %v, got %v", amounts, quote.Amounts)
+	}
+	if len(quote.Hops) != 3 || quote.Hops[1].FeeTier != 5 {
+		t.Fatalf("hops want 3 with the 5 bps tier second, got %+v", quote.Hops)
+	}
+	if quote.Hops[0].LPFee.Cmp(big.NewInt(3e13)) != 0 {
+		t.Errorf("first hop fee want %s, got %s", big.NewInt(3e13), quote.Hops[0].LPFee)
+	}
+	wantSecond := new(big.Int).Quo(new(big.Int).Mul(amounts[1], big.NewInt(5)), big.NewInt(10000))
+	if quote.Hops[1].LPFee.Cmp(wantSecond) != 0 {
+		t.Errorf("second hop fee want %s, got %s", wantSecond, quote.Hops[1].LPFee)
+	}
+	for i, hop := range quote.Hops {
+		if fee := quote.Fees[hop.TokenIn]; fee.Cmp(hop.LPFee) != 0 {
+			t.Errorf("fees in token %d want %s, got %s", hop.TokenIn, hop.LPFee, fee)
+		}
+		if hop.AmountOut.Cmp(amounts[i+1]) != 0 {
+			t.Errorf("hop %d output want %s, got %s", i, amounts[i+1], hop.AmountOut)
+		}
+	}
+}
+
+func TestRouter_GetAmountsOut(t *testing.T) {
+	service := New()
+	for _, tokens := range [][2]Token{{0, 1}, {2, 1}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("address", big.NewInt(5e18), big.NewInt(8e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	router := NewRouter(service)
+	path := []Token{0, 1, 2}
+	amounts, err := router.GetAmountsOut(big.NewInt(1e18), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1e18 * 997 * 8e18 / (5e18 * 1000 + 1e18 * 997), then into the 8e18 reserve of token 1
+	if len(amounts) != 3 || amounts[1].String() != "1329998332499583124" || amounts[2].String() != "710919553958520150" {
+		t.Fatalf("amounts want 1e18, 1329998332499583124, 710919553958520150, got %v", amounts)
+	}
+	swapped, err := router.Swap(big.NewInt(1e18), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(swapped, amounts) {
+		t.Errorf("swapped amounts want %v, got %v", amounts, swapped)
+	}
+
+	if _, err := router.GetAmountsOut(big.NewInt(1e18), []Token{0}); err != ErrorInvalidPath {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidPath)
+	}
+	if _, err := router.GetAmountsOut(big.NewInt(1e18), []Token{0, 3}); !errors.Is(err, ErrorPairNotExists) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+}
@@ -0,0 +1,161 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// routingIndex is the token graph used by path search. Edges and connected
+// components are maintained as pairs are created; the neighbours of a token
+// are kept sorted by the liquidity of that token in the pair, and re-sorted
+// lazily after reserves change.
+type routingIndex struct {
+	mu        sync.Mutex
+	adjacency map[Token][]Token
+	parent    map[Token]Token
+	stale     map[Token]bool
+	// changes whenever the graph does
+	version uint64
+}
+
+func newRoutingIndex() *routingIndex {
+	return &routingIndex{
+		adjacency: map[Token][]Token{},
+		parent:    map[Token]Token{},
+		stale:     map[Token]bool{},
+	}
+}
+
+// add links the tokens of a newly created token pair. The caller holds the
+// muPairs write lock.
+func (r *routingIndex) add(tokenA, tokenB Token) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.version++
+	r.adjacency[tokenA] = append(r.adjacency[tokenA], tokenB)
+	r.adjacency[tokenB] = append(r.adjacency[tokenB], tokenA)
+	r.stale[tokenA], r.stale[tokenB] = true, true
+
+	rootA, rootB := r.find(tokenA), r.find(tokenB)
+	if rootA != rootB {
+		r.parent[rootA] = rootB
+	}
+}
+
+// rebuild replaces the index with the graph of the given token pairs.
+func (r *routingIndex) rebuild(keys []pairKey) {
+	r.mu.Lock()
+	r.adjacency = map[Token][]Token{}
+	r.parent = map[Token]Token{}
+	r.stale = map[Token]bool{}
+	r.version++
+	r.mu.Unlock()
+
+	for _, key := range keys {
+		r.add(key.TokenA, key.TokenB)
+	}
+}
+
+// touch marks the neighbours of both tokens for re-sorting.
+func (r *routingIndex) touch(tokenA, tokenB Token) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stale[tokenA], r.stale[tokenB] = true, true
+}
+
+func (r *routingIndex) generation() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.version
+}
+
+func (r *routingIndex) connected(tokenA, tokenB Token) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.find(tokenA) == r.find(tokenB)
+}
+
+func (r *routingIndex) find(token Token) Token {
+	parent, ok := r.parent[token]
+	if !ok || parent == token {
+		return token
+	}
+	root := r.find(parent)
+	r.parent[token] = root
+	return root
+}
+
+// tokenPairs returns the sorted keys of all token pairs. The caller holds
+// the muPairs lock.
+func (s *UniswapV2) tokenPairs() []pairKey {
+	keys := make([]pairKey, 0, len(s.tiers))
+	for key := range s.tiers {
+		keys = append(keys, key)
+	}
+	sortPairKeys(keys)
+	return keys
+}
+
+// neighbours returns the tokens paired with token, deepest liquidity first.
+// The caller holds the muPairs lock.
+func (s *UniswapV2) neighbours(token Token) []Token {
+	r := s.routes
+	r.mu.Lock()
+	neighbours := append([]Token(nil), r.adjacency[token]...)
+	stale := r.stale[token]
+	delete(r.stale, token)
+	r.mu.Unlock()
+
+	if !stale {
+		return neighbours
+	}
+
+	// reserves are read without holding r.mu, as update takes the pair lock first
+	liquidity := make(map[Token]*big.Int, len(neighbours))
+	for _, next := range neighbours {
+		liquidity[next] = big.NewInt(0)
+		for _, pair := range s.tierPairs(token, next) {
+			reserve, _ := pair.Reserves()
+			liquidity[next].Add(liquidity[next], reserve)
+		}
+	}
+	sort.SliceStable(neighbours, func(i, j int) bool {
+		if cmp := liquidity[neighbours[i]].Cmp(liquidity[neighbours[j]]); cmp != 0 {
+			return cmp == 1
+		}
+		return TokenLess(neighbours[i], neighbours[j])
+	})
+
+	r.mu.Lock()
+	r.adjacency[token] = neighbours
+	r.mu.Unlock()
+	return append([]Token(nil), neighbours...)
+}
+
+// PairsByToken returns the canonical keys of every pair, of every fee tier,
+// holding token, in canonical key order. It is served by the routing index
+// rather than a scan of all pairs.
+func (s *UniswapV2) PairsByToken(token Token) []pairKey {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	s.routes.mu.Lock()
+	neighbours := append([]Token(nil), s.routes.adjacency[token]...)
+	s.routes.mu.Unlock()
+
+	var keys []pairKey
+	for _, next := range neighbours {
+		key := pairKey{TokenA: token, TokenB: next}.sort()
+		for _, fee := range s.tiers[key] {
+			key.Fee = fee
+			keys = append(keys, key)
+		}
+	}
+	sortPairKeys(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestUniswapV2_neighbours(t *testing.T) {
+	service := New()
+	for _, tt := range []struct {
+		tokenA, tokenB   Token
+		amountA, amountB int64
+	}{
+		{0, 1, 1e18, 1e18},
+		{0, 2, 3e18, 1e18},
+		{0, 3, 2e18, 1e18},
+		{4, 5, 1e18, 1e18},
+	} {
+		pair, err := service.CreatePair(tt.tokenA, tt.tokenB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("address", big.NewInt(tt.amountA), big.NewInt(tt.amountB))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := service.neighbours(0); !reflect.DeepEqual(got, []Token{2, 3, 1}) {
+		t.Errorf("neighbours want %v, got %v", []Token{2, 3, 1}, got)
+	}
+
+	_, err := service.Pair(0, 1).Mint("address", big.NewInt(4e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := service.neighbours(0); !reflect.DeepEqual(got, []Token{1, 2, 3}) {
+		t.Errorf("neighbours want %v, got %v", []Token{1, 2, 3}, got)
+	}
+
+	if !service.routes.connected(1, 3) {
+		t.Error("tokens 1 and 3 want connected")
+	}
+	if service.routes.connected(1, 5) {
+		t.Error("tokens 1 and 5 want disconnected")
+	}
+	if got := service.clone().neighbours(0); !reflect.DeepEqual(got, []Token{1, 2, 3}) {
+		t.Errorf("cloned neighbours want %v, got %v", []Token{1, 2, 3}, got)
+	}
+}
+
+func TestUniswapV2_PairsByToken(t *testing.T) {
+	service := New()
+	for _, tokens := range [][2]Token{{2, 1}, {0, 1}, {2, 3}, {1, 3}} {
+		if _, err := service.CreatePair(tokens[0], tokens[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := service.CreatePairWithFee(1, 0, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []pairKey{{TokenA: 0, TokenB: 1}, {TokenA: 0, TokenB: 1, Fee: 5}, {TokenA: 1, TokenB: 2}, {TokenA: 1, TokenB: 3}}
+	if got := service.PairsByToken(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("pairs want %v, got %v", want, got)
+	}
+	if got := service.PairsByToken(4); len(got) != 0 {
+		t.Errorf("pairs want none, got %v", got)
+	}
+
+	id := service.Snapshot()
+	if _, err := service.CreatePair(1, 4); err != nil {
+		t.Fatal(err)
+	}
+	if len(service.PairsByToken(1)) != 5 {
+		t.Errorf("pairs want 5, got %v", service.PairsByToken(1))
+	}
+	if err := service.Revert(id); err != nil {
+		t.Fatal(err)
+	}
+	if got := service.PairsByToken(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("pairs want %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,123 @@
+package uniswapV2
+
+import (
+	"math/big"
+)
+
+type Divergence struct {
+	Operation             string
+	Key                   pairKey
+	Primary, Shadow       []*big.Int
+	PrimaryErr, ShadowErr error
+}
+
+// Shadow applies every operation to the primary service and replays it on
+// the shadow service. Only results of the primary are returned; any
+// difference observed on the shadow is passed to report.
+type Shadow struct {
+	primary, shadow *UniswapV2
+	report          func(Divergence)
+}
+
+func NewShadow(primary, shadow *UniswapV2, report func(Divergence)) *Shadow {
+	return &Shadow{primary: primary, shadow: shadow, report: report}
+}
+
+func (s *Shadow) CreatePair(coinA, coinB Token) (*Pair, error) {
+	pair, err := s.primary.CreatePair(coinA, coinB)
+	_, shadowErr := s.shadow.CreatePair(coinA, coinB)
+	s.compare("create_pair", coinA, coinB, nil, err, nil, shadowErr)
+	return pair, err
+}
+
+func (s *Shadow) Mint(coinA, coinB Token, address Address, amountA, amountB *big.Int) (liquidity *big.Int, err error) {
+	pair, shadowPair, err := s.pairs(coinA, coinB)
+	if err != nil {
+		return nil, err
+	}
+
+	liquidity, err = pair.Mint(address, amountA, amountB)
+	var shadowLiquidity *big.Int
+	shadowErr := ErrorPairNotExists
+	if shadowPair != nil {
+		shadowLiquidity, shadowErr = shadowPair.Mint(address, amountA, amountB)
+	}
+	s.compare("mint", coinA, coinB, []*big.Int{liquidity}, err, []*big.Int{shadowLiquidity}, shadowErr)
+	return liquidity, err
+}
+
+func (s *Shadow) Burn(coinA, coinB Token, address Address, liquidity *big.Int) (amountA, amountB *big.Int, err error) {
+	pair, shadowPair, err := s.pairs(coinA, coinB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	amountA, amountB, err = pair.Burn(address, liquidity)
+	var shadowAmountA, shadowAmountB *big.Int
+	shadowErr := ErrorPairNotExists
+	if shadowPair != nil {
+		shadowAmountA, shadowAmountB, shadowErr = shadowPair.Burn(address, liquidity)
+	}
+	s.compare("burn", coinA, coinB, []*big.Int{amountA, amountB}, err, []*big.Int{shadowAmountA, shadowAmountB}, shadowErr)
+	return amountA, amountB, err
+}
+
+func (s *Shadow) Swap(coinA, coinB Token, amountAIn, amountBIn, amountAOut, amountBOut *big.Int) (amountA, amountB *big.Int, err error) {
+	pair, shadowPair, err := s.pairs(coinA, coinB)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	amountA, amountB, err = pair.Swap(amountAIn, amountBIn, amountAOut, amountBOut)
+	var shadowAmountA, shadowAmountB *big.Int
+	shadowErr := ErrorPairNotExists
+	if shadowPair != nil {
+		shadowAmountA, shadowAmountB, shadowErr = shadowPair.Swap(amountAIn, amountBIn, amountAOut, amountBOut)
+	}
+	s.compare("swap", coinA, coinB, []*big.Int{amountA, amountB}, err, []*big.Int{shadowAmountA, shadowAmountB}, shadowErr)
+	return amountA, amountB, err
+}
+
+func (s *Shadow) pairs(coinA, coinB Token) (pair, shadowPair *Pair, err error) {
+	pair = s.primary.Pair(coinA, coinB)
+	if pair == nil {
+		return nil, nil, ErrorPairNotExists
+	}
+	return pair, s.shadow.Pair(coinA, coinB), nil
+}
+
+func (s *Shadow) compare(operation string, coinA, coinB Token, primary []*big.Int, primaryErr error, shadow []*big.Int, shadowErr error) {
+	key := pairKey{TokenA: coinA, TokenB: coinB}
+	if primaryErr == nil && shadowErr == nil {
+		primary = append(primary, s.state(s.primary, key)...)
+		shadow = append(shadow, s.state(s.shadow, key)...)
+	}
+
+	diverged := primaryErr != shadowErr
+	if !diverged && primaryErr == nil {
+		for i := range primary {
+			if primary[i].Cmp(shadow[i]) != 0 {
+				diverged = true
+				break
+			}
+		}
+	}
+	if !diverged {
+		return
+	}
+
+	s.report(Divergence{
+		Operation:  operation,
+		Key:        key,
+		Primary:    primary,
+		Shadow:     shadow,
+		PrimaryErr: primaryErr,
+		ShadowErr:  shadowErr,
+	})
+}
+
+func (s *Shadow) state(service *UniswapV2, key pairKey) []*big.Int {
+	pair := service.Pair(key.TokenA, key.TokenB)
+	reserve0, reserve1 := pair.Reserves()
+	return []*big.Int{reserve0, reserve1, pair.TotalSupply()}
+}
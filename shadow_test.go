@@ -0,0 +1,46 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestShadow(t *testing.T) {
+	var divergences []Divergence
+	shadow := NewShadow(New(), New(), func(divergence Divergence) {
+		divergences = append(divergences, divergence)
+	})
+
+	_, err := shadow.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = shadow.Mint(1, 0, "address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = shadow.Swap(1, 0, big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(divergences) != 0 {
+		t.Fatalf("divergences want none, got %v", divergences)
+	}
+
+	shadow.shadow.AddSwapHook(0, 1, func(amount0In, amount1In, amount0Out, amount1Out *big.Int) (tax0, tax1 *big.Int, err error) {
+		return nil, amount1In, nil
+	})
+	amount0, _, err := shadow.Swap(1, 0, big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount0.Cmp(big.NewInt(1e17)) != 0 {
+		t.Errorf("amount0 want %s, got %s", big.NewInt(1e17), amount0)
+	}
+	if len(divergences) != 1 {
+		t.Fatalf("divergences want 1, got %d", len(divergences))
+	}
+	if divergences[0].Operation != "swap" || divergences[0].ShadowErr == nil {
+		t.Errorf("unexpected divergence %+v", divergences[0])
+	}
+}
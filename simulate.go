@@ -0,0 +1,89 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sync"
+)
+
+// SimulateSwap returns what Swap would, running the same math, swap hooks
+// and validations, Before hooks included, without changing the pair.
+func (p *Pair) SimulateSwap(amount0In, amount1In, amount0Out, amount1Out *big.Int) (amount0, amount1 *big.Int, err error) {
+	return p.simulation().swapWithCallback(amount0In, amount1In, amount0Out, amount1Out, nil)
+}
+
+// SimulateMint returns what Mint would without changing the pair.
+func (p *Pair) SimulateMint(address Address, amount0, amount1 *big.Int) (liquidity *big.Int, err error) {
+	if err := normalizeAddresses(&address); err != nil {
+		return nil, err
+	}
+	return p.simulation(address).Mint(address, amount0, amount1)
+}
+
+// SimulateBurn returns what Burn would without changing the pair.
+func (p *Pair) SimulateBurn(address Address, liquidity *big.Int) (amount0, amount1 *big.Int, err error) {
+	if err := normalizeAddresses(&address); err != nil {
+		return nil, nil, err
+	}
+	return p.simulation(address).Burn(address, liquidity)
+}
+
+// simulation returns a detached copy of the view p with the balances of
+// addresses only, on a service sharing the options and the swap and Before
+// hooks of the pair but no logs, events, persistence or other pairs. The
+// copy is cheap, and operations on it change nothing else.
+func (p *Pair) simulation(addresses ...Address) *Pair {
+	s := p.service
+	sim := &UniswapV2{
+		options:   s.options,
+		pairs:     map[pairKey]*Pair{},
+		tiers:     map[pairKey][]uint32{},
+		routes:    newRoutingIndex(),
+		positions: map[Address]map[pairKey]struct{}{},
+		swapHooks: map[pairKey][]SwapHook{},
+		metadata:  map[pairKey]map[string]string{},
+	}
+	sim.auditLog, sim.events, sim.metrics, sim.logger, sim.tracer, sim.wal, sim.lazy = nil, nil, nil, nil, nil, nil, nil
+
+	key := p.key.sort().tokens()
+	s.muHooks.RLock()
+	sim.swapHooks[key] = append([]SwapHook(nil), s.swapHooks[key]...)
+	for _, hooks := range s.hooks {
+		sim.hooks = append(sim.hooks, Hooks{BeforeMint: hooks.BeforeMint, BeforeBurn: hooks.BeforeBurn, BeforeSwap: hooks.BeforeSwap, BeforeSync: hooks.BeforeSync})
+	}
+	s.muHooks.RUnlock()
+
+	p.pairData.RLock()
+	blockTimestampLast := *p.blockTimestampLast
+	data := pairData{
+		pairMutex:            &pairMutex{},
+		reserve0:             new(big.Int).Set(p.reserve0),
+		reserve1:             new(big.Int).Set(p.reserve1),
+		totalSupply:          new(big.Int).Set(p.totalSupply),
+		price0CumulativeLast: new(big.Int).Set(p.price0CumulativeLast),
+		price1CumulativeLast: new(big.Int).Set(p.price1CumulativeLast),
+		blockTimestampLast:   &blockTimestampLast,
+	}
+	p.pairData.RUnlock()
+
+	balances := make(map[Address]*big.Int, len(addresses))
+	p.muBalance.RLock()
+	for _, address := range addresses {
+		if balance, ok := p.balances[address]; ok {
+			balances[address] = new(big.Int).Set(balance)
+		}
+	}
+	p.muBalance.RUnlock()
+
+	return &Pair{
+		key:        p.key,
+		service:    sim,
+		fee:        p.fee,
+		pairData:   data,
+		muBalance:  &sync.RWMutex{},
+		balances:   balances,
+		allowances: map[Address]map[Address]*big.Int{},
+		nonces:     map[Address]uint64{},
+		dirty:      &dirty{},
+		ctx:        p.ctx,
+	}
+}
@@ -0,0 +1,82 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestPair_Simulate(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(4e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.AddSwapHook(0, 1, func(amount0In, amount1In, amount0Out, amount1Out *big.Int) (tax0, tax1 *big.Int, err error) {
+		return nil, new(big.Int).Div(amount1In, big.NewInt(100)), nil
+	})
+	if err := service.Commit(NewStorageWriter(NewMemoryStorage())); err != nil {
+		t.Fatal(err)
+	}
+	state := marshal(t, service)
+	view := service.Pair(1, 0)
+
+	_, _, err = view.SimulateSwap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(4e17))
+	if !errors.Is(err, ErrorK) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorK)
+	}
+	_, _, err = view.SimulateBurn("bob", big.NewInt(1))
+	if !errors.Is(err, ErrorInsufficientLiquidityBurned) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidityBurned)
+	}
+	liquidity, err := view.SimulateMint("bob", big.NewInt(1e17), big.NewInt(4e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(marshal(t, service), state) {
+		t.Fatal("simulations changed the state")
+	}
+	if pair.isDirty || pair.isDirtyBalances {
+		t.Error("simulations set dirty flags")
+	}
+	if len(service.PositionsOf("bob")) != 0 {
+		t.Errorf("positions want none, got %v", service.PositionsOf("bob"))
+	}
+
+	minted, err := view.Mint("bob", big.NewInt(1e17), big.NewInt(4e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minted.Cmp(liquidity) != 0 {
+		t.Errorf("liquidity want %s, got %s", liquidity, minted)
+	}
+
+	want0, want1, err := view.SimulateSwap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(3e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got0, got1, err := view.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(3e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got0.Cmp(want0) != 0 || got1.Cmp(want1) != 0 {
+		t.Errorf("swap want %s, %s, got %s, %s", want0, want1, got0, got1)
+	}
+
+	want0, want1, err = view.SimulateBurn("alice", big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got0, got1, err = view.Burn("alice", big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got0.Cmp(want0) != 0 || got1.Cmp(want1) != 0 {
+		t.Errorf("burn want %s, %s, got %s, %s", want0, want1, got0, got1)
+	}
+}
@@ -0,0 +1,261 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+)
+
+// StateID identifies a snapshot taken by Snapshot.
+type StateID int
+
+var (
+	ErrorUnknownSnapshot = errors.New("UNKNOWN_SNAPSHOT")
+)
+
+type snapshot struct {
+	id    StateID
+	state *UniswapV2
+}
+
+// Snapshot deep-copies the state of all pairs, balances, positions and
+// metadata, to be restored with Revert. With WithLazyStorage, pairs loaded
+// while the snapshot is open are added to it as they were in storage, and
+// no pair is dropped from memory until it is released or reverted.
+func (s *UniswapV2) Snapshot() StateID {
+	// no pair may be loaded between the copy and the snapshot being open
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+	state := s.cloneLocked()
+
+	s.muSnapshots.Lock()
+	defer s.muSnapshots.Unlock()
+
+	s.nextStateID++
+	s.snapshots = append(s.snapshots, snapshot{id: s.nextStateID, state: state})
+	s.writeWALBestEffort(walRecord{Operation: "snapshot", Snapshot: s.nextStateID})
+	return s.nextStateID
+}
+
+// Revert restores the state saved by Snapshot in place, so pairs obtained
+// earlier stay valid; pairs created since the snapshot are removed. The
+// snapshot and every later one are discarded.
+func (s *UniswapV2) Revert(id StateID) error {
+	s.muSnapshots.Lock()
+	i := len(s.snapshots) - 1
+	for i >= 0 && s.snapshots[i].id != id {
+		i--
+	}
+	if i < 0 {
+		s.muSnapshots.Unlock()
+		return ErrorUnknownSnapshot
+	}
+	if err := s.writeWAL(walRecord{Operation: "revert", Snapshot: id}); err != nil {
+		s.muSnapshots.Unlock()
+		return err
+	}
+	state := s.snapshots[i].state
+	s.snapshots = s.snapshots[:i]
+	s.muSnapshots.Unlock()
+
+	restored, removed := s.restore(state)
+	emitReverts(restored, removed)
+	return nil
+}
+
+// emitReverts emits a Revert event for the restored pairs and, with zero
+// reserves, for the removed ones.
+func emitReverts(restored, removed []*Pair) {
+	for _, pair := range restored {
+		pair.emit(Event{Kind: EventRevert})
+	}
+	for _, pair := range removed {
+		pair.emit(Event{Kind: EventRevert, Reserve0: big.NewInt(0), Reserve1: big.NewInt(0)})
+	}
+}
+
+// releaseSnapshot drops the snapshot id without restoring it, keeping the
+// others.
+func (s *UniswapV2) releaseSnapshot(id StateID) error {
+	s.muSnapshots.Lock()
+	defer s.muSnapshots.Unlock()
+
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		if s.snapshots[i].id == id {
+			s.snapshots = append(s.snapshots[:i], s.snapshots[i+1:]...)
+			s.writeWALBestEffort(walRecord{Operation: "release", Snapshot: id})
+			return nil
+		}
+	}
+	return ErrorUnknownSnapshot
+}
+
+// restore returns the pairs it changed or added back and the ones it removed.
+func (s *UniswapV2) restore(state *UniswapV2) (restored, removed []*Pair) {
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+
+	for _, key := range s.sortedKeys() {
+		pair := s.pairs[key]
+		saved, ok := state.pairs[key]
+		if !ok {
+			// created since; a committed one is deleted by the next commit
+			delete(s.pairs, key)
+			removed = append(removed, pair)
+			continue
+		}
+		if pair.restore(saved) {
+			restored = append(restored, pair)
+		}
+	}
+	// pairs removed since are taken over from the discarded snapshot
+	for _, key := range state.sortedKeys() {
+		if _, ok := s.pairs[key]; !ok {
+			saved := state.pairs[key]
+			saved.service = s
+			s.pairs[key] = saved
+			restored = append(restored, saved)
+			if s.lazy != nil {
+				s.lazy.use(key)
+			}
+		}
+	}
+	s.keyPairs = append(s.keyPairs[:0], state.keyPairs...)
+	s.isDirtyKeyPairs = state.isDirtyKeyPairs
+	s.tiers = make(map[pairKey][]uint32, len(state.tiers))
+	for key, fees := range state.tiers {
+		s.tiers[key] = append([]uint32(nil), fees...)
+	}
+	s.routes.rebuild(s.tokenPairs())
+
+	s.muPositions.Lock()
+	s.positions = state.positions
+	s.muPositions.Unlock()
+
+	s.muMetadata.Lock()
+	s.metadata = state.metadata
+	s.muMetadata.Unlock()
+	return restored, removed
+}
+
+// snapshotLoaded adds a canonical pair just loaded from the lazy storage to
+// the open snapshots, which did not hold it, so that reverting one of them
+// keeps the pair as it was in storage instead of taking it for created since
+// and removing it. The caller holds the muPairs write lock.
+func (s *UniswapV2) snapshotLoaded(pair *Pair) {
+	s.muSnapshots.Lock()
+	defer s.muSnapshots.Unlock()
+
+	metadata := pair.metadata()
+	for _, snapshot := range s.snapshots {
+		saved := pair.clone()
+		saved.service = snapshot.state
+		stateMetadata := make(map[string]string, len(metadata))
+		for k, v := range metadata {
+			stateMetadata[k] = v
+		}
+		snapshot.state.addStoredPair(saved, stateMetadata)
+	}
+}
+
+// snapshotsOpen reports whether there are snapshots not yet released or
+// reverted.
+func (s *UniswapV2) snapshotsOpen() bool {
+	s.muSnapshots.Lock()
+	defer s.muSnapshots.Unlock()
+	return len(s.snapshots) != 0
+}
+
+// restore copies the state of saved, a clone of the pair, into the pair
+// without replacing any of the values shared with its views. Whatever it
+// changes is marked dirty, the balances address by address, as the last
+// commit may have been made after saved was taken. It reports whether the
+// reserves, total supply or balances changed.
+func (p *Pair) restore(saved *Pair) (changed bool) {
+	p.pairData.Lock()
+	defer p.pairData.Unlock()
+	p.muBalance.Lock()
+	defer p.muBalance.Unlock()
+
+	if !p.pairData.equal(&saved.pairData) {
+		p.isDirty, changed = true, true
+	}
+	for address, balance := range p.balances {
+		if restored, ok := saved.balances[address]; !ok || restored.Cmp(balance) != 0 {
+			p.touchBalance(address)
+			changed = true
+		}
+	}
+	for address := range saved.balances {
+		if _, ok := p.balances[address]; !ok {
+			p.touchBalance(address)
+			changed = true
+		}
+	}
+	if !equalAllowances(p.allowances, saved.allowances) || !equalNonces(p.nonces, saved.nonces) {
+		p.isDirtyAllowances = true
+	}
+
+	p.reserve0.Set(saved.reserve0)
+	p.reserve1.Set(saved.reserve1)
+	p.totalSupply.Set(saved.totalSupply)
+	p.price0CumulativeLast.Set(saved.price0CumulativeLast)
+	p.price1CumulativeLast.Set(saved.price1CumulativeLast)
+	*p.blockTimestampLast = *saved.blockTimestampLast
+
+	for address := range p.balances {
+		delete(p.balances, address)
+	}
+	for address, balance := range saved.balances {
+		p.balances[address] = new(big.Int).Set(balance)
+	}
+	for owner := range p.allowances {
+		delete(p.allowances, owner)
+	}
+	for owner, spenders := range saved.allowances {
+		p.allowances[owner] = spenders
+	}
+	for owner := range p.nonces {
+		delete(p.nonces, owner)
+	}
+	for owner, nonce := range saved.nonces {
+		p.nonces[owner] = nonce
+	}
+	return changed
+}
+
+func (pd *pairData) equal(other *pairData) bool {
+	return pd.reserve0.Cmp(other.reserve0) == 0 && pd.reserve1.Cmp(other.reserve1) == 0 &&
+		pd.totalSupply.Cmp(other.totalSupply) == 0 &&
+		pd.price0CumulativeLast.Cmp(other.price0CumulativeLast) == 0 &&
+		pd.price1CumulativeLast.Cmp(other.price1CumulativeLast) == 0 &&
+		*pd.blockTimestampLast == *other.blockTimestampLast
+}
+
+func equalAllowances(a, b map[Address]map[Address]*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for owner, spenders := range a {
+		if len(spenders) != len(b[owner]) {
+			return false
+		}
+		for spender, allowance := range spenders {
+			if other, ok := b[owner][spender]; !ok || other.Cmp(allowance) != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func equalNonces(a, b map[Address]uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for owner, nonce := range a {
+		if other, ok := b[owner]; !ok || other != nonce {
+			return false
+		}
+	}
+	return true
+}
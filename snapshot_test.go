@@ -0,0 +1,72 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestUniswapV2_Revert(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversed := service.Pair(1, 0)
+	balance := pair.Balance("address")
+
+	id := service.Snapshot()
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("other", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := service.Snapshot()
+	created, err := service.CreatePair(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = created.Mint("other", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.Revert(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Revert(inner); err != ErrorUnknownSnapshot {
+		t.Fatalf("failed with %v; want error %v", err, ErrorUnknownSnapshot)
+	}
+
+	reserve0, reserve1 := reversed.Reserves()
+	if reserve0.Cmp(big.NewInt(2e18)) != 0 || reserve1.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserves want %s, %s, got %s, %s", big.NewInt(2e18), big.NewInt(1e18), reserve0, reserve1)
+	}
+	if pair.Balance("address").Cmp(balance) != 0 || pair.Balance("other") != nil {
+		t.Errorf("balances want %s and none, got %s and %s", balance, pair.Balance("address"), pair.Balance("other"))
+	}
+	if service.Pair(1, 2) != nil {
+		t.Error("pair created after snapshot want removed")
+	}
+	if keys, _ := service.Pairs(); !reflect.DeepEqual(keys, []pairKey{{TokenA: 0, TokenB: 1}}) {
+		t.Errorf("pairs want %v, got %v", []pairKey{{TokenA: 0, TokenB: 1}}, keys)
+	}
+	if positions := service.PositionsOf("other"); len(positions) != 0 {
+		t.Errorf("positions want none, got %v", positions)
+	}
+	if _, _, err := service.FindBestPath(0, 2, big.NewInt(1e16), 3); err != ErrorPathNotFound {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPathNotFound)
+	}
+
+	_, _, err = pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
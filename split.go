@@ -0,0 +1,189 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sort"
+)
+
+// splitParts is the number of parts the input of a split swap is divided
+// into, each going to the path paying the most for it.
+const splitParts = 20
+
+// SplitLeg is the part of a split swap going through one path.
+type SplitLeg struct {
+	Path      []Token
+	AmountIn  *big.Int
+	AmountOut *big.Int
+}
+
+// QuoteSplit divides amountIn of tokenIn across at most maxPaths paths to
+// tokenOut, of up to three hops and sharing no token pair, so that the
+// total output is the largest. The input is allocated in splitParts parts,
+// every part to the path paying the most for it on top of the parts
+// allocated before. Legs are ordered by input, largest first.
+func (r *Router) QuoteSplit(amountIn *big.Int, tokenIn, tokenOut Token, maxPaths int) (legs []SplitLeg, amountOut *big.Int, err error) {
+	if tokenIn == tokenOut || maxPaths < 1 {
+		return nil, nil, ErrorInvalidPath
+	}
+	if err := checkAmounts(amountIn); err != nil {
+		return nil, nil, err
+	}
+	if amountIn.Sign() != 1 {
+		return nil, nil, ErrorInsufficientInputAmount
+	}
+
+	s := r.service
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	part := new(big.Int).Quo(amountIn, big.NewInt(splitParts))
+	if part.Sign() != 1 {
+		part = amountIn
+	}
+	paths := s.splitPaths(tokenIn, tokenOut, part, maxPaths)
+	if len(paths) == 0 {
+		return nil, nil, &NoRouteError{Hop: -1, TokenIn: tokenIn, TokenOut: tokenOut, Err: ErrorPathNotFound}
+	}
+
+	allocated := make([]*big.Int, len(paths))
+	outputs := make([]*big.Int, len(paths))
+	for i := range paths {
+		allocated[i], outputs[i] = big.NewInt(0), big.NewInt(0)
+	}
+	for remaining := new(big.Int).Set(amountIn); remaining.Sign() == 1; {
+		if remaining.Cmp(new(big.Int).Mul(part, big.NewInt(2))) == -1 {
+			part = new(big.Int).Set(remaining)
+		}
+		best, bestOut, bestGain := -1, (*big.Int)(nil), (*big.Int)(nil)
+		for i, path := range paths {
+			out, err := s.pathOut(path, new(big.Int).Add(allocated[i], part))
+			if err != nil {
+				continue
+			}
+			if gain := new(big.Int).Sub(out, outputs[i]); bestGain == nil || gain.Cmp(bestGain) == 1 {
+				best, bestOut, bestGain = i, out, gain
+			}
+		}
+		if best < 0 {
+			return nil, nil, ErrorInsufficientLiquidity
+		}
+		allocated[best].Add(allocated[best], part)
+		outputs[best] = bestOut
+		remaining.Sub(remaining, part)
+	}
+
+	amountOut = big.NewInt(0)
+	for i, path := range paths {
+		if allocated[i].Sign() != 1 {
+			continue
+		}
+		legs = append(legs, SplitLeg{Path: path, AmountIn: allocated[i], AmountOut: outputs[i]})
+		amountOut.Add(amountOut, outputs[i])
+	}
+	sort.SliceStable(legs, func(i, j int) bool { return legs[i].AmountIn.Cmp(legs[j].AmountIn) == 1 })
+	return legs, amountOut, nil
+}
+
+// SwapSplit sells amountIn of tokenIn for tokenOut along the legs of
+// QuoteSplit. Either all legs are applied or none; it fails with
+// ErrorInsufficientOutputAmount if they pay less than amountOutMin.
+func (r *Router) SwapSplit(amountIn, amountOutMin *big.Int, tokenIn, tokenOut Token, maxPaths int) (legs []SplitLeg, err error) {
+	if err := r.checkDeadline(); err != nil {
+		return nil, err
+	}
+	if err := checkAmounts(amountOutMin); err != nil {
+		return nil, err
+	}
+	legs, _, err = r.QuoteSplit(amountIn, tokenIn, tokenOut, maxPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	var j journal
+	amountOut := big.NewInt(0)
+	for i, leg := range legs {
+		amounts, pairs, err := r.service.amountsOut(leg.AmountIn, leg.Path)
+		if err == nil {
+			err = swap(&j, r.sender, amounts, pairs)
+		}
+		if err != nil {
+			j.revert()
+			return nil, err
+		}
+		legs[i].AmountOut = amounts[len(amounts)-1]
+		amountOut.Add(amountOut, legs[i].AmountOut)
+	}
+	if amountOut.Cmp(amountOutMin) == -1 {
+		j.revert()
+		return nil, &SlippageError{Hop: -1, Amount: amountOut, Limit: amountOutMin, Err: ErrorInsufficientOutputAmount}
+	}
+	j.commit()
+	return legs, nil
+}
+
+// splitPaths returns up to maxPaths paths from tokenIn to tokenOut sharing
+// no token pair, the ones paying the most for amountIn first. The caller
+// holds the muPairs lock.
+func (s *UniswapV2) splitPaths(tokenIn, tokenOut Token, amountIn *big.Int, maxPaths int) [][]Token {
+	type candidate struct {
+		path      []Token
+		amountOut *big.Int
+	}
+	var candidates []candidate
+	var walk func(path []Token, visited map[Token]bool)
+	walk = func(path []Token, visited map[Token]bool) {
+		last := path[len(path)-1]
+		if last == tokenOut {
+			if amountOut, err := s.pathOut(path, amountIn); err == nil {
+				candidates = append(candidates, candidate{path: append([]Token(nil), path...), amountOut: amountOut})
+			}
+			return
+		}
+		if len(path) > routerMaxHops {
+			return
+		}
+		for _, next := range s.neighbours(last) {
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+			walk(append(path, next), visited)
+			visited[next] = false
+		}
+	}
+	walk([]Token{tokenIn}, map[Token]bool{tokenIn: true})
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].amountOut.Cmp(candidates[j].amountOut) == 1 })
+
+	var paths [][]Token
+	used := map[pairKey]bool{}
+	for _, candidate := range candidates {
+		if len(paths) == maxPaths {
+			break
+		}
+		disjoint := true
+		for i := 0; disjoint && i < len(candidate.path)-1; i++ {
+			disjoint = !used[pairKey{TokenA: candidate.path[i], TokenB: candidate.path[i+1]}.sort()]
+		}
+		if !disjoint {
+			continue
+		}
+		for i := 0; i < len(candidate.path)-1; i++ {
+			used[pairKey{TokenA: candidate.path[i], TokenB: candidate.path[i+1]}.sort()] = true
+		}
+		paths = append(paths, candidate.path)
+	}
+	return paths
+}
+
+// pathOut is the output of amountIn along path on the best tier of every
+// hop. The caller holds the muPairs lock.
+func (s *UniswapV2) pathOut(path []Token, amountIn *big.Int) (*big.Int, error) {
+	amount := amountIn
+	for i := 0; i < len(path)-1; i++ {
+		var err error
+		if _, amount, err = s.bestPairOut(path[i], path[i+1], amount); err != nil {
+			return nil, err
+		}
+	}
+	return amount, nil
+}
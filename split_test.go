@@ -0,0 +1,73 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestRouter_SwapSplit(t *testing.T) {
+	service := New()
+	for _, tokens := range [][2]Token{{0, 2}, {0, 1}, {1, 2}, {0, 3}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := NewRouter(service)
+	amountIn := big.NewInt(5e17)
+
+	legs, amountOut, err := router.QuoteSplit(amountIn, 0, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(legs) != 2 || !reflect.DeepEqual(legs[0].Path, []Token{0, 2}) || !reflect.DeepEqual(legs[1].Path, []Token{0, 1, 2}) {
+		t.Fatalf("legs want [0 2] and [0 1 2], got %+v", legs)
+	}
+	if sum := new(big.Int).Add(legs[0].AmountIn, legs[1].AmountIn); sum.Cmp(amountIn) != 0 {
+		t.Errorf("inputs want %s in total, got %s", amountIn, sum)
+	}
+	_, single, err := service.FindBestPath(0, 2, amountIn, routerMaxHops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountOut.Cmp(single) != 1 {
+		t.Errorf("split output %s want above single path output %s", amountOut, single)
+	}
+	legs, _, err = router.QuoteSplit(amountIn, 0, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(legs) != 1 || legs[0].AmountOut.Cmp(single) != 0 {
+		t.Errorf("single leg want output %s, got %+v", single, legs)
+	}
+
+	state := marshal(t, service)
+	if _, err := router.SwapSplit(amountIn, new(big.Int).Add(amountOut, big.NewInt(1)), 0, 2, 2); !errors.Is(err, ErrorInsufficientOutputAmount) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientOutputAmount)
+	}
+	if !bytes.Equal(marshal(t, service), state) {
+		t.Fatal("failed split swap changed the state")
+	}
+	legs, err = router.SwapSplit(amountIn, amountOut, 0, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reserve0, reserve2 := service.Pair(0, 2).Reserves()
+	if want := new(big.Int).Add(big.NewInt(1e18), legs[0].AmountIn); reserve0.Cmp(want) != 0 {
+		t.Errorf("reserve0 want %s, got %s", want, reserve0)
+	}
+	if want := new(big.Int).Sub(big.NewInt(1e18), legs[0].AmountOut); reserve2.Cmp(want) != 0 {
+		t.Errorf("reserve2 want %s, got %s", want, reserve2)
+	}
+
+	if _, _, err := router.QuoteSplit(amountIn, 0, 4, 2); !errors.Is(err, ErrorPathNotFound) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPathNotFound)
+	}
+}
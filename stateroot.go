@@ -0,0 +1,42 @@
+package uniswapV2
+
+import "crypto/sha256"
+
+// StateRoot returns the root of a Merkle tree over all pairs, for consensus
+// engines to include the state in their app hash. Leaves are the SHA-256 of
+// a zero byte and the Pair.Marshal encoding of every pair, in canonical key
+// order; a node is the SHA-256 of a one byte and its children, the left
+// subtree holding the largest power of two of leaves fewer than the node
+// has, as in RFC 6962. The root of no pairs is the SHA-256 of nothing. Like
+// Commit it must not run concurrently with operations changing the state.
+// With WithLazyStorage the pairs not in memory are read from storage,
+// failing with its error.
+func (s *UniswapV2) StateRoot() ([32]byte, error) {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	pairs, err := s.statePairs()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	leaves := make([][32]byte, 0, len(pairs))
+	for _, pair := range pairs {
+		leaves = append(leaves, sha256.Sum256(append([]byte{0}, pair.Marshal()...)))
+	}
+	return merkleRoot(leaves), nil
+}
+
+func merkleRoot(leaves [][32]byte) [32]byte {
+	switch len(leaves) {
+	case 0:
+		return sha256.Sum256(nil)
+	case 1:
+		return leaves[0]
+	}
+	split := 1
+	for split*2 < len(leaves) {
+		split *= 2
+	}
+	left, right := merkleRoot(leaves[:split]), merkleRoot(leaves[split:])
+	return sha256.Sum256(append(append([]byte{1}, left[:]...), right[:]...))
+}
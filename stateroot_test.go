@@ -0,0 +1,58 @@
+package uniswapV2
+
+import (
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_StateRoot(t *testing.T) {
+	service := New()
+	if root := stateRoot(t, service); root != sha256.Sum256(nil) {
+		t.Errorf("empty root want %x, got %x", sha256.Sum256(nil), root)
+	}
+
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := sha256.Sum256(append([]byte{0}, pair.Marshal()...))
+	if root := stateRoot(t, service); root != leaf {
+		t.Errorf("root of one pair want %x, got %x", leaf, root)
+	}
+
+	if _, err := service.CreatePair(2, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CreatePair(4, 5); err != nil {
+		t.Fatal(err)
+	}
+	root := stateRoot(t, service)
+
+	// the same state built in another order has the same root
+	other := New()
+	for _, tokens := range [][2]Token{{5, 4}, {0, 1}, {3, 2}} {
+		if _, err := other.CreatePair(tokens[0], tokens[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stateRoot(t, other) != root {
+		t.Error("root depends on the order pairs were created")
+	}
+
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stateRoot(t, service) == root {
+		t.Error("root does not change with the state")
+	}
+}
+
+func stateRoot(t *testing.T, s *UniswapV2) [32]byte {
+	root, err := s.StateRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
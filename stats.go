@@ -0,0 +1,85 @@
+package uniswapV2
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// pairMutex is the lock of the pair data, counting how often it is taken
+// and how long callers waited for it.
+type pairMutex struct {
+	// counters first to keep them 64-bit aligned for the atomic operations
+	reads, writes       uint64
+	readWait, writeWait int64
+	sync.RWMutex
+
+	// op is the operation lock of holdOp, a semaphore so that it can be
+	// waited for with a context
+	opOnce sync.Once
+	op     chan struct{}
+}
+
+func (m *pairMutex) RLock() {
+	atomic.AddUint64(&m.reads, 1)
+	start := time.Now()
+	m.RWMutex.RLock()
+	atomic.AddInt64(&m.readWait, int64(time.Since(start)))
+}
+
+func (m *pairMutex) Lock() {
+	atomic.AddUint64(&m.writes, 1)
+	start := time.Now()
+	m.RWMutex.Lock()
+	atomic.AddInt64(&m.writeWait, int64(time.Since(start)))
+}
+
+// PairAccess is the number of times the data of a pair was locked for
+// reading and for writing since the pair was created or loaded, and the
+// time spent waiting for those locks. The counts tell how busy a pair is,
+// the waits how contended.
+type PairAccess struct {
+	Token0, Token1      Token
+	FeeTier             uint32
+	Reads, Writes       uint64
+	ReadWait, WriteWait time.Duration
+}
+
+// AccessStats returns how often the pair was accessed.
+func (p *Pair) AccessStats() PairAccess {
+	key := p.key.sort()
+	return PairAccess{
+		Token0:    key.TokenA,
+		Token1:    key.TokenB,
+		FeeTier:   key.Fee,
+		Reads:     atomic.LoadUint64(&p.pairMutex.reads),
+		Writes:    atomic.LoadUint64(&p.pairMutex.writes),
+		ReadWait:  time.Duration(atomic.LoadInt64(&p.pairMutex.readWait)),
+		WriteWait: time.Duration(atomic.LoadInt64(&p.pairMutex.writeWait)),
+	}
+}
+
+// HotPairs returns the n most accessed pairs, by writes and then by reads.
+// The pairs most contended for are those with the longest waits of
+// AccessStats.
+func (s *UniswapV2) HotPairs(n int) []PairAccess {
+	s.muPairs.RLock()
+	keys := s.sortedKeys()
+	stats := make([]PairAccess, 0, len(keys))
+	for _, key := range keys {
+		stats = append(stats, s.pairs[key].AccessStats())
+	}
+	s.muPairs.RUnlock()
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Writes != stats[j].Writes {
+			return stats[i].Writes > stats[j].Writes
+		}
+		return stats[i].Reads > stats[j].Reads
+	})
+	if n >= 0 && n < len(stats) {
+		stats = stats[:n]
+	}
+	return stats
+}
@@ -0,0 +1,82 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestUniswapV2_HotPairs(t *testing.T) {
+	service := New()
+	cold, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hot, err := service.CreatePair(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CreatePair(4, 5); err != nil {
+		t.Fatal(err)
+	}
+	_, err = hot.Mint("address", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = hot.Swap(big.NewInt(1e15), big.NewInt(0), big.NewInt(0), big.NewInt(9e14))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		cold.Reserves()
+	}
+
+	stats := service.HotPairs(2)
+	if len(stats) != 2 {
+		t.Fatalf("stats want 2, got %d", len(stats))
+	}
+	if stats[0].Token0 != 2 || stats[0].Token1 != 3 || stats[0].Writes == 0 {
+		t.Errorf("hottest pair want 2/3 with writes, got %+v", stats[0])
+	}
+	if stats[1].Token0 != 0 || stats[1].Writes != 0 || stats[1].Reads != 10 {
+		t.Errorf("second pair want 0/1 with 10 reads, got %+v", stats[1])
+	}
+	if access := service.Pair(1, 0).AccessStats(); access != stats[1] {
+		t.Errorf("access want %+v, got %+v", stats[1], access)
+	}
+	if len(service.HotPairs(-1)) != 3 {
+		t.Errorf("stats want 3, got %d", len(service.HotPairs(-1)))
+	}
+}
+
+func TestPair_AccessStats_wait(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pair.pairData.Lock()
+	reads := atomic.LoadUint64(&pair.pairMutex.reads)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pair.Reserves()
+	}()
+	for atomic.LoadUint64(&pair.pairMutex.reads) == reads {
+		runtime.Gosched()
+	}
+	time.Sleep(20 * time.Millisecond)
+	pair.pairData.Unlock()
+	<-done
+
+	access := pair.AccessStats()
+	if access.ReadWait < 10*time.Millisecond {
+		t.Errorf("read wait want at least 10ms, got %s", access.ReadWait)
+	}
+	if access.WriteWait >= 10*time.Millisecond {
+		t.Errorf("write wait of an uncontended lock want below 10ms, got %s", access.WriteWait)
+	}
+}
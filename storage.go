@@ -0,0 +1,394 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Storage is a key-value store the state of the pairs can be kept in.
+//
+// The keys written by NewStorageWriter all start with mainPrefix "p":
+//
+//	p | token0 | token1 | fee tier                                  pair record
+//	p | token0 | token1 | fee tier | b | address                    LP balance
+//	p | token0 | token1 | fee tier | a | owner length | owner | spender   allowance
+//	p | token0 | token1 | fee tier | n | owner                      permit nonce
+//
+// where tokens and the fee tier are 4 bytes big-endian, tokens offset by
+// 2^31 to keep keys in numeric order, addresses are raw and the length of
+// the owner is a uvarint. Balances and allowances are their big-endian
+// bytes, a nonce is a uvarint. A pair record is a version byte followed by the fee,
+// reserves, total supply, price accumulators, block timestamp and metadata,
+// in the encoding of Pair.Marshal.
+type Storage interface {
+	// Get returns the value of key, ok being false if there is none.
+	Get(key []byte) (value []byte, ok bool, err error)
+	Set(key, value []byte) error
+	Delete(key []byte) error
+	// Iterate calls fn for every key with prefix in byte order, stopping at
+	// the first error. fn may change the storage.
+	Iterate(prefix []byte, fn func(key, value []byte) error) error
+}
+
+// BatchStorage is implemented by a Storage that can apply writes
+// atomically: the writes between BeginBatch and EndBatch with a nil error
+// survive a crash whole or not at all, and EndBatch with an error drops
+// them. EndBatch returns nil only if the batch is applied.
+type BatchStorage interface {
+	BeginBatch() error
+	EndBatch(err error) error
+}
+
+const (
+	storagePairKeyLen = 1 + 4 + 4 + 4
+	storageBalance    = 'b'
+	storageAllowance  = 'a'
+	storageNonce      = 'n'
+)
+
+// MemoryStorage is a Storage kept in memory.
+type MemoryStorage struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{values: map[string][]byte{}}
+}
+
+func (m *MemoryStorage) Get(key []byte) ([]byte, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.values[string(key)]
+	return append([]byte(nil), value...), ok, nil
+}
+
+func (m *MemoryStorage) Set(key, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.values[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+func (m *MemoryStorage) Delete(key []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.values, string(key))
+	return nil
+}
+
+func (m *MemoryStorage) Iterate(prefix []byte, fn func(key, value []byte) error) error {
+	m.mu.RLock()
+	keys := make([]string, 0, len(m.values))
+	for key := range m.values {
+		if strings.HasPrefix(key, string(prefix)) {
+			keys = append(keys, key)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok, _ := m.Get([]byte(key))
+		if !ok {
+			continue
+		}
+		if err := fn([]byte(key), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type storageWriter struct {
+	storage Storage
+}
+
+// NewStorageWriter returns a StateWriter for Commit keeping the state in
+// storage.
+func NewStorageWriter(storage Storage) StateWriter {
+	return &storageWriter{storage: storage}
+}
+
+// BeginBatch starts a batch if the storage is a BatchStorage.
+func (w *storageWriter) BeginBatch() error {
+	if batch, ok := w.storage.(BatchStorage); ok {
+		return batch.BeginBatch()
+	}
+	return nil
+}
+
+func (w *storageWriter) EndBatch(err error) error {
+	if batch, ok := w.storage.(BatchStorage); ok {
+		return batch.EndBatch(err)
+	}
+	return err
+}
+
+func (w *storageWriter) WritePair(state PairState) error {
+	e := &encoder{}
+	e.byte(binaryVersion)
+	e.varint(state.Fee.Numerator)
+	e.varint(state.Fee.Denominator)
+	for _, amount := range []*big.Int{state.Reserve0, state.Reserve1, state.TotalSupply, state.Price0CumulativeLast, state.Price1CumulativeLast} {
+		e.bigInt(amount)
+	}
+	e.uvarint(uint64(state.BlockTimestampLast))
+	keys := make([]string, 0, len(state.Metadata))
+	for key := range state.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	e.uvarint(uint64(len(keys)))
+	for _, key := range keys {
+		e.string(key)
+		e.string(state.Metadata[key])
+	}
+	return w.storage.Set(storagePairKey(pairKey{TokenA: state.Token0, TokenB: state.Token1, Fee: state.FeeTier}), e.buf)
+}
+
+func (w *storageWriter) WriteBalances(token0, token1 Token, feeTier uint32, balances map[Address]*big.Int) error {
+	prefix := append(storagePairKey(pairKey{TokenA: token0, TokenB: token1, Fee: feeTier}), storageBalance)
+	err := w.storage.Iterate(prefix, func(key, _ []byte) error {
+		if _, ok := balances[Address(key[len(prefix):])]; ok {
+			return nil
+		}
+		return w.storage.Delete(key)
+	})
+	if err != nil {
+		return err
+	}
+	for address, balance := range balances {
+		if err := w.storage.Set(append(prefix[:len(prefix):len(prefix)], address...), balance.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *storageWriter) UpdateBalances(token0, token1 Token, feeTier uint32, balances map[Address]*big.Int) error {
+	prefix := append(storagePairKey(pairKey{TokenA: token0, TokenB: token1, Fee: feeTier}), storageBalance)
+	addresses := make([]Address, 0, len(balances))
+	for address := range balances {
+		addresses = append(addresses, address)
+	}
+	sortAddresses(addresses)
+	for _, address := range addresses {
+		key := append(prefix[:len(prefix):len(prefix)], address...)
+		if balances[address] == nil {
+			if err := w.storage.Delete(key); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.storage.Set(key, balances[address].Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *storageWriter) WriteAllowances(token0, token1 Token, feeTier uint32, allowances map[Address]map[Address]*big.Int, nonces map[Address]uint64) error {
+	pair := storagePairKey(pairKey{TokenA: token0, TokenB: token1, Fee: feeTier})
+	values := map[string][]byte{}
+	for owner, spenders := range allowances {
+		for spender, allowance := range spenders {
+			values[string(storageAllowanceKey(pair, owner, spender))] = allowance.Bytes()
+		}
+	}
+	for owner, nonce := range nonces {
+		e := &encoder{}
+		e.uvarint(nonce)
+		values[string(append(append(pair[:len(pair):len(pair)], storageNonce), owner...))] = e.buf
+	}
+	for _, kind := range []byte{storageAllowance, storageNonce} {
+		err := w.storage.Iterate(append(pair[:len(pair):len(pair)], kind), func(key, _ []byte) error {
+			if _, ok := values[string(key)]; ok {
+				return nil
+			}
+			return w.storage.Delete(key)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := w.storage.Set([]byte(key), values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *storageWriter) DeletePair(token0, token1 Token, feeTier uint32) error {
+	key := storagePairKey(pairKey{TokenA: token0, TokenB: token1, Fee: feeTier})
+	if err := w.WriteBalances(token0, token1, feeTier, nil); err != nil {
+		return err
+	}
+	if err := w.WriteAllowances(token0, token1, feeTier, nil, nil); err != nil {
+		return err
+	}
+	return w.storage.Delete(key)
+}
+
+func storageAllowanceKey(pair []byte, owner, spender Address) []byte {
+	e := &encoder{buf: append(pair[:len(pair):len(pair)], storageAllowance)}
+	e.string(string(owner))
+	return append(e.buf, spender...)
+}
+
+// readStorageEntry adds the balance, allowance or nonce of the key following
+// the record of the pair to it, suffix being the key past the record key.
+func readStorageEntry(pair *Pair, suffix, value []byte) error {
+	if len(suffix) == 0 {
+		return ErrorInvalidEncoding
+	}
+	switch suffix[0] {
+	case storageBalance:
+		pair.balances[Address(suffix[1:])] = new(big.Int).SetBytes(value)
+	case storageAllowance:
+		length, n := binary.Uvarint(suffix[1:])
+		if n <= 0 || uint64(len(suffix)-1-n) < length {
+			return ErrorInvalidEncoding
+		}
+		owner := Address(suffix[1+n : 1+n+int(length)])
+		spenders, ok := pair.allowances[owner]
+		if !ok {
+			spenders = map[Address]*big.Int{}
+			pair.allowances[owner] = spenders
+		}
+		spenders[Address(suffix[1+n+int(length):])] = new(big.Int).SetBytes(value)
+	case storageNonce:
+		nonce, n := binary.Uvarint(value)
+		if n <= 0 || n != len(value) {
+			return ErrorInvalidEncoding
+		}
+		pair.nonces[Address(suffix[1:])] = nonce
+	default:
+		return ErrorInvalidEncoding
+	}
+	return nil
+}
+
+func storagePairKey(key pairKey) []byte {
+	buf := make([]byte, storagePairKeyLen)
+	buf[0] = mainPrefix[0]
+	binary.BigEndian.PutUint32(buf[1:], uint32(key.TokenA)^1<<31)
+	binary.BigEndian.PutUint32(buf[5:], uint32(key.TokenB)^1<<31)
+	binary.BigEndian.PutUint32(buf[9:], key.Fee)
+	return buf
+}
+
+// LoadStorage loads the state written to storage by Commit with
+// NewStorageWriter into a service without pairs. The loaded state is the
+// committed one.
+func (s *UniswapV2) LoadStorage(storage Storage) error {
+	pairs, metadata, err := readStorage(storage)
+	if err != nil {
+		return err
+	}
+	for _, pair := range pairs {
+		sum := big.NewInt(0)
+		for _, balance := range pair.balances {
+			sum.Add(sum, balance)
+		}
+		if sum.Cmp(pair.totalSupply) != 0 {
+			return ErrorInvalidTotalSupply
+		}
+	}
+	if err := s.load(pairs, metadata); err != nil {
+		return err
+	}
+
+	s.muPairs.Lock()
+	defer s.muPairs.Unlock()
+	loaded := make([]*Pair, 0, len(pairs))
+	for _, pair := range pairs {
+		loaded = append(loaded, s.pairs[pair.key])
+	}
+	s.markCommitted(loaded)
+	s.isDirtyKeyPairs = false
+	return nil
+}
+
+// readStorage decodes every pair written to storage by NewStorageWriter, with
+// its balances, allowances, nonces and metadata, in canonical key order.
+func readStorage(storage Storage) (pairs []*Pair, metadata []map[string]string, err error) {
+	err = storage.Iterate([]byte(mainPrefix), func(key, value []byte) error {
+		if len(key) == storagePairKeyLen {
+			pair, pairMetadata, err := storagePair(key, value)
+			if err != nil {
+				return err
+			}
+			pairs = append(pairs, pair)
+			metadata = append(metadata, pairMetadata)
+			return nil
+		}
+		// balances, allowances and nonces follow the record of their pair
+		if len(key) < storagePairKeyLen || len(pairs) == 0 ||
+			!bytes.Equal(key[:storagePairKeyLen], storagePairKey(pairs[len(pairs)-1].key)) {
+			return ErrorInvalidEncoding
+		}
+		return readStorageEntry(pairs[len(pairs)-1], key[storagePairKeyLen:], value)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return pairs, metadata, nil
+}
+
+func storagePair(key, value []byte) (*Pair, map[string]string, error) {
+	k := pairKey{
+		TokenA: Token(binary.BigEndian.Uint32(key[1:]) ^ 1<<31),
+		TokenB: Token(binary.BigEndian.Uint32(key[5:]) ^ 1<<31),
+		Fee:    binary.BigEndian.Uint32(key[9:]),
+	}
+	d := &decoder{buf: value}
+	if d.byte() != binaryVersion {
+		return nil, nil, ErrorInvalidEncoding
+	}
+	fee := Fee{Numerator: d.varint(), Denominator: d.varint()}
+	var amounts [5]*big.Int
+	for i := range amounts {
+		amounts[i] = d.bigInt()
+	}
+	blockTimestampLast := uint32(d.uvarint())
+	metadata := map[string]string{}
+	for n := d.count(); n > 0; n-- {
+		key := d.string()
+		metadata[key] = d.string()
+	}
+	if d.err != nil || len(d.buf) != 0 || !k.isSorted() || !fee.valid() {
+		return nil, nil, ErrorInvalidEncoding
+	}
+	return &Pair{
+		key: k,
+		fee: fee,
+		pairData: pairData{
+			pairMutex:            &pairMutex{},
+			reserve0:             amounts[0],
+			reserve1:             amounts[1],
+			totalSupply:          amounts[2],
+			price0CumulativeLast: amounts[3],
+			price1CumulativeLast: amounts[4],
+			blockTimestampLast:   &blockTimestampLast,
+		},
+		muBalance:  &sync.RWMutex{},
+		balances:   map[Address]*big.Int{},
+		allowances: map[Address]map[Address]*big.Int{},
+		nonces:     map[Address]uint64{},
+		dirty:      &dirty{},
+	}, metadata, nil
+}
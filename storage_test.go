@@ -0,0 +1,156 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestUniswapV2_LoadStorage(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair.SetMetadata("listed", "true")
+	tier, err := service.CreatePairWithFee(0, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tier.Mint("bob", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewMemoryStorage()
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = pair.Burn("alice", pair.Balance("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	key := append(storagePairKey(pairKey{TokenA: -1, TokenB: 1}), append([]byte{storageBalance}, "alice"...)...)
+	if value, ok, _ := storage.Get(key); !ok || len(value) != 0 {
+		t.Errorf("balance of alice want 0, got %x", value)
+	}
+
+	loaded := New()
+	if err := loaded.LoadStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	var want, got bytes.Buffer
+	if err := service.ExportJSON(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.ExportJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Errorf("state want\n%s\ngot\n%s", want.String(), got.String())
+	}
+
+	recorder := &recordingWriter{}
+	if err := loaded.Commit(recorder); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.pairs) != 0 || len(recorder.balances) != 0 {
+		t.Errorf("loaded state is not committed: %v %v", recorder.pairs, recorder.balances)
+	}
+
+	writer := NewStorageWriter(storage)
+	if err := writer.WriteBalances(-1, 1, 0, map[Address]*big.Int{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := storage.Get(key); ok {
+		t.Error("balance of alice is not deleted")
+	}
+	if err := storage.Set([]byte("p-"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := New().LoadStorage(storage); err != ErrorInvalidEncoding {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidEncoding)
+	}
+}
+
+func TestUniswapV2_LoadStorage_allowances(t *testing.T) {
+	now := time.Unix(1000, 0)
+	service := New(WithClock(func() time.Time { return now }))
+	for _, tokens := range [][2]Token{{0, 1}, {2, 3}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pair := service.Pair(0, 1)
+	if err := pair.Approve("alice", "bob", big.NewInt(5)); err != nil {
+		t.Fatal(err)
+	}
+	domain, err := NewPermitDomain("uniswapV2", 1, testVerify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := testSign("alice", pair.PermitDigest(domain, "alice", "carol", big.NewInt(7), 0, 2000))
+	if err := pair.Permit(domain, "alice", "carol", big.NewInt(7), 2000, sig); err != nil {
+		t.Fatal(err)
+	}
+	storage := NewMemoryStorage()
+	if err := service.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := New()
+	if err := loaded.LoadStorage(storage); err != nil {
+		t.Fatal(err)
+	}
+	if allowance := loaded.Pair(0, 1).Allowance("alice", "bob"); allowance.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("allowance want 5, got %s", allowance)
+	}
+	if nonce := loaded.Pair(0, 1).Nonces("alice"); nonce != 1 {
+		t.Errorf("nonce want 1, got %d", nonce)
+	}
+
+	lazy := New(WithLazyStorage(storage, 1), WithClock(func() time.Time { return now }))
+	lazy.Pair(0, 1)
+	lazy.Pair(2, 3)
+	if _, ok := lazy.pairs[pairKey{TokenA: 0, TokenB: 1}]; ok {
+		t.Fatal("pair is not evicted")
+	}
+	if allowance := lazy.Pair(0, 1).Allowance("alice", "carol"); allowance.Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("allowance after eviction want 7, got %s", allowance)
+	}
+	if err := lazy.Pair(0, 1).Permit(domain, "alice", "carol", big.NewInt(7), 2000, sig); err != ErrorInvalidSignature {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidSignature)
+	}
+
+	if err := lazy.Pair(0, 1).Approve("alice", "bob", big.NewInt(0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := lazy.Commit(NewStorageWriter(storage)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := storage.Get(storageAllowanceKey(storagePairKey(pairKey{TokenA: 0, TokenB: 1}), "alice", "bob")); ok {
+		t.Error("zero allowance is not deleted")
+	}
+	if err := NewStorageWriter(storage).(PairDeleter).DeletePair(0, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.Iterate(storagePairKey(pairKey{TokenA: 0, TokenB: 1}), func(key, _ []byte) error {
+		t.Errorf("key %x is not deleted", key)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,47 @@
+package uniswapV2
+
+import (
+	"math/big"
+)
+
+// Span is a traced operation, e.g. an OpenTelemetry span behind an adapter.
+type Span interface {
+	SetAttributes(attributes ...LogField)
+	// End ends the span with the error of the operation, nil on success.
+	End(err error)
+}
+
+// Tracer starts spans, parent being nil for a root span.
+type Tracer interface {
+	Start(parent Span, name string) Span
+}
+
+// WithTracer traces the swaps of the service: a "pair.swap" span for every
+// Pair swap and, for the swaps of a Router, a "router.swap" span with a
+// "router.hop" child for every hop. Swap spans have the pair, the amounts in
+// and out and, on success, the reserve deltas as attributes, in canonical
+// token order.
+func WithTracer(tracer Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// tracedSwap swaps within a span named name.
+func (p *Pair) tracedSwap(parent Span, name string, amount0In, amount1In, amount0Out, amount1Out *big.Int, callee SwapCallee) (amount0, amount1 *big.Int, err error) {
+	tracer := p.service.tracer
+	if tracer == nil {
+		return p.swapWithCallback(amount0In, amount1In, amount0Out, amount1Out, callee)
+	}
+
+	span := tracer.Start(parent, name)
+	span.SetAttributes(p.fields()...)
+	span.SetAttributes(p.amountFields("amount_in", amount0In, amount1In)...)
+	span.SetAttributes(p.amountFields("amount_out", amount0Out, amount1Out)...)
+	amount0, amount1, err = p.swapWithCallback(amount0In, amount1In, amount0Out, amount1Out, callee)
+	if err == nil {
+		span.SetAttributes(p.amountFields("reserve_delta", amount0, amount1)...)
+	}
+	span.End(err)
+	return amount0, amount1, err
+}
@@ -0,0 +1,82 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+type testSpan struct {
+	name       string
+	parent     *testSpan
+	attributes map[string]interface{}
+	ended      bool
+	err        error
+}
+
+func (s *testSpan) SetAttributes(attributes ...LogField) {
+	for _, attribute := range attributes {
+		s.attributes[attribute.Key] = attribute.Value
+	}
+}
+
+func (s *testSpan) End(err error) {
+	s.ended, s.err = true, err
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(parent Span, name string) Span {
+	span := &testSpan{name: name, attributes: map[string]interface{}{}}
+	if parent != nil {
+		span.parent = parent.(*testSpan)
+	}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func TestWithTracer(t *testing.T) {
+	tracer := &testTracer{}
+	service := New(WithTracer(tracer))
+	for _, tokens := range [][2]Token{{0, 1}, {2, 1}} {
+		pair, err := service.CreatePair(tokens[0], tokens[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	amounts, err := NewRouter(service).Swap(big.NewInt(1e16), []Token{0, 1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tracer.spans) != 3 {
+		t.Fatalf("spans want 3, got %d", len(tracer.spans))
+	}
+	root, first, second := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+	if root.name != "router.swap" || root.parent != nil || root.attributes["hops"] != 2 || !root.ended || root.err != nil {
+		t.Errorf("root span want ended router.swap of 2 hops, got %+v", root)
+	}
+	if first.name != "router.hop" || first.parent != root || second.parent != root || !second.ended {
+		t.Errorf("hop spans want children of the root, got %+v, %+v", first, second)
+	}
+	if second.attributes["token0"] != Token(1) || second.attributes["amount_in0"] != amounts[1].String() || second.attributes["reserve_delta1"] != new(big.Int).Neg(amounts[2]).String() {
+		t.Errorf("second hop want canonical amounts, got %v", second.attributes)
+	}
+
+	_, _, err = service.Pair(0, 1).Swap(big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(1e16))
+	if !errors.Is(err, ErrorInsufficientInputAmount) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientInputAmount)
+	}
+	if last := tracer.spans[len(tracer.spans)-1]; last.name != "pair.swap" || last.parent != nil || !errors.Is(last.err, ErrorInsufficientInputAmount) {
+		t.Errorf("span want failed pair.swap, got %+v", last)
+	}
+	if _, ok := tracer.spans[len(tracer.spans)-1].attributes["reserve_delta0"]; ok {
+		t.Error("failed swap want no reserve deltas")
+	}
+}
@@ -0,0 +1,164 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// TokenInfo is how amounts of a token are presented: Decimals digits of
+// its raw amounts are the fraction of one unit of Symbol.
+type TokenInfo struct {
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// RegisterToken sets the symbol and decimals of token, replacing any set
+// before, for FormatAmount, ParseAmount, Router.QuoteUnits and exports.
+func (s *UniswapV2) RegisterToken(id Token, symbol string, decimals uint8) {
+	s.muTokens.Lock()
+	defer s.muTokens.Unlock()
+
+	if s.tokens == nil {
+		s.tokens = map[Token]TokenInfo{}
+	}
+	s.tokens[id] = TokenInfo{Symbol: symbol, Decimals: decimals}
+}
+
+func (s *UniswapV2) TokenInfo(id Token) (info TokenInfo, ok bool) {
+	s.muTokens.RLock()
+	defer s.muTokens.RUnlock()
+
+	info, ok = s.tokens[id]
+	return info, ok
+}
+
+// registeredTokens returns the registered tokens in TokenLess order.
+func (s *UniswapV2) registeredTokens() []Token {
+	s.muTokens.RLock()
+	defer s.muTokens.RUnlock()
+
+	tokens := make([]Token, 0, len(s.tokens))
+	for token := range s.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool { return TokenLess(tokens[i], tokens[j]) })
+	return tokens
+}
+
+// FormatAmount presents a raw amount of token in units followed by its
+// symbol, e.g. "1.5 WETH" for 15e17 of a token of 18 decimals. Amounts of
+// unregistered tokens are presented raw.
+func (s *UniswapV2) FormatAmount(token Token, amount *big.Int) string {
+	info, ok := s.TokenInfo(token)
+	if !ok {
+		return amount.String()
+	}
+	text := FormatAmount(amount, info.Decimals)
+	if info.Symbol != "" {
+		text += " " + info.Symbol
+	}
+	return text
+}
+
+// ParseAmount reads an amount of token presented in units, optionally
+// followed by its symbol, as FormatAmount writes it. It fails with
+// ErrorInvalidAmount for another symbol. Amounts of unregistered tokens are
+// read raw.
+func (s *UniswapV2) ParseAmount(token Token, text string) (*big.Int, error) {
+	info, ok := s.TokenInfo(token)
+	if !ok {
+		return ParseAmount(strings.TrimSpace(text), 0)
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 || len(fields) > 2 || len(fields) == 2 && fields[1] != info.Symbol {
+		return nil, ErrorInvalidAmount
+	}
+	return ParseAmount(fields[0], info.Decimals)
+}
+
+// FormatAmount presents a raw amount with decimals fractional digits,
+// without trailing zeros, e.g. "1.5" for 15e17 with 18 decimals.
+func FormatAmount(amount *big.Int, decimals uint8) string {
+	digits := new(big.Int).Abs(amount).String()
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+	text := digits[:len(digits)-int(decimals)]
+	if fraction := strings.TrimRight(digits[len(digits)-int(decimals):], "0"); fraction != "" {
+		text += "." + fraction
+	}
+	if amount.Sign() == -1 {
+		text = "-" + text
+	}
+	return text
+}
+
+// ParseAmount reads a non-negative decimal number of at most decimals
+// fractional digits, e.g. "1.5", into a raw amount with decimals digits,
+// 15e17 for 18 decimals. It fails with ErrorInvalidAmount otherwise.
+func ParseAmount(text string, decimals uint8) (*big.Int, error) {
+	whole, fraction := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		whole, fraction = text[:i], text[i+1:]
+	}
+	if whole == "" && fraction == "" || len(fraction) > int(decimals) || strings.ContainsAny(whole+fraction, "+-") {
+		return nil, ErrorInvalidAmount
+	}
+	amount, ok := new(big.Int).SetString(whole+fraction+strings.Repeat("0", int(decimals)-len(fraction)), 10)
+	if !ok {
+		return nil, ErrorInvalidAmount
+	}
+	return amount, nil
+}
+
+// Price is the spot price of one unit of token0 of the view in units of
+// token1, for tokens of decimals0 and decimals1 decimals.
+func (p *Pair) Price(decimals0, decimals1 uint8) (*big.Rat, error) {
+	if p.drained() {
+		return nil, ErrorInactivePair
+	}
+	reserve0, reserve1 := p.Reserves()
+	if reserve0.Sign() != 1 || reserve1.Sign() != 1 {
+		return nil, ErrorInsufficientLiquidity
+	}
+	price := new(big.Rat).SetFrac(reserve1, reserve0)
+	if decimals0 > decimals1 {
+		return price.Mul(price, new(big.Rat).SetInt(pow10(decimals0-decimals1))), nil
+	}
+	return price.Quo(price, new(big.Rat).SetInt(pow10(decimals1-decimals0))), nil
+}
+
+// UnitPrice is Price of the base/quote pair with the decimals of
+// RegisterToken, zero for unregistered tokens.
+func (s *UniswapV2) UnitPrice(base, quote Token) (*big.Rat, error) {
+	pair := s.Pair(base, quote)
+	if pair == nil {
+		return nil, ErrorPairNotExists
+	}
+	baseInfo, _ := s.TokenInfo(base)
+	quoteInfo, _ := s.TokenInfo(quote)
+	return pair.Price(baseInfo.Decimals, quoteInfo.Decimals)
+}
+
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// QuoteUnits is GetAmountsOut with the input and the output in units of
+// the first and last token of path, as FormatAmount presents them.
+func (r *Router) QuoteUnits(amountIn string, path []Token) (amountOut string, err error) {
+	if len(path) < 2 {
+		return "", ErrorInvalidPath
+	}
+	amount, err := r.service.ParseAmount(path[0], amountIn)
+	if err != nil {
+		return "", err
+	}
+	amounts, err := r.GetAmountsOut(amount, path)
+	if err != nil {
+		return "", err
+	}
+	return r.service.FormatAmount(path[len(path)-1], amounts[len(amounts)-1]), nil
+}
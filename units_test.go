@@ -0,0 +1,149 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_FormatAmount(t *testing.T) {
+	service := New()
+	service.RegisterToken(0, "WETH", 18)
+	service.RegisterToken(1, "USDC", 6)
+
+	for _, tt := range []struct {
+		token  Token
+		amount *big.Int
+		text   string
+	}{
+		{0, big.NewInt(15e17), "1.5 WETH"},
+		{0, big.NewInt(1), "0.000000000000000001 WETH"},
+		{0, big.NewInt(0), "0 WETH"},
+		{1, big.NewInt(2500e6), "2500 USDC"},
+		{2, big.NewInt(1234), "1234"},
+	} {
+		if text := service.FormatAmount(tt.token, tt.amount); text != tt.text {
+			t.Errorf("format %s want %q, got %q", tt.amount, tt.text, text)
+		}
+		amount, err := service.ParseAmount(tt.token, tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amount.Cmp(tt.amount) != 0 {
+			t.Errorf("parse %q want %s, got %s", tt.text, tt.amount, amount)
+		}
+	}
+	if amount, err := service.ParseAmount(1, ".25"); err != nil || amount.Cmp(big.NewInt(25e4)) != 0 {
+		t.Errorf("parse .25 want 250000, got %v, %v", amount, err)
+	}
+	for _, text := range []string{"1.0000001 USDC", "1 WETH", "-1", "", "1.2.3", "."} {
+		if _, err := service.ParseAmount(1, text); err != ErrorInvalidAmount {
+			t.Errorf("parse %q failed with %v; want error %v", text, err, ErrorInvalidAmount)
+		}
+	}
+}
+
+func TestRouter_QuoteUnits(t *testing.T) {
+	service := New()
+	service.RegisterToken(0, "WETH", 18)
+	service.RegisterToken(1, "USDC", 6)
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(1000e15), big.NewInt(2000e6)); err != nil {
+		t.Fatal(err)
+	}
+
+	router := NewRouter(service)
+	amounts, err := router.GetAmountsOut(big.NewInt(1e16), []Token{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	amountOut, err := router.QuoteUnits("0.01 WETH", []Token{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := service.FormatAmount(1, amounts[1]); amountOut != want {
+		t.Errorf("amount out want %q, got %q", want, amountOut)
+	}
+
+	var buf bytes.Buffer
+	if err := service.ExportJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	imported := New()
+	if err := imported.ImportJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if info, ok := imported.TokenInfo(1); !ok || info != (TokenInfo{Symbol: "USDC", Decimals: 6}) {
+		t.Errorf("imported token 1 want USDC of 6 decimals, got %+v", info)
+	}
+	if info, ok := service.Clone().TokenInfo(0); !ok || info.Symbol != "WETH" {
+		t.Errorf("cloned token 0 want WETH, got %+v", info)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for _, tt := range []struct {
+		text     string
+		decimals uint8
+		amount   *big.Int
+	}{
+		{"1.5", 18, big.NewInt(15e17)},
+		{"1", 0, big.NewInt(1)},
+		{"0.000001", 6, big.NewInt(1)},
+		{"1234.5", 2, big.NewInt(123450)},
+	} {
+		amount, err := ParseAmount(tt.text, tt.decimals)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if amount.Cmp(tt.amount) != 0 {
+			t.Errorf("parse %q want %s, got %s", tt.text, tt.amount, amount)
+		}
+		if text := FormatAmount(amount, tt.decimals); text != tt.text {
+			t.Errorf("format %s want %q, got %q", amount, tt.text, text)
+		}
+	}
+	if _, err := ParseAmount("1.5", 0); err != ErrorInvalidAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInvalidAmount)
+	}
+	if text := FormatAmount(big.NewInt(-25), 2); text != "-0.25" {
+		t.Errorf("format -25 want %q, got %q", "-0.25", text)
+	}
+}
+
+func TestPair_Price(t *testing.T) {
+	service := New()
+	service.RegisterToken(0, "WETH", 18)
+	service.RegisterToken(1, "USDC", 6)
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pair.Price(18, 6); err != ErrorInsufficientLiquidity {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidity)
+	}
+	if _, err := pair.Mint("alice", big.NewInt(2e18), big.NewInt(5000e6)); err != nil {
+		t.Fatal(err)
+	}
+
+	price, err := service.UnitPrice(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Cmp(big.NewRat(2500, 1)) != 0 {
+		t.Errorf("price of WETH want 2500, got %s", price.RatString())
+	}
+	price, err = service.UnitPrice(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price.Cmp(big.NewRat(1, 2500)) != 0 {
+		t.Errorf("price of USDC want 1/2500, got %s", price.RatString())
+	}
+	if _, err := service.UnitPrice(0, 2); err != ErrorPairNotExists {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+}
@@ -0,0 +1,148 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+)
+
+type Upgrade func(s *UniswapV2) error
+
+type Validation func(s *UniswapV2) error
+
+var (
+	ErrorInvalidReserves    = errors.New("INVALID_RESERVES")
+	ErrorInvalidTotalSupply = errors.New("INVALID_TOTAL_SUPPLY")
+)
+
+func ValidateReserves(s *UniswapV2) error {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	for _, key := range s.sortedKeys() {
+		pair := s.pairs[key]
+		reserve0, reserve1 := pair.Reserves()
+		if reserve0.Sign() == -1 || reserve1.Sign() == -1 {
+			return ErrorInvalidReserves
+		}
+	}
+	return nil
+}
+
+func ValidateTotalSupply(s *UniswapV2) error {
+	s.muPairs.RLock()
+	defer s.muPairs.RUnlock()
+
+	for _, key := range s.sortedKeys() {
+		pair := s.pairs[key]
+		sum := big.NewInt(0)
+		pair.muBalance.RLock()
+		for _, balance := range pair.balances {
+			sum.Add(sum, balance)
+		}
+		pair.muBalance.RUnlock()
+		if sum.Cmp(pair.TotalSupply()) != 0 {
+			return ErrorInvalidTotalSupply
+		}
+	}
+	return nil
+}
+
+type BalanceDiff struct {
+	Address       Address
+	Before, After *big.Int
+}
+
+type PairDiff struct {
+	Key                                 pairKey
+	Reserve0Before, Reserve0After       *big.Int
+	Reserve1Before, Reserve1After       *big.Int
+	TotalSupplyBefore, TotalSupplyAfter *big.Int
+	Balances                            []BalanceDiff
+}
+
+type UpgradeReport struct {
+	Created  []pairKey
+	Removed  []pairKey
+	Changed  []PairDiff
+	Failures []error
+}
+
+func (r *UpgradeReport) Ok() bool {
+	return len(r.Failures) == 0
+}
+
+// SimulateUpgrade applies upgrade to a deep copy of the service, runs the
+// validations against the result and reports how the state would change.
+// The service itself is never modified.
+func (s *UniswapV2) SimulateUpgrade(upgrade Upgrade, validations ...Validation) (*UpgradeReport, error) {
+	before := s.clone()
+	after := before.clone()
+
+	if err := upgrade(after); err != nil {
+		return nil, err
+	}
+
+	report := &UpgradeReport{}
+	for _, validation := range validations {
+		if err := validation(after); err != nil {
+			report.Failures = append(report.Failures, err)
+		}
+	}
+
+	for _, key := range before.sortedKeys() {
+		pairAfter, ok := after.pairs[key]
+		if !ok {
+			report.Removed = append(report.Removed, key)
+			continue
+		}
+		if diff, changed := diffPair(key, before.pairs[key], pairAfter); changed {
+			report.Changed = append(report.Changed, diff)
+		}
+	}
+	for _, key := range after.sortedKeys() {
+		if _, ok := before.pairs[key]; !ok {
+			report.Created = append(report.Created, key)
+		}
+	}
+
+	return report, nil
+}
+
+func diffPair(key pairKey, before, after *Pair) (PairDiff, bool) {
+	diff := PairDiff{Key: key}
+	diff.Reserve0Before, diff.Reserve1Before = before.Reserves()
+	diff.Reserve0After, diff.Reserve1After = after.Reserves()
+	diff.TotalSupplyBefore, diff.TotalSupplyAfter = before.TotalSupply(), after.TotalSupply()
+
+	changed := diff.Reserve0Before.Cmp(diff.Reserve0After) != 0 ||
+		diff.Reserve1Before.Cmp(diff.Reserve1After) != 0 ||
+		diff.TotalSupplyBefore.Cmp(diff.TotalSupplyAfter) != 0
+
+	addresses := map[Address]struct{}{}
+	for address := range before.balances {
+		addresses[address] = struct{}{}
+	}
+	for address := range after.balances {
+		addresses[address] = struct{}{}
+	}
+	sorted := make([]Address, 0, len(addresses))
+	for address := range addresses {
+		sorted = append(sorted, address)
+	}
+	sortAddresses(sorted)
+
+	for _, address := range sorted {
+		balanceBefore, balanceAfter := before.Balance(address), after.Balance(address)
+		if balanceBefore == nil {
+			balanceBefore = big.NewInt(0)
+		}
+		if balanceAfter == nil {
+			balanceAfter = big.NewInt(0)
+		}
+		if balanceBefore.Cmp(balanceAfter) != 0 {
+			diff.Balances = append(diff.Balances, BalanceDiff{Address: address, Before: balanceBefore, After: balanceAfter})
+		}
+	}
+
+	return diff, changed || len(diff.Balances) != 0
+}
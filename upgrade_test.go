@@ -0,0 +1,66 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_SimulateUpgrade(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("address", big.NewInt(1e18), big.NewInt(2e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := service.SimulateUpgrade(func(s *UniswapV2) error {
+		_, _, err := s.Pair(0, 1).Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1e17))
+		if err != nil {
+			return err
+		}
+		_, err = s.CreatePair(1, 2)
+		return err
+	}, ValidateReserves, ValidateTotalSupply)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Ok() {
+		t.Errorf("failures want none, got %v", report.Failures)
+	}
+	if len(report.Created) != 1 || report.Created[0] != (pairKey{TokenA: 1, TokenB: 2}) {
+		t.Errorf("created want %v, got %v", []pairKey{{TokenA: 1, TokenB: 2}}, report.Created)
+	}
+	if len(report.Changed) != 1 {
+		t.Fatalf("changed want 1 pair, got %d", len(report.Changed))
+	}
+	diff := report.Changed[0]
+	if diff.Reserve0After.Cmp(big.NewInt(11e17)) != 0 {
+		t.Errorf("reserve0 want %s, got %s", big.NewInt(11e17), diff.Reserve0After)
+	}
+	if len(diff.Balances) != 0 {
+		t.Errorf("balances diff want none, got %v", diff.Balances)
+	}
+
+	reserve0, _ := pair.Reserves()
+	if reserve0.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("live reserve0 want %s, got %s", big.NewInt(1e18), reserve0)
+	}
+	if service.Pair(1, 2) != nil {
+		t.Error("live service has simulated pair")
+	}
+
+	report, err = service.SimulateUpgrade(func(s *UniswapV2) error {
+		s.Pair(0, 1).totalSupply.SetInt64(0)
+		return nil
+	}, ValidateTotalSupply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failures) != 1 || report.Failures[0] != ErrorInvalidTotalSupply {
+		t.Errorf("failures want %v, got %v", ErrorInvalidTotalSupply, report.Failures)
+	}
+}
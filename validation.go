@@ -0,0 +1,52 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrorNilAmount      = errors.New("NIL_AMOUNT")
+	ErrorNegativeAmount = errors.New("NEGATIVE_AMOUNT")
+	ErrorZeroAmount     = errors.New("ZERO_AMOUNT")
+)
+
+// checkAmounts rejects nil and negative amounts, which the arithmetic of the
+// operations does not expect, before anything else looks at them.
+func checkAmounts(amounts ...*big.Int) error {
+	for _, amount := range amounts {
+		if amount == nil {
+			return ErrorNilAmount
+		}
+		if amount.Sign() == -1 {
+			return ErrorNegativeAmount
+		}
+	}
+	return nil
+}
+
+// checkPrices is checkAmounts for the prices of the valuation functions.
+func checkPrices(prices ...*big.Rat) error {
+	for _, price := range prices {
+		if price == nil {
+			return ErrorNilAmount
+		}
+		if price.Sign() == -1 {
+			return ErrorNegativeAmount
+		}
+	}
+	return nil
+}
+
+// checkPositive is checkAmounts rejecting zero amounts too.
+func checkPositive(amounts ...*big.Int) error {
+	if err := checkAmounts(amounts...); err != nil {
+		return err
+	}
+	for _, amount := range amounts {
+		if amount.Sign() == 0 {
+			return ErrorZeroAmount
+		}
+	}
+	return nil
+}
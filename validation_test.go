@@ -0,0 +1,74 @@
+package uniswapV2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestPair_validation(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tt := range []struct {
+		do  func() error
+		err error
+	}{
+		{func() error { _, err := pair.Mint("alice", nil, big.NewInt(1)); return err }, ErrorNilAmount},
+		{func() error { _, err := pair.Mint("alice", big.NewInt(-1), big.NewInt(1)); return err }, ErrorNegativeAmount},
+		{func() error { _, err := pair.Mint("alice", big.NewInt(0), big.NewInt(1e18)); return err }, ErrorZeroAmount},
+		{func() error { _, _, err := pair.Burn("alice", nil); return err }, ErrorNilAmount},
+		{func() error { _, _, err := pair.Burn("alice", big.NewInt(-1)); return err }, ErrorNegativeAmount},
+		{func() error { _, _, err := pair.Burn("alice", big.NewInt(0)); return err }, ErrorZeroAmount},
+		{func() error {
+			_, _, err := pair.Swap(nil, big.NewInt(0), big.NewInt(0), big.NewInt(1))
+			return err
+		}, ErrorNilAmount},
+		{func() error {
+			_, _, err := pair.Swap(big.NewInt(-1e17), big.NewInt(0), big.NewInt(0), big.NewInt(1))
+			return err
+		}, ErrorNegativeAmount},
+		{func() error {
+			_, _, err := pair.Swap(big.NewInt(1e17), big.NewInt(0), big.NewInt(-1e17), big.NewInt(1))
+			return err
+		}, ErrorNegativeAmount},
+		{func() error { _, err := pair.GetAmountOut(big.NewInt(-1)); return err }, ErrorNegativeAmount},
+		{func() error { return pair.Sync(nil, big.NewInt(1)) }, ErrorNilAmount},
+		{func() error { return pair.Approve("alice", "bob", nil) }, ErrorNilAmount},
+		{func() error { _, err := NewRouter(service).Swap(big.NewInt(-1), []Token{0, 1}); return err }, ErrorNegativeAmount},
+		{func() error {
+			_, err := NewRouter(service).SwapExactTokensForTokens(big.NewInt(1e15), nil, []Token{0, 1})
+			return err
+		}, ErrorNilAmount},
+		{func() error { _, err := pair.DepthCurve([]*big.Int{big.NewInt(1), nil}, true); return err }, ErrorNilAmount},
+		{func() error { _, err := pair.PriceImpact(nil, true); return err }, ErrorNilAmount},
+		{func() error { _, err := pair.PriceImpact(big.NewInt(-1), false); return err }, ErrorNegativeAmount},
+		{func() error { _, err := pair.SpotValue(nil, big.NewRat(1, 1), big.NewRat(1, 1)); return err }, ErrorNilAmount},
+		{func() error { _, err := pair.SpotValue(big.NewInt(1), nil, big.NewRat(1, 1)); return err }, ErrorNilAmount},
+		{func() error { _, err := pair.FairValue(nil, big.NewRat(1, 1), big.NewRat(1, 1)); return err }, ErrorNilAmount},
+		{func() error { _, err := pair.FairValue(big.NewInt(1), big.NewRat(1, 1), big.NewRat(-1, 1)); return err }, ErrorNegativeAmount},
+		{func() error {
+			_, err := pair.MaxSafeBorrow(big.NewInt(1), big.NewRat(1, 1), big.NewRat(1, 1), nil)
+			return err
+		}, ErrorNilAmount},
+		{func() error { _, _, err := service.FindBestPath(0, 1, nil, 2); return err }, ErrorNilAmount},
+		{func() error { _, err := service.QuoteOut(0, 1, nil); return err }, ErrorNilAmount},
+		{func() error { local, _ := service.CompareQuotes(0, 1, nil); return local.Err }, ErrorNilAmount},
+	} {
+		if err := tt.do(); !errors.Is(err, tt.err) {
+			t.Errorf("%d: failed with %v; want error %v", i, err, tt.err)
+		}
+	}
+
+	reserve0, reserve1 := pair.Reserves()
+	if reserve0.Cmp(big.NewInt(1e18)) != 0 || reserve1.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("reserves want %s, %s, got %s, %s", big.NewInt(1e18), big.NewInt(1e18), reserve0, reserve1)
+	}
+}
@@ -0,0 +1,76 @@
+package uniswapV2
+
+import (
+	"math/big"
+)
+
+// PairView is the read-only part of a Pair.
+type PairView interface {
+	Reserves() (reserve0, reserve1 *big.Int)
+	TotalSupply() *big.Int
+	Balance(address Address) *big.Int
+	Allowance(owner, spender Address) *big.Int
+	ForEachBalance(fn func(address Address, liquidity *big.Int) bool)
+	HoldersCount() int
+	TopHolders(n int) []Holder
+	IsActive() bool
+	CumulativePrices() (price0Cumulative, price1Cumulative *big.Int, blockTimestamp uint32)
+	Amounts(liquidity *big.Int) (amount0, amount1 *big.Int)
+	Quote(amount0 *big.Int) (amount1 *big.Int, err error)
+	GetAmountOut(amountIn *big.Int) (amountOut *big.Int, err error)
+	GetAmountIn(amountOut *big.Int) (amountIn *big.Int, err error)
+	SimulateSwap(amount0In, amount1In, amount0Out, amount1Out *big.Int) (amount0, amount1 *big.Int, err error)
+	SimulateMint(address Address, amount0, amount1 *big.Int) (liquidity *big.Int, err error)
+	SimulateBurn(address Address, liquidity *big.Int) (amount0, amount1 *big.Int, err error)
+	Metadata(key string) (value string, ok bool)
+	MetadataKeys() []string
+}
+
+// View is the read-only part of UniswapV2, for read paths such as RPC
+// handlers that must not change the state.
+type View interface {
+	// Pair and PairWithFee return nil if the pair does not exist.
+	Pair(coinA, coinB Token) PairView
+	PairWithFee(coinA, coinB Token, feeBps uint32) PairView
+	Pairs() ([]pairKey, error)
+	SortedPairs() []pairKey
+	PairsPage(offset, limit int) []pairKey
+	PairsCount() int
+	PairsByToken(token Token) []pairKey
+	PositionsOf(address Address) []pairKey
+	FindBestPath(tokenIn, tokenOut Token, amountIn *big.Int, maxHops int) (path []Token, amountOut *big.Int, err error)
+	QuoteOut(tokenIn, tokenOut Token, amountIn *big.Int) (amountOut *big.Int, err error)
+	Height() uint64
+	PairAt(coinA, coinB Token, height uint64) (reserve0, reserve1, totalSupply *big.Int, err error)
+	StateRoot() ([32]byte, error)
+}
+
+var (
+	_ PairView = (*Pair)(nil)
+	_ View     = readOnly{}
+)
+
+// readOnly narrows the service to View. Being unexported, it cannot be
+// converted back outside the package.
+type readOnly struct {
+	*UniswapV2
+}
+
+// ReadOnly returns the service as a View.
+func (s *UniswapV2) ReadOnly() View {
+	return readOnly{s}
+}
+
+func (r readOnly) Pair(coinA, coinB Token) PairView {
+	if pair := r.UniswapV2.Pair(coinA, coinB); pair != nil {
+		return pair
+	}
+	return nil
+}
+
+func (r readOnly) PairWithFee(coinA, coinB Token, feeBps uint32) PairView {
+	if pair := r.UniswapV2.PairWithFee(coinA, coinB, feeBps); pair != nil {
+		return pair
+	}
+	return nil
+}
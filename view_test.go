@@ -0,0 +1,40 @@
+package uniswapV2
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestUniswapV2_ReadOnly(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	view := service.ReadOnly()
+	if view.Pair(1, 2) != nil || view.PairWithFee(0, 1, 5) != nil {
+		t.Fatal("missing pairs want nil")
+	}
+	reserve1, reserve0 := view.Pair(1, 0).Reserves()
+	if reserve0.Cmp(big.NewInt(1e18)) != 0 || reserve1.Cmp(big.NewInt(4e18)) != 0 {
+		t.Errorf("reserves want 1e18, 4e18, got %s, %s", reserve0, reserve1)
+	}
+	if balance := view.Pair(0, 1).Balance("alice"); balance.Cmp(pair.Balance("alice")) != 0 {
+		t.Errorf("balance want %s, got %s", pair.Balance("alice"), balance)
+	}
+	if view.PairsCount() != 1 {
+		t.Errorf("pairs want 1, got %d", view.PairsCount())
+	}
+	amountOut, err := view.QuoteOut(0, 1, big.NewInt(1e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := pair.GetAmountOut(big.NewInt(1e16)); amountOut.Cmp(want) != 0 {
+		t.Errorf("amount out want %s, got %s", want, amountOut)
+	}
+}
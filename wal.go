@@ -0,0 +1,270 @@
+package uniswapV2
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// walRecord is one line of the write-ahead log. Tokens and amounts are in
+// the order of the pair view the operation was called on.
+type walRecord struct {
+	Time      int64    `json:"time"`
+	Operation string   `json:"operation"`
+	Address   Address  `json:"address,omitempty"`
+	From      Address  `json:"from,omitempty"`
+	To        Address  `json:"to,omitempty"`
+	Token0    Token    `json:"token0"`
+	Token1    Token    `json:"token1"`
+	FeeTier   uint32   `json:"fee_tier"`
+	ToTier    uint32   `json:"to_tier,omitempty"`
+	Fee       *Fee     `json:"fee,omitempty"`
+	Amounts   []string `json:"amounts,omitempty"`
+	Height    uint64   `json:"height,omitempty"`
+	Snapshot  StateID  `json:"snapshot,omitempty"`
+}
+
+type writeAheadLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// WithWAL writes a JSON line to w for every change of the state before it is
+// made: CreatePair, RemovePair, Mint, Burn, Swap, Sync, Approve, Permit,
+// TransferFrom and MigratePair, as well as Commit, Discard, Snapshot and
+// Revert. An operation whose record cannot be written fails with the write
+// error. Multi-step operations of the Router and Execute log the steps they
+// undo when they fail, and DeliverOp logs where its operation begins and
+// whether it ends or is undone; those records, like the ones of Commit,
+// Discard and Snapshot, which cannot fail, are written on a best effort basis
+// and a write error is logged at LogError. Replaying the log with Recover on
+// top of the state the log was started from restores the state the
+// operations left. CreatePairs, imports and metadata are not logged, and
+// operations on one pair must not run concurrently for the log to keep their
+// order.
+func WithWAL(w io.Writer) Option {
+	return func(o *options) {
+		o.wal = &writeAheadLog{w: w}
+	}
+}
+
+func (s *UniswapV2) writeWAL(record walRecord) error {
+	if s.wal == nil {
+		return nil
+	}
+	record.Time = s.now().UnixNano()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.wal.mu.Lock()
+	defer s.wal.mu.Unlock()
+	_, err = s.wal.w.Write(append(data, '\n'))
+	return err
+}
+
+// writeWALBestEffort writes record for an operation that cannot fail,
+// logging a write error instead of returning it.
+func (s *UniswapV2) writeWALBestEffort(record walRecord) {
+	err := s.writeWAL(record)
+	if err != nil && s.logs(LogError) {
+		s.logger.Log(LogError, "wal", LogField{"operation", record.Operation}, LogField{"error", err.Error()})
+	}
+}
+
+func (p *Pair) writeWAL(operation string, address Address, amounts ...*big.Int) error {
+	if p.service.wal == nil {
+		return nil
+	}
+	return p.service.writeWAL(p.walRecord(operation, address, amounts...))
+}
+
+func (p *Pair) walRecord(operation string, address Address, amounts ...*big.Int) walRecord {
+	record := walRecord{Operation: operation, Address: address, Token0: p.key.TokenA, Token1: p.key.TokenB, FeeTier: p.key.Fee}
+	for _, amount := range amounts {
+		record.Amounts = append(record.Amounts, amount.String())
+	}
+	return record
+}
+
+// rollbackUpdate, rollbackMint and rollbackBurn undo the changes of a failed
+// multi-step operation, logging them so that Recover undoes them too.
+func (p *Pair) rollbackUpdate(amount0, amount1 *big.Int) {
+	if p.service.wal != nil {
+		p.service.writeWALBestEffort(p.walRecord("rollback_update", addressZero, amount0, amount1))
+	}
+	p.update(amount0, amount1)
+}
+
+func (p *Pair) rollbackMint(address Address, liquidity *big.Int) {
+	if p.service.wal != nil {
+		p.service.writeWALBestEffort(p.walRecord("rollback_mint", address, liquidity))
+	}
+	p.mint(address, liquidity)
+}
+
+func (p *Pair) rollbackBurn(address Address, liquidity *big.Int) {
+	if p.service.wal != nil {
+		p.service.writeWALBestEffort(p.walRecord("rollback_burn", address, liquidity))
+	}
+	p.burn(address, liquidity)
+}
+
+// Recover replays a log written with WithWAL, with the clock of every
+// operation set to the time it was logged. A last line cut short by a crash
+// is ignored, and so is an operation of DeliverOp the crash cut short.
+// Recover must run before any other operation; the replayed operations are
+// not logged again.
+func (s *UniswapV2) Recover(r io.Reader) error {
+	wal, clock := s.wal, s.clock
+	defer func() { s.wal, s.clock = wal, clock }()
+	s.wal = nil
+	defer s.endOp(false)
+
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var record walRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("wal line %d: %w", line, err)
+		}
+		s.clock = func() time.Time { return time.Unix(0, record.Time) }
+		if _, err := s.replay(record); err != nil {
+			return fmt.Errorf("wal line %d: %w", line, err)
+		}
+	}
+}
+
+// replay applies the operation of record and returns its results: the
+// liquidity of mint, the amounts of burn and swap and nothing for the
+// others.
+func (s *UniswapV2) replay(record walRecord) ([]*big.Int, error) {
+	parse := parseAmount
+	if record.Operation == "rollback_update" {
+		parse = parseSignedAmount
+	}
+	amounts := make([]*big.Int, len(record.Amounts))
+	for i, text := range record.Amounts {
+		amount, err := parse(text)
+		if err != nil {
+			return nil, err
+		}
+		amounts[i] = amount
+	}
+
+	switch record.Operation {
+	case "create_pair":
+		if record.Fee == nil {
+			return nil, ErrorInvalidFee
+		}
+		_, err := s.createPair(pairKey{TokenA: record.Token0, TokenB: record.Token1, Fee: record.FeeTier}, pairOptions{fee: *record.Fee})
+		return nil, err
+	case "remove_pair":
+		return nil, s.removePairKey(nil, pairKey{TokenA: record.Token0, TokenB: record.Token1, Fee: record.FeeTier})
+	case "migrate":
+		_, err := s.migratePair(record.Token0, record.Token1, record.FeeTier, record.ToTier, nil)
+		return nil, err
+	case "commit":
+		s.muPairs.Lock()
+		defer s.muPairs.Unlock()
+		return nil, s.commit(discardWriter{}, record.Height)
+	case "discard":
+		s.Discard()
+		return nil, nil
+	case "snapshot":
+		if id := s.Snapshot(); id != record.Snapshot {
+			return nil, ErrorUnknownSnapshot
+		}
+		return nil, nil
+	case "revert":
+		return nil, s.Revert(record.Snapshot)
+	case "release":
+		// written by DeliverOp before it kept a journal
+		return nil, s.releaseSnapshot(record.Snapshot)
+	case "begin_op":
+		s.beginOp()
+		return nil, nil
+	case "revert_op", "end_op":
+		s.endOp(record.Operation == "end_op")
+		return nil, nil
+	}
+
+	pair := s.PairWithFee(record.Token0, record.Token1, record.FeeTier)
+	if pair == nil {
+		return nil, ErrorPairNotExists
+	}
+	// the records hold canonical addresses, unless NormalizeAddress changed
+	// since they were written
+	for _, address := range []*Address{&record.Address, &record.From, &record.To} {
+		if *address == addressZero {
+			continue
+		}
+		if err := normalizeAddresses(address); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case record.Operation == "mint" && len(amounts) == 2:
+		liquidity, err := pair.Mint(record.Address, amounts[0], amounts[1])
+		return []*big.Int{liquidity}, err
+	case record.Operation == "burn" && len(amounts) == 1:
+		amount0, amount1, err := pair.Burn(record.Address, amounts[0])
+		return []*big.Int{amount0, amount1}, err
+	case record.Operation == "swap" && len(amounts) == 4:
+		amount0, amount1, err := pair.Swap(amounts[0], amounts[1], amounts[2], amounts[3])
+		return []*big.Int{amount0, amount1}, err
+	case record.Operation == "sync" && len(amounts) == 2:
+		return nil, pair.Sync(amounts[0], amounts[1])
+	case record.Operation == "approve" && len(amounts) == 1:
+		return nil, pair.Approve(record.Address, record.To, amounts[0])
+	case record.Operation == "permit" && len(amounts) == 1:
+		pair.muBalance.Lock()
+		pair.nonces[record.Address]++
+		pair.approve(record.Address, record.To, amounts[0])
+		pair.muBalance.Unlock()
+		return nil, nil
+	case record.Operation == "transfer_from" && len(amounts) == 1:
+		return nil, pair.TransferFrom(record.Address, record.From, record.To, amounts[0])
+	case record.Operation == "rollback_update" && len(amounts) == 2:
+		pair.update(amounts[0], amounts[1])
+		return nil, nil
+	case record.Operation == "rollback_mint" && len(amounts) == 1:
+		pair.mint(record.Address, amounts[0])
+		return nil, nil
+	case record.Operation == "rollback_burn" && len(amounts) == 1:
+		pair.burn(record.Address, amounts[0])
+		return nil, nil
+	}
+	return nil, fmt.Errorf("invalid operation %q", record.Operation)
+}
+
+func parseSignedAmount(text string) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(text, 10)
+	if !ok {
+		return nil, ErrorInvalidExport
+	}
+	return amount, nil
+}
+
+// discardWriter is the StateWriter of replayed commits, whose changes were
+// written when they were logged.
+type discardWriter struct{}
+
+func (discardWriter) WritePair(PairState) error {
+	return nil
+}
+
+func (discardWriter) WriteBalances(Token, Token, uint32, map[Address]*big.Int) error {
+	return nil
+}
@@ -0,0 +1,239 @@
+package uniswapV2
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestUniswapV2_Recover(t *testing.T) {
+	var log bytes.Buffer
+	clock := NewSimulatedClock(time.Unix(1600000000, 0))
+	service := New(WithWAL(&log), WithClock(clock.Now))
+
+	pair, err := service.CreatePair(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tier, err := service.CreatePairWithFee(0, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tier.Mint("bob", big.NewInt(1e18), big.NewInt(1e18))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute, time.Minute)
+	_, _, err = pair.Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(3e16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute, time.Minute)
+	_, _, err = pair.Burn("alice", big.NewInt(1e17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tier.Sync(big.NewInt(2e18), big.NewInt(1e18)); err != nil {
+		t.Fatal(err)
+	}
+	// failed operations are not logged
+	if _, _, err := pair.Burn("carol", big.NewInt(1)); !errors.Is(err, ErrorInsufficientLiquidityBurned) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidityBurned)
+	}
+
+	// a record torn by a crash is ignored
+	log.WriteString(`{"time":1600000200,"operation":"sw`)
+
+	recovered := New()
+	if err := recovered.Recover(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	var want, got bytes.Buffer
+	if err := service.ExportJSON(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := recovered.ExportJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Errorf("state want\n%s\ngot\n%s", want.String(), got.String())
+	}
+
+	if err := New().Recover(bytes.NewReader(log.Bytes()[bytes.IndexByte(log.Bytes(), '\n')+1:])); !errors.Is(err, ErrorPairNotExists) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorPairNotExists)
+	}
+}
+
+func TestUniswapV2_Recover_rollbacks(t *testing.T) {
+	var log bytes.Buffer
+	clock := NewSimulatedClock(time.Unix(1600000000, 0))
+	service := New(WithWAL(&log), WithClock(clock.Now))
+	router := NewRouter(service)
+
+	_, _, _, err := router.AddLiquidity(0, 1, big.NewInt(1e18), big.NewInt(4e18), big.NewInt(0), big.NewInt(0), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, err = router.AddLiquidity(1, 2, big.NewInt(1e18), big.NewInt(1e18), big.NewInt(0), big.NewInt(0), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute, time.Minute)
+
+	// the swap is applied and undone when the burn by nobody fails
+	err = service.Execute([]Operation{
+		{Kind: OperationCreatePair, TokenA: 2, TokenB: 3},
+		{Kind: OperationSwap, TokenA: 0, TokenB: 1, AmountAIn: big.NewInt(1e16), AmountBOut: big.NewInt(3e16)},
+		{Kind: OperationMint, TokenA: 1, TokenB: 2, Address: "bob", AmountAIn: big.NewInt(1e17), AmountBIn: big.NewInt(1e17)},
+		{Kind: OperationBurn, TokenA: 0, TokenB: 1, Address: "nobody", Liquidity: big.NewInt(1)},
+	})
+	if !errors.Is(err, ErrorInsufficientLiquidityBurned) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientLiquidityBurned)
+	}
+	_, _, err = router.RemoveLiquidity(1, 0, big.NewInt(1e17), big.NewInt(0), big.NewInt(1e18), "alice")
+	if err != ErrorInsufficientBAmount {
+		t.Fatalf("failed with %v; want error %v", err, ErrorInsufficientBAmount)
+	}
+	clock.Advance(time.Minute, time.Minute)
+
+	pair := service.Pair(0, 1)
+	if err := pair.Approve("alice", "carol", big.NewInt(1e17)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.TransferFrom("carol", "alice", "dave", big.NewInt(4e16)); err != nil {
+		t.Fatal(err)
+	}
+	domain, err := NewPermitDomain("uniswapV2", 1, testVerify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := testSign("dave", pair.PermitDigest(domain, "dave", "carol", big.NewInt(1e16), 0, 1700000000))
+	if err := pair.Permit(domain, "dave", "carol", big.NewInt(1e16), 1700000000, sig); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Commit(&recordingWriter{}); err != nil {
+		t.Fatal(err)
+	}
+
+	id := service.Snapshot()
+	if _, _, err := pair.Swap(big.NewInt(1e16), big.NewInt(0), big.NewInt(0), big.NewInt(3e16)); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Revert(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := pair.Sync(big.NewInt(2e18), big.NewInt(4e18)); err != nil {
+		t.Fatal(err)
+	}
+	service.Discard()
+	if _, err := service.CreatePair(4, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RemovePair(5, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := New()
+	if err := recovered.Recover(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	var want, got bytes.Buffer
+	if err := service.ExportJSON(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := recovered.ExportJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Errorf("state want\n%s\ngot\n%s", want.String(), got.String())
+	}
+	if stateRoot(t, service) != stateRoot(t, recovered) {
+		t.Error("state roots differ")
+	}
+	recoveredPair := recovered.Pair(0, 1)
+	if allowance := recoveredPair.Allowance("alice", "carol"); allowance.Cmp(big.NewInt(6e16)) != 0 {
+		t.Errorf("allowance want %d, got %s", int64(6e16), allowance)
+	}
+	if nonce := recoveredPair.Nonces("dave"); nonce != 1 {
+		t.Errorf("nonce want 1, got %d", nonce)
+	}
+}
+
+func TestUniswapV2_Recover_deliverOp(t *testing.T) {
+	var log bytes.Buffer
+	service := New(WithWAL(&log))
+	if err := service.BeginBlock(1, time.Unix(1600000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	err := service.DeliverOp(func(s *UniswapV2) error {
+		pair, err := s.CreatePair(0, 1)
+		if err != nil {
+			return err
+		}
+		_, err = pair.Mint("alice", big.NewInt(1e18), big.NewInt(4e18))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = service.DeliverOp(func(s *UniswapV2) error {
+		if _, err := s.Pair(0, 1).Mint("bob", big.NewInt(1e17), big.NewInt(4e17)); err != nil {
+			return err
+		}
+		_, err := s.Pair(0, 1).Mint("bob", nil, nil)
+		return err
+	})
+	if !errors.Is(err, ErrorNilAmount) {
+		t.Fatalf("failed with %v; want error %v", err, ErrorNilAmount)
+	}
+	if _, err := service.EndBlock(&recordingWriter{}); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := New()
+	if err := recovered.Recover(bytes.NewReader(log.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if stateRoot(t, service) != stateRoot(t, recovered) {
+		t.Error("state roots differ")
+	}
+	if recovered.Pair(0, 1).Balance("bob") != nil {
+		t.Error("mint of the failed op is replayed")
+	}
+	if len(recovered.snapshots) != 0 {
+		t.Errorf("snapshots want none, got %d", len(recovered.snapshots))
+	}
+}
+
+type failingWriter struct{}
+
+var errWrite = errors.New("write")
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
+
+func TestUniswapV2_WithWAL_writeError(t *testing.T) {
+	service := New()
+	pair, err := service.CreatePair(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.wal = &writeAheadLog{w: failingWriter{}}
+
+	if _, err := pair.Mint("alice", big.NewInt(1e18), big.NewInt(1e18)); !errors.Is(err, errWrite) {
+		t.Fatalf("failed with %v; want error %v", err, errWrite)
+	}
+	if pair.TotalSupply().Sign() != 0 {
+		t.Errorf("total supply want 0, got %s", pair.TotalSupply())
+	}
+	if _, err := service.CreatePair(1, 2); err != errWrite {
+		t.Fatalf("failed with %v; want error %v", err, errWrite)
+	}
+}